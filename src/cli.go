@@ -0,0 +1,167 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"gobrev/src/config"
+	"gobrev/src/storage"
+)
+
+// runCLI handles command line subcommands and returns the process exit code
+func runCLI(args []string) int {
+	cfg := config.LoadForCLI()
+
+	switch args[0] {
+	case "restore":
+		return cliRestore(cfg, args[1:])
+	case "encrypt":
+		return cliEncrypt(cfg, args[1:])
+	case "rotate-key":
+		return cliRotateKey(cfg, args[1:])
+	case "db":
+		return cliDB(cfg, args[1:])
+	case "help", "-h", "--help":
+		printUsage()
+		return 0
+	}
+
+	fmt.Fprintf(os.Stderr, "[-] Unknown command: %s\n\n", args[0])
+	printUsage()
+	return 2
+}
+
+// printUsage prints available subcommands
+func printUsage() {
+	fmt.Fprintf(os.Stderr, `Usage:
+  gobrev                                 start the bot
+  gobrev restore [--force] <file>        restore a backup into DB_PATH (bot must be stopped)
+  gobrev encrypt [--remove-plain]        encrypt an existing plaintext DB_PATH with the configured key
+  gobrev rotate-key --new-key-file <f>   re-encrypt DB_PATH with a new master key
+  gobrev db stats                        key counts and sizes per prefix
+  gobrev db dump [--prefix p] [--chat id] [--limit n]
+                                         print records as JSON lines
+  gobrev db delete-chat <id>             delete all stored data of a chat
+  gobrev db compact                      flatten the LSM tree and run value log GC
+`)
+}
+
+// openCLIStore opens the configured database for offline tools
+func openCLIStore(cfg *config.Config) (*storage.BadgerStore, error) {
+	badgerConfig, err := loadBadgerConfig(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("invalid storage configuration: %w", err)
+	}
+
+	store, err := storage.OpenBadger(cfg.DBPath, badgerConfig)
+	if err != nil {
+		return nil, fmt.Errorf("%w (is the bot still running?)", err)
+	}
+
+	return store, nil
+}
+
+// cliRestore restores a backup file into the configured database
+func cliRestore(cfg *config.Config, args []string) int {
+	fs := flag.NewFlagSet("restore", flag.ContinueOnError)
+	force := fs.Bool("force", false, "merge into a non-empty database")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 {
+		printUsage()
+		return 2
+	}
+
+	badgerConfig, err := loadBadgerConfig(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[-] Invalid storage configuration: %v\n", err)
+		return 1
+	}
+
+	file := fs.Arg(0)
+	fmt.Printf("[#] Restoring %s into %s\n", file, cfg.DBPath)
+
+	count, err := storage.RestoreBadger(cfg.DBPath, badgerConfig, file, *force)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[-] Restore failed: %v\n", err)
+		return 1
+	}
+
+	fmt.Printf("[+] Restore complete, database has %d keys\n", count)
+	return 0
+}
+
+// cliEncrypt migrates a plaintext database to an encrypted one
+func cliEncrypt(cfg *config.Config, args []string) int {
+	fs := flag.NewFlagSet("encrypt", flag.ContinueOnError)
+	removePlain := fs.Bool("remove-plain", false, "delete the plaintext copy after a successful migration")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	badgerConfig, err := loadBadgerConfig(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[-] Invalid storage configuration: %v\n", err)
+		return 1
+	}
+	if len(badgerConfig.EncryptionKey) == 0 {
+		fmt.Fprintf(os.Stderr, "[-] Set DB_ENCRYPTION_KEY or DB_ENCRYPTION_KEY_FILE first\n")
+		return 1
+	}
+
+	fmt.Printf("[#] Encrypting %s\n", cfg.DBPath)
+	plainPath, err := storage.EncryptBadger(cfg.DBPath, badgerConfig)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[-] Encryption failed: %v\n", err)
+		return 1
+	}
+
+	if *removePlain {
+		if err := os.RemoveAll(plainPath); err != nil {
+			fmt.Fprintf(os.Stderr, "[-] Failed to remove plaintext copy %s: %v\n", plainPath, err)
+			return 1
+		}
+		fmt.Printf("[+] Database encrypted, plaintext copy removed\n")
+		return 0
+	}
+
+	fmt.Printf("[+] Database encrypted. Plaintext copy kept at %s, remove it after checking the bot starts\n", plainPath)
+	fmt.Printf("[!] Old backups in %s are still plaintext\n", cfg.BackupDir)
+	return 0
+}
+
+// cliRotateKey replaces the master encryption key
+func cliRotateKey(cfg *config.Config, args []string) int {
+	fs := flag.NewFlagSet("rotate-key", flag.ContinueOnError)
+	newKeyFile := fs.String("new-key-file", "", "file with the new key")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *newKeyFile == "" {
+		printUsage()
+		return 2
+	}
+
+	oldKey, err := storage.LoadEncryptionKey(cfg.DBEncryptionKey, cfg.DBEncryptionKeyFile)
+	if err != nil || oldKey == nil {
+		fmt.Fprintf(os.Stderr, "[-] Current key isn't configured: %v\n", err)
+		return 1
+	}
+
+	newKey, err := storage.LoadEncryptionKey("", *newKeyFile)
+	if err != nil || newKey == nil {
+		fmt.Fprintf(os.Stderr, "[-] Failed to load new key: %v\n", err)
+		return 1
+	}
+
+	if err := storage.RotateBadgerKey(cfg.DBPath, oldKey, newKey); err != nil {
+		fmt.Fprintf(os.Stderr, "[-] Key rotation failed: %v\n", err)
+		return 1
+	}
+
+	fmt.Printf("[+] Master key rotated. Point DB_ENCRYPTION_KEY_FILE to %s and restart the bot\n", *newKeyFile)
+	fmt.Printf("[!] Existing backups stay encrypted with the old key\n")
+	return 0
+}
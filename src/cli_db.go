@@ -0,0 +1,253 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"gobrev/src/config"
+	"gobrev/src/models"
+	"gobrev/src/storage"
+)
+
+// cliDB dispatches "gobrev db" subcommands
+func cliDB(cfg *config.Config, args []string) int {
+	if len(args) == 0 {
+		printUsage()
+		return 2
+	}
+
+	store, err := openCLIStore(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[-] %v\n", err)
+		return 1
+	}
+	defer store.Close()
+
+	switch args[0] {
+	case "stats":
+		return dbStats(store)
+	case "dump":
+		return dbDump(store, args[1:])
+	case "delete-chat":
+		return dbDeleteChat(store, args[1:])
+	case "compact":
+		return dbCompact(store)
+	}
+
+	fmt.Fprintf(os.Stderr, "[-] Unknown db command: %s\n\n", args[0])
+	printUsage()
+	return 2
+}
+
+// prefixStats accumulates key counts for one prefix
+type prefixStats struct {
+	keys  int
+	bytes int64
+}
+
+// dbStats prints key counts and sizes per manager prefix
+func dbStats(store *storage.BadgerStore) int {
+	const otherPrefix = "(other)"
+	stats := make(map[string]*prefixStats)
+
+	err := store.Scan("", func(key string, value []byte) error {
+		prefix := otherPrefix
+		for _, known := range models.KeyPrefixes {
+			if strings.HasPrefix(key, known) {
+				prefix = known
+				break
+			}
+		}
+
+		entry, ok := stats[prefix]
+		if !ok {
+			entry = &prefixStats{}
+			stats[prefix] = entry
+		}
+		entry.keys++
+		entry.bytes += int64(len(key) + len(value))
+		return nil
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[-] Failed to scan database: %v\n", err)
+		return 1
+	}
+
+	prefixes := make([]string, 0, len(stats))
+	for prefix := range stats {
+		prefixes = append(prefixes, prefix)
+	}
+	sort.Strings(prefixes)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "prefix\tkeys\tbytes\t")
+
+	total := prefixStats{}
+	for _, prefix := range prefixes {
+		entry := stats[prefix]
+		fmt.Fprintf(w, "%s\t%d\t%d\t\n", prefix, entry.keys, entry.bytes)
+		total.keys += entry.keys
+		total.bytes += entry.bytes
+	}
+	fmt.Fprintf(w, "total\t%d\t%d\t\n", total.keys, total.bytes)
+	w.Flush()
+
+	lsm, vlog := store.Size()
+	fmt.Printf("\nOn disk: LSM %.1f MB, value log %.1f MB\n", float64(lsm)/1024/1024, float64(vlog)/1024/1024)
+	return 0
+}
+
+// dumpRecord is one line of "db dump" output
+type dumpRecord struct {
+	Key   string      `json:"key"`
+	Value interface{} `json:"value"`
+}
+
+// dbDump prints records as JSON lines, decoding JSON values in place
+func dbDump(store *storage.BadgerStore, args []string) int {
+	fs := flag.NewFlagSet("dump", flag.ContinueOnError)
+	prefix := fs.String("prefix", "", "only keys with this prefix")
+	chat := fs.Int64("chat", 0, "only records of this chat")
+	limit := fs.Int("limit", 0, "stop after n records (0 = no limit)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetEscapeHTML(false)
+	count := 0
+
+	err := store.Scan(*prefix, func(key string, value []byte) error {
+		if *chat != 0 && !models.KeyBelongsToChat(key, value, *chat) {
+			return nil
+		}
+
+		record := dumpRecord{Key: key, Value: string(value)}
+		if json.Valid(value) {
+			record.Value = json.RawMessage(value)
+		}
+		if err := encoder.Encode(record); err != nil {
+			return err
+		}
+
+		count++
+		if *limit > 0 && count >= *limit {
+			return storage.ErrStopScan
+		}
+		return nil
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[-] Dump failed: %v\n", err)
+		return 1
+	}
+
+	fmt.Fprintf(os.Stderr, "[#] %d records\n", count)
+	return 0
+}
+
+// dbDeleteChat removes all data of a chat through the managers
+func dbDeleteChat(store *storage.BadgerStore, args []string) int {
+	if len(args) != 1 {
+		printUsage()
+		return 2
+	}
+
+	chatID, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[-] Invalid chat ID: %s\n", args[0])
+		return 2
+	}
+
+	statsCount, err := models.NewStatsManager(store).DeleteChatStats(chatID)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[-] Failed to delete stats: %v\n", err)
+		return 1
+	}
+
+	reviewCount, err := models.NewReviewManager(store).DeleteChatMessages(chatID)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[-] Failed to delete review messages: %v\n", err)
+		return 1
+	}
+
+	messageIDCount, err := models.NewMessageIDManager(store).DeleteChatMessages(chatID)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[-] Failed to delete AI message records: %v\n", err)
+		return 1
+	}
+
+	warningCount, err := models.NewWarningManager(store).DeleteChatWarnings(chatID)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[-] Failed to delete warnings: %v\n", err)
+		return 1
+	}
+
+	if _, err := models.NewCaptchaManager(store).DeleteChatCaptchas(chatID); err != nil {
+		fmt.Fprintf(os.Stderr, "[-] Failed to delete captchas: %v\n", err)
+		return 1
+	}
+
+	if _, err := models.NewReminderManager(store).DeleteChatReminders(chatID); err != nil {
+		fmt.Fprintf(os.Stderr, "[-] Failed to delete reminders: %v\n", err)
+		return 1
+	}
+
+	if _, err := models.NewKarmaManager(store).DeleteChatKarma(chatID); err != nil {
+		fmt.Fprintf(os.Stderr, "[-] Failed to delete karma: %v\n", err)
+		return 1
+	}
+
+	if _, err := models.NewQuoteManager(store).DeleteChatQuotes(chatID); err != nil {
+		fmt.Fprintf(os.Stderr, "[-] Failed to delete quotes: %v\n", err)
+		return 1
+	}
+
+	if _, err := models.NewQuizManager(store).DeleteChatQuiz(chatID); err != nil {
+		fmt.Fprintf(os.Stderr, "[-] Failed to delete quiz leaderboard: %v\n", err)
+		return 1
+	}
+
+	if _, err := models.NewDailyWinnerManager(store).DeleteChatWinners(chatID); err != nil {
+		fmt.Fprintf(os.Stderr, "[-] Failed to delete users of the day: %v\n", err)
+		return 1
+	}
+
+	if err := models.NewSettingsManager(store).Reset(chatID); err != nil {
+		fmt.Fprintf(os.Stderr, "[-] Failed to delete chat settings: %v\n", err)
+		return 1
+	}
+
+	if err := models.NewGreetingManager(store).Reset(chatID); err != nil {
+		fmt.Fprintf(os.Stderr, "[-] Failed to delete greetings: %v\n", err)
+		return 1
+	}
+
+	// Privacy opt-outs are kept on purpose: they must survive the bot being re-added to the chat
+	fmt.Printf("[+] Chat %d deleted: %d stats keys, %d review messages, %d AI message records, %d warnings, settings and greetings\n",
+		chatID, statsCount, reviewCount, messageIDCount, warningCount)
+	return 0
+}
+
+// dbCompact flattens the LSM tree and reclaims value log space
+func dbCompact(store *storage.BadgerStore) int {
+	lsmBefore, vlogBefore := store.Size()
+
+	rewrites, err := store.Compact(0.5)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[-] Compaction failed: %v\n", err)
+		return 1
+	}
+
+	lsm, vlog := store.Size()
+	fmt.Printf("[+] Compaction complete, %d value log files rewritten\n", rewrites)
+	fmt.Printf("    LSM %.1f -> %.1f MB, value log %.1f -> %.1f MB\n",
+		float64(lsmBefore)/1024/1024, float64(lsm)/1024/1024,
+		float64(vlogBefore)/1024/1024, float64(vlog)/1024/1024)
+	return 0
+}
@@ -4,6 +4,7 @@ import (
 	"log"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
@@ -16,10 +17,54 @@ type Config struct {
 	PollTimeout  time.Duration
 	LogLevel     string
 	StartTime    time.Time
+
+	// Retention janitor
+	JanitorInterval        time.Duration
+	StatsRetentionDays     int
+	ReviewRetentionDays    int
+	MessageIDRetentionDays int
+
+	// Storage
+	DBPath              string
+	BackupDir           string
+	MigrateDryRun       bool
+	BackupBeforeMigrate bool
+	BackupInterval      time.Duration
+	BackupKeep          int
+
+	// Encryption at rest
+	DBEncryptionKey     string
+	DBEncryptionKeyFile string
+	DBIndexCacheMB      int
+	DBKeyRotationDays   int
+
+	// Inline mode
+	InlineMaxTokens       int
+	InlineCacheMinutes    int
+	InlineCooldownSeconds int
+	InlineAllowedUsers    []int64
+
+	// Speech to text (OpenAI-compatible /audio/transcriptions)
+	TranscribeURL      string
+	TranscribeAPIKey   string
+	TranscribeModel    string
+	TranscribeLanguage string
 }
 
 // Load loads configuration from .env file and environment variables
 func Load() *Config {
+	config := LoadForCLI()
+
+	// Validate required parameters
+	if config.BotToken == "" {
+		log.Fatal("TELEGRAM_BOT_TOKEN is required")
+	}
+
+	return config
+}
+
+// LoadForCLI loads configuration without requiring bot credentials
+func LoadForCLI() *Config {
 	// Load .env file if it exists
 	if err := godotenv.Load(); err != nil {
 		log.Printf("Warning: .env file not found: %v", err)
@@ -31,11 +76,33 @@ func Load() *Config {
 		PollTimeout: time.Duration(getEnvInt("POLL_TIMEOUT", 10)) * time.Second,
 		LogLevel:    getEnv("LOG_LEVEL", "info"),
 		StartTime:   time.Now(),
-	}
 
-	// Validate required parameters
-	if config.BotToken == "" {
-		log.Fatal("TELEGRAM_BOT_TOKEN is required")
+		JanitorInterval:        time.Duration(getEnvInt("JANITOR_INTERVAL_MINUTES", 60)) * time.Minute,
+		StatsRetentionDays:     getEnvInt("STATS_RETENTION_DAYS", 30),
+		ReviewRetentionDays:    getEnvInt("REVIEW_RETENTION_DAYS", 7),
+		MessageIDRetentionDays: getEnvInt("MESSAGE_ID_RETENTION_DAYS", 14),
+
+		DBPath:              getEnv("DB_PATH", "./data/message_ids"),
+		BackupDir:           getEnv("BACKUP_DIR", "./data/backups"),
+		MigrateDryRun:       getEnvBool("MIGRATE_DRY_RUN", false),
+		BackupBeforeMigrate: getEnvBool("BACKUP_BEFORE_MIGRATE", true),
+		BackupInterval:      time.Duration(getEnvInt("BACKUP_INTERVAL_HOURS", 24)) * time.Hour,
+		BackupKeep:          getEnvInt("BACKUP_KEEP", 7),
+
+		DBEncryptionKey:     getEnv("DB_ENCRYPTION_KEY", ""),
+		DBEncryptionKeyFile: getEnv("DB_ENCRYPTION_KEY_FILE", ""),
+		DBIndexCacheMB:      getEnvInt("DB_INDEX_CACHE_MB", 64),
+		DBKeyRotationDays:   getEnvInt("DB_KEY_ROTATION_DAYS", 10),
+
+		InlineMaxTokens:       getEnvInt("INLINE_MAX_TOKENS", 300),
+		InlineCacheMinutes:    getEnvInt("INLINE_CACHE_MINUTES", 10),
+		InlineCooldownSeconds: getEnvInt("INLINE_COOLDOWN_SECONDS", 20),
+		InlineAllowedUsers:    getEnvInt64List("INLINE_ALLOWED_USERS"),
+
+		TranscribeURL:      getEnv("TRANSCRIBE_URL", ""),
+		TranscribeAPIKey:   getEnv("TRANSCRIBE_API_KEY", ""),
+		TranscribeModel:    getEnv("TRANSCRIBE_MODEL", "whisper-1"),
+		TranscribeLanguage: getEnv("TRANSCRIBE_LANGUAGE", "ru"),
 	}
 
 	return config
@@ -68,3 +135,14 @@ func getEnvInt(key string, defaultValue int) int {
 	}
 	return defaultValue
 }
+
+// getEnvInt64List gets a comma-separated list of integers, skipping invalid entries
+func getEnvInt64List(key string) []int64 {
+	var values []int64
+	for _, part := range strings.Split(os.Getenv(key), ",") {
+		if parsed, err := strconv.ParseInt(strings.TrimSpace(part), 10, 64); err == nil {
+			values = append(values, parsed)
+		}
+	}
+	return values
+}
@@ -0,0 +1,145 @@
+package callbacks
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gopkg.in/telebot.v3"
+	"gobrev/src/models"
+)
+
+// Telegram limits callback data to 64 bytes
+const maxCallbackData = 64
+
+// separator splits prefix, record ID and action in callback data
+const separator = "|"
+
+// Handler handles a button press. data is the stored record, action is the pressed button.
+type Handler func(c telebot.Context, data *models.CallbackData, action string) error
+
+// Router dispatches inline button presses to handlers by prefix
+type Router struct {
+	callbackManager *models.CallbackManager
+	handlers        map[string]Handler
+}
+
+// NewRouter creates a new callback router
+func NewRouter(callbackManager *models.CallbackManager) *Router {
+	return &Router{
+		callbackManager: callbackManager,
+		handlers:        make(map[string]Handler),
+	}
+}
+
+// Handle registers a handler for buttons created with prefix
+func (r *Router) Handle(prefix string, handler Handler) {
+	if strings.Contains(prefix, separator) {
+		panic("callback prefix must not contain " + separator)
+	}
+	r.handlers[prefix] = handler
+}
+
+// Register attaches the router to the bot
+func (r *Router) Register(bot *telebot.Bot) {
+	bot.Handle(telebot.OnCallback, r.route)
+}
+
+// KeyboardOptions configure who may use a keyboard and for how long
+type KeyboardOptions struct {
+	Payload string        // Opaque state passed to the handler
+	ChatID  int64         // Chat the keyboard is sent to
+	UserID  int64         // Only this user may press the buttons (0 = anyone in the chat)
+	TTL     time.Duration // Buttons stop working after this time
+}
+
+// Keyboard builds buttons backed by one stored callback record
+type Keyboard struct {
+	data *models.CallbackData
+}
+
+// NewKeyboard stores a callback record for a new inline keyboard
+func (r *Router) NewKeyboard(prefix string, opts KeyboardOptions) (*Keyboard, error) {
+	if _, ok := r.handlers[prefix]; !ok {
+		return nil, fmt.Errorf("no callback handler for prefix %q", prefix)
+	}
+
+	data, err := r.callbackManager.Create(prefix, opts.Payload, opts.ChatID, opts.UserID, opts.TTL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create callback: %w", err)
+	}
+
+	return &Keyboard{data: data}, nil
+}
+
+// Keyboard returns a keyboard for an existing record, e.g. to redraw buttons after a press
+func (r *Router) Keyboard(data *models.CallbackData) *Keyboard {
+	return &Keyboard{data: data}
+}
+
+// ID returns the callback record ID
+func (k *Keyboard) ID() string {
+	return k.data.ID
+}
+
+// Button returns an inline button that triggers action
+func (k *Keyboard) Button(text, action string) telebot.InlineButton {
+	data := k.data.Prefix + separator + k.data.ID + separator + action
+	if len(data) > maxCallbackData {
+		fmt.Printf("[-] Callback data too long (%d bytes): %s\n", len(data), data)
+	}
+	return telebot.InlineButton{Text: text, Data: data}
+}
+
+// Disable removes the callback record so the keyboard stops working
+func (r *Router) Disable(id string) error {
+	return r.callbackManager.Delete(id)
+}
+
+// UpdatePayload replaces the stored payload of a keyboard
+func (r *Router) UpdatePayload(id, payload string) error {
+	return r.callbackManager.UpdatePayload(id, payload)
+}
+
+// route parses callback data, checks expiry and authorization and runs the handler
+func (r *Router) route(c telebot.Context) error {
+	parts := strings.SplitN(c.Callback().Data, separator, 3)
+	if len(parts) < 2 {
+		return c.Respond()
+	}
+
+	prefix, id := parts[0], parts[1]
+	action := ""
+	if len(parts) == 3 {
+		action = parts[2]
+	}
+
+	handler, ok := r.handlers[prefix]
+	if !ok {
+		fmt.Printf("[-] No callback handler for prefix %s\n", prefix)
+		return c.Respond()
+	}
+
+	data, err := r.callbackManager.Get(id)
+	if err != nil {
+		if errors.Is(err, models.ErrCallbackExpired) {
+			return c.Respond(&telebot.CallbackResponse{Text: "⌛ Кнопка устарела"})
+		}
+		fmt.Printf("[-] Failed to load callback %s: %v\n", id, err)
+		return c.Respond(&telebot.CallbackResponse{Text: "❌ Ошибка, попробуй ещё раз"})
+	}
+
+	// The record must belong to this handler and chat, so IDs can't be replayed elsewhere
+	if data.Prefix != prefix || (c.Chat() != nil && data.ChatID != c.Chat().ID) {
+		fmt.Printf("[-] Callback %s used outside its chat or handler\n", id)
+		return c.Respond(&telebot.CallbackResponse{Text: "❌ Кнопка недействительна"})
+	}
+
+	if data.UserID != 0 && data.UserID != c.Sender().ID {
+		return c.Respond(&telebot.CallbackResponse{Text: "🙅 Эта кнопка не для тебя"})
+	}
+
+	fmt.Printf("[i] Callback %s:%s from user %d\n", prefix, action, c.Sender().ID)
+	return handler(c, data, action)
+}
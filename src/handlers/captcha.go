@@ -0,0 +1,437 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/telebot.v3"
+	"gobrev/src/handlers/callbacks"
+	"gobrev/src/handlers/commands"
+	"gobrev/src/models"
+	"gobrev/src/utils"
+)
+
+const (
+	// captchaCallbackPrefix routes captcha buttons
+	captchaCallbackPrefix = "captcha"
+	// captchaMaxAttempts is how many answers a newcomer may give, a bot guessing at random passes 1 time in captchaOptions
+	captchaMaxAttempts = 1
+	// captchaOptions is how many answer buttons a challenge has
+	captchaOptions = 9
+	// captchaNoticeTTL is how long pass and fail notices stay in the chat
+	captchaNoticeTTL = 30 * time.Second
+)
+
+// captchaWords are emoji challenges: the image shows the word, buttons show emojis
+var captchaWords = []struct {
+	emoji string
+	word  string
+}{
+	{"🐱", "КОТ"}, {"🐶", "ПЁС"}, {"🍎", "ЯБЛОКО"}, {"🚗", "МАШИНА"}, {"⚽️", "МЯЧ"},
+	{"🌵", "КАКТУС"}, {"🎸", "ГИТАРА"}, {"🍕", "ПИЦЦА"}, {"🐟", "РЫБА"}, {"🌙", "ЛУНА"},
+	{"🔥", "ОГОНЬ"}, {"🍌", "БАНАН"}, {"✈️", "САМОЛЁТ"}, {"🐸", "ЛЯГУШКА"}, {"☂️", "ЗОНТ"},
+}
+
+// captchaChallenge is a generated question with its answer buttons
+type captchaChallenge struct {
+	imageText string
+	hint      string
+	options   []string
+	answer    string
+}
+
+// CaptchaHandler restricts new members until they solve a challenge and kicks them on timeout
+type CaptchaHandler struct {
+	bot             *telebot.Bot
+	captchaManager  *models.CaptchaManager
+	settingsManager *models.SettingsManager
+	callbackRouter  *callbacks.Router
+	adminManager    *utils.AdminManager
+
+	onPass func(chat *telebot.Chat, user *telebot.User)
+
+	mu     sync.Mutex
+	timers map[string]*time.Timer
+}
+
+// NewCaptchaHandler creates a captcha handler and registers its buttons
+func NewCaptchaHandler(bot *telebot.Bot, captchaManager *models.CaptchaManager, settingsManager *models.SettingsManager, callbackRouter *callbacks.Router) *CaptchaHandler {
+	h := &CaptchaHandler{
+		bot:             bot,
+		captchaManager:  captchaManager,
+		settingsManager: settingsManager,
+		callbackRouter:  callbackRouter,
+		adminManager:    utils.NewAdminManager(),
+		timers:          make(map[string]*time.Timer),
+	}
+
+	callbackRouter.Handle(captchaCallbackPrefix, h.handleButton)
+	return h
+}
+
+// OnPass sets a callback for newcomers who passed the check, e.g. to welcome them
+func (h *CaptchaHandler) OnPass(fn func(chat *telebot.Chat, user *telebot.User)) {
+	h.onPass = fn
+}
+
+// Restore schedules timeouts of challenges left pending by a restart, overdue ones are kicked right away
+func (h *CaptchaHandler) Restore() {
+	captchas, err := h.captchaManager.Pending()
+	if err != nil {
+		fmt.Printf("[-] Failed to load pending captchas: %v\n", err)
+		return
+	}
+
+	for _, captcha := range captchas {
+		h.schedule(captcha)
+	}
+	if len(captchas) > 0 {
+		fmt.Printf("[+] Restored %d pending captchas\n", len(captchas))
+	}
+}
+
+// Challenge restricts a newcomer and posts a challenge. It returns false when the chat has no captcha
+// or the bot can't restrict members, so the caller may greet the user right away.
+func (h *CaptchaHandler) Challenge(c telebot.Context, user *telebot.User) bool {
+	chat := c.Chat()
+	settings := h.settingsManager.Get(chat.ID)
+	if !settings.Captcha || user.IsBot {
+		return false
+	}
+
+	// Members added by an admin are trusted
+	if c.Sender() != nil && c.Sender().ID != user.ID && h.adminManager.IsChatAdmin(c) {
+		return false
+	}
+
+	member, err := h.bot.ChatMemberOf(chat, h.bot.Me)
+	if err != nil || member.Role != telebot.Administrator || !member.CanRestrictMembers {
+		fmt.Printf("[-] Captcha is enabled in chat %d but the bot can't restrict members\n", chat.ID)
+		return false
+	}
+
+	if err := h.bot.Restrict(chat, &telebot.ChatMember{User: user, Rights: telebot.NoRights(), RestrictedUntil: telebot.Forever()}); err != nil {
+		fmt.Printf("[-] Failed to restrict newcomer %d in chat %d: %v\n", user.ID, chat.ID, err)
+		return false
+	}
+
+	challenge := newCaptchaChallenge()
+	image, err := utils.GenerateCaptchaImage(challenge.imageText)
+	if err != nil {
+		fmt.Printf("[-] Failed to render captcha: %v\n", err)
+		h.restoreRights(chat.ID, user)
+		return false
+	}
+
+	timeout := time.Duration(settings.CaptchaSecs) * time.Second
+	keyboard, err := h.callbackRouter.NewKeyboard(captchaCallbackPrefix, callbacks.KeyboardOptions{
+		Payload: strconv.FormatInt(user.ID, 10),
+		ChatID:  chat.ID,
+		TTL:     timeout + time.Minute,
+	})
+	if err != nil {
+		fmt.Printf("[-] Failed to create captcha keyboard: %v\n", err)
+		h.restoreRights(chat.ID, user)
+		return false
+	}
+
+	var rows [][]telebot.InlineButton
+	var row []telebot.InlineButton
+	for _, option := range challenge.options {
+		row = append(row, keyboard.Button(option, "a:"+option))
+		if len(row) == 3 {
+			rows = append(rows, row)
+			row = nil
+		}
+	}
+	rows = append(rows, []telebot.InlineButton{keyboard.Button("✅ Пропустить (админ)", "approve"), keyboard.Button("🚫 Кикнуть (админ)", "kick")})
+
+	photo := &telebot.Photo{
+		File: telebot.FromReader(bytes.NewReader(image)),
+		Caption: fmt.Sprintf("👋 %s, докажи, что ты не бот: %s\n⏳ На ответ %d сек., попыток: %d",
+			commands.MentionUser(user), challenge.hint, settings.CaptchaSecs, captchaMaxAttempts),
+	}
+	msg, err := h.bot.Send(chat, photo, &telebot.SendOptions{
+		ParseMode:   telebot.ModeHTML,
+		ReplyMarkup: &telebot.ReplyMarkup{InlineKeyboard: rows},
+	})
+	if err != nil {
+		fmt.Printf("[-] Failed to send captcha: %v\n", err)
+		h.restoreRights(chat.ID, user)
+		return false
+	}
+
+	now := time.Now()
+	captcha := models.Captcha{
+		ChatID:    chat.ID,
+		UserID:    user.ID,
+		UserName:  user.FirstName,
+		MessageID: msg.ID,
+		Answer:    challenge.answer,
+		CreatedAt: now.Unix(),
+		ExpiresAt: now.Add(timeout).Unix(),
+	}
+	if err := h.captchaManager.Save(captcha); err != nil {
+		fmt.Printf("[-] Failed to save captcha: %v\n", err)
+	}
+	h.schedule(captcha)
+
+	fmt.Printf("[+] Captcha sent to user %d in chat %d\n", user.ID, chat.ID)
+	return true
+}
+
+// HandleLeave drops the challenge of a newcomer who left on their own.
+// It returns true if the user still had a pending challenge.
+func (h *CaptchaHandler) HandleLeave(chatID int64, user *telebot.User) bool {
+	captcha := h.take(chatID, user.ID)
+	if captcha == nil {
+		return false
+	}
+	h.deleteMessage(chatID, captcha.MessageID)
+	return true
+}
+
+// handleButton handles answers of the newcomer and admin decisions
+func (h *CaptchaHandler) handleButton(c telebot.Context, data *models.CallbackData, action string) error {
+	chatID := data.ChatID
+	userID, _ := strconv.ParseInt(data.Payload, 10, 64)
+
+	captcha, err := h.captchaManager.Get(chatID, userID)
+	if err != nil || captcha == nil {
+		h.callbackRouter.Disable(data.ID)
+		return c.Respond(&telebot.CallbackResponse{Text: "⌛ Проверка уже закончилась"})
+	}
+
+	switch {
+	case action == "approve" || action == "kick":
+		if !h.adminManager.IsAdmin(c) {
+			return c.Respond(&telebot.CallbackResponse{Text: "🙅 Только для админов", ShowAlert: true})
+		}
+		c.Respond()
+		if action == "approve" {
+			h.pass(chatID, userID, fmt.Sprintf("✅ %s пропущен админом", commands.MentionUser(c.Sender())))
+		} else {
+			h.fail(chatID, userID, "выгнан админом")
+		}
+		return nil
+
+	case strings.HasPrefix(action, "a:"):
+		if c.Sender().ID != userID {
+			return c.Respond(&telebot.CallbackResponse{Text: "🙅 Эта капча не для тебя"})
+		}
+
+		if strings.TrimPrefix(action, "a:") == captcha.Answer {
+			c.Respond(&telebot.CallbackResponse{Text: "✅ Верно!"})
+			h.pass(chatID, userID, "")
+			return nil
+		}
+
+		attempts, ok := h.countMiss(chatID, userID)
+		if !ok {
+			return c.Respond(&telebot.CallbackResponse{Text: "⌛ Проверка уже закончилась"})
+		}
+		if attempts >= captchaMaxAttempts {
+			c.Respond(&telebot.CallbackResponse{Text: "❌ Неверно, попытки кончились", ShowAlert: true})
+			h.fail(chatID, userID, "не решил капчу")
+			return nil
+		}
+		return c.Respond(&telebot.CallbackResponse{
+			Text:      fmt.Sprintf("❌ Неверно, осталось попыток: %d", captchaMaxAttempts-attempts),
+			ShowAlert: true,
+		})
+	}
+
+	return c.Respond()
+}
+
+// pass lifts the restriction of a newcomer who solved the challenge
+func (h *CaptchaHandler) pass(chatID, userID int64, notice string) {
+	captcha := h.take(chatID, userID)
+	if captcha == nil {
+		return
+	}
+
+	user := &telebot.User{ID: userID, FirstName: captcha.UserName}
+	h.restoreRights(chatID, user)
+	h.deleteMessage(chatID, captcha.MessageID)
+
+	if notice == "" {
+		notice = fmt.Sprintf("✅ %s прошёл проверку, добро пожаловать!", commands.MentionUser(user))
+	}
+	h.sendNotice(chatID, notice)
+	fmt.Printf("[+] User %d passed captcha in chat %d\n", userID, chatID)
+
+	if h.onPass != nil {
+		h.onPass(&telebot.Chat{ID: chatID}, user)
+	}
+}
+
+// fail kicks a newcomer; they can join again later
+func (h *CaptchaHandler) fail(chatID, userID int64, reason string) {
+	captcha := h.take(chatID, userID)
+	if captcha == nil {
+		return
+	}
+
+	chat := &telebot.Chat{ID: chatID}
+	user := &telebot.User{ID: userID, FirstName: captcha.UserName}
+
+	if err := h.bot.Ban(chat, &telebot.ChatMember{User: user}); err != nil {
+		fmt.Printf("[-] Failed to kick user %d from chat %d: %v\n", userID, chatID, err)
+	} else if err := h.bot.Unban(chat, user, true); err != nil {
+		fmt.Printf("[-] Failed to unban kicked user %d in chat %d: %v\n", userID, chatID, err)
+	}
+	h.deleteMessage(chatID, captcha.MessageID)
+
+	h.sendNotice(chatID, fmt.Sprintf("🚪 %s кикнут: %s", html.EscapeString(captcha.UserName), reason))
+	fmt.Printf("[+] User %d kicked from chat %d: %s\n", userID, chatID, reason)
+}
+
+// schedule kicks the user when the challenge times out
+func (h *CaptchaHandler) schedule(captcha models.Captcha) {
+	key := fmt.Sprintf("%d_%d", captcha.ChatID, captcha.UserID)
+	delay := time.Until(time.Unix(captcha.ExpiresAt, 0))
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if timer, ok := h.timers[key]; ok {
+		timer.Stop()
+	}
+	h.timers[key] = time.AfterFunc(max(delay, 0), func() {
+		h.fail(captcha.ChatID, captcha.UserID, "не ответил на капчу вовремя")
+	})
+}
+
+// countMiss counts a wrong answer and returns the attempts used, false if the challenge is over.
+// It holds the lock so fast taps can't both read the old count.
+func (h *CaptchaHandler) countMiss(chatID, userID int64) (int, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	captcha, err := h.captchaManager.Get(chatID, userID)
+	if err != nil || captcha == nil {
+		return 0, false
+	}
+
+	captcha.Attempts++
+	if err := h.captchaManager.Save(*captcha); err != nil {
+		fmt.Printf("[-] Failed to save captcha attempt: %v\n", err)
+	}
+	return captcha.Attempts, true
+}
+
+// take removes a pending challenge and its timer. Only the caller that gets it acts on it.
+func (h *CaptchaHandler) take(chatID, userID int64) *models.Captcha {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	key := fmt.Sprintf("%d_%d", chatID, userID)
+	if timer, ok := h.timers[key]; ok {
+		timer.Stop()
+		delete(h.timers, key)
+	}
+
+	captcha, err := h.captchaManager.Get(chatID, userID)
+	if err != nil || captcha == nil {
+		return nil
+	}
+	if err := h.captchaManager.Delete(chatID, userID); err != nil {
+		fmt.Printf("[-] Failed to delete captcha: %v\n", err)
+	}
+	return captcha
+}
+
+// restoreRights gives the user the chat's default permissions back
+func (h *CaptchaHandler) restoreRights(chatID int64, user *telebot.User) {
+	if err := commands.RestoreMemberRights(h.bot, chatID, user); err != nil {
+		fmt.Printf("[-] Failed to restore rights of user %d in chat %d: %v\n", user.ID, chatID, err)
+	}
+}
+
+// deleteMessage deletes a bot message by ID
+func (h *CaptchaHandler) deleteMessage(chatID int64, messageID int) {
+	if err := h.bot.Delete(&telebot.StoredMessage{MessageID: strconv.Itoa(messageID), ChatID: chatID}); err != nil {
+		fmt.Printf("[-] Failed to delete captcha message: %v\n", err)
+	}
+}
+
+// sendNotice posts a short notice that removes itself after a while
+func (h *CaptchaHandler) sendNotice(chatID int64, text string) {
+	msg, err := h.bot.Send(&telebot.Chat{ID: chatID}, text, &telebot.SendOptions{ParseMode: telebot.ModeHTML})
+	if err != nil {
+		fmt.Printf("[-] Failed to send captcha notice: %v\n", err)
+		return
+	}
+	time.AfterFunc(captchaNoticeTTL, func() {
+		h.bot.Delete(msg)
+	})
+}
+
+// newCaptchaChallenge generates a math or emoji challenge
+func newCaptchaChallenge() captchaChallenge {
+	if rand.Intn(2) == 0 {
+		return newMathChallenge()
+	}
+	return newEmojiChallenge()
+}
+
+// newMathChallenge asks for the result of a small arithmetic expression
+func newMathChallenge() captchaChallenge {
+	a, b := 2+rand.Intn(18), 2+rand.Intn(9)
+	var text string
+	var answer int
+	switch rand.Intn(3) {
+	case 0:
+		text, answer = fmt.Sprintf("%d + %d = ?", a, b), a+b
+	case 1:
+		a += b
+		text, answer = fmt.Sprintf("%d - %d = ?", a, b), a-b
+	default:
+		a = 2 + rand.Intn(8)
+		text, answer = fmt.Sprintf("%d × %d = ?", a, b), a*b
+	}
+
+	seen := map[int]bool{answer: true}
+	options := []string{strconv.Itoa(answer)}
+	for len(options) < captchaOptions {
+		option := answer + rand.Intn(17) - 8
+		if option < 0 || seen[option] {
+			continue
+		}
+		seen[option] = true
+		options = append(options, strconv.Itoa(option))
+	}
+	rand.Shuffle(len(options), func(i, j int) { options[i], options[j] = options[j], options[i] })
+
+	return captchaChallenge{
+		imageText: text,
+		hint:      "реши пример с картинки",
+		options:   options,
+		answer:    strconv.Itoa(answer),
+	}
+}
+
+// newEmojiChallenge asks to press the emoji named on the image
+func newEmojiChallenge() captchaChallenge {
+	picks := rand.Perm(len(captchaWords))[:captchaOptions]
+	answer := captchaWords[picks[rand.Intn(len(picks))]]
+
+	options := make([]string, 0, len(picks))
+	for _, index := range picks {
+		options = append(options, captchaWords[index].emoji)
+	}
+
+	return captchaChallenge{
+		imageText: answer.word,
+		hint:      "нажми на то, что написано на картинке",
+		options:   options,
+		answer:    answer.emoji,
+	}
+}
@@ -0,0 +1,223 @@
+package commands
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"gopkg.in/telebot.v3"
+)
+
+// ArgType defines how a command argument is parsed
+type ArgType int
+
+const (
+	ArgWord     ArgType = iota // Single word
+	ArgInt                     // Integer number
+	ArgDuration                // Duration like 30м, 1ч, 2д
+	ArgChoice                  // One of the listed keywords
+	ArgText                    // Rest of the message, must be the last argument
+)
+
+// ArgSpec describes a command argument
+type ArgSpec struct {
+	Name     string
+	Type     ArgType
+	Required bool
+	Choices  []string // Allowed keywords for ArgChoice
+}
+
+// argsContextKey is the telebot context key holding parsed arguments
+const argsContextKey = "command_args"
+
+// Args holds parsed command arguments
+type Args struct {
+	values map[string]interface{}
+	raw    []string
+	parsed bool
+}
+
+// ParseArgs parses argument words according to specs.
+// Optional arguments that don't match their type are skipped, so "[все] [N]" accepts both "все 5" and "5".
+func ParseArgs(specs []ArgSpec, words []string) (*Args, error) {
+	args := &Args{
+		values: make(map[string]interface{}),
+		raw:    words,
+		parsed: true,
+	}
+
+	pos := 0
+	for _, spec := range specs {
+		if pos >= len(words) {
+			if spec.Required {
+				return nil, fmt.Errorf("не указан аргумент «%s»", spec.Name)
+			}
+			continue
+		}
+
+		word := words[pos]
+
+		if spec.Type == ArgText {
+			args.values[spec.Name] = strings.Join(words[pos:], " ")
+			pos = len(words)
+			continue
+		}
+
+		value, err := parseArg(spec, word)
+		if err != nil {
+			if spec.Required {
+				return nil, err
+			}
+			continue
+		}
+
+		args.values[spec.Name] = value
+		pos++
+	}
+
+	if pos < len(words) {
+		return nil, fmt.Errorf("лишний аргумент «%s»", words[pos])
+	}
+
+	return args, nil
+}
+
+// parseArg converts a single word according to its spec
+func parseArg(spec ArgSpec, word string) (interface{}, error) {
+	switch spec.Type {
+	case ArgInt:
+		n, err := strconv.Atoi(word)
+		if err != nil {
+			return nil, fmt.Errorf("«%s» должно быть числом", spec.Name)
+		}
+		return n, nil
+
+	case ArgDuration:
+		d, err := ParseDuration(word)
+		if err != nil {
+			return nil, fmt.Errorf("«%s»: %v", spec.Name, err)
+		}
+		return d, nil
+
+	case ArgChoice:
+		lower := strings.ToLower(word)
+		for _, choice := range spec.Choices {
+			if lower == choice {
+				return choice, nil
+			}
+		}
+		return nil, fmt.Errorf("«%s» должно быть одним из: %s", spec.Name, strings.Join(spec.Choices, ", "))
+	}
+
+	return word, nil
+}
+
+// textAfterFields returns the text after the first n whitespace-separated fields, keeping line breaks
+func textAfterFields(text string, n int) string {
+	for i := 0; i < n; i++ {
+		text = strings.TrimLeftFunc(text, unicode.IsSpace)
+		end := strings.IndexFunc(text, unicode.IsSpace)
+		if end < 0 {
+			return ""
+		}
+		text = text[end:]
+	}
+	return strings.TrimSpace(text)
+}
+
+// ParseDuration parses durations with Russian units: 30с, 15м, 1ч, 2д, 1н (also 1ч30м)
+func ParseDuration(s string) (time.Duration, error) {
+	units := map[string]time.Duration{
+		"с": time.Second, "сек": time.Second,
+		"м": time.Minute, "мин": time.Minute,
+		"ч": time.Hour, "час": time.Hour, "часа": time.Hour, "часов": time.Hour,
+		"д": 24 * time.Hour, "дн": 24 * time.Hour, "день": 24 * time.Hour, "дня": 24 * time.Hour, "дней": 24 * time.Hour,
+		"н": 7 * 24 * time.Hour, "нед": 7 * 24 * time.Hour,
+	}
+
+	runes := []rune(strings.ToLower(strings.TrimSpace(s)))
+	var total time.Duration
+	i := 0
+
+	for i < len(runes) {
+		start := i
+		for i < len(runes) && unicode.IsDigit(runes[i]) {
+			i++
+		}
+		if start == i {
+			return 0, fmt.Errorf("неверная длительность «%s», пример: 30м, 1ч, 2д", s)
+		}
+		n, _ := strconv.Atoi(string(runes[start:i]))
+
+		unitStart := i
+		for i < len(runes) && !unicode.IsDigit(runes[i]) {
+			i++
+		}
+		unit, ok := units[string(runes[unitStart:i])]
+		if !ok {
+			return 0, fmt.Errorf("неверная длительность «%s», пример: 30м, 1ч, 2д", s)
+		}
+
+		total += time.Duration(n) * unit
+	}
+
+	if total <= 0 {
+		return 0, fmt.Errorf("длительность должна быть больше нуля")
+	}
+
+	return total, nil
+}
+
+// SetArgs stores parsed arguments in the context
+func SetArgs(c telebot.Context, args *Args) {
+	c.Set(argsContextKey, args)
+}
+
+// GetArgs returns parsed arguments from the context.
+// Commands executed without the router (e.g. AI triggers) get empty arguments.
+func GetArgs(c telebot.Context) *Args {
+	if args, ok := c.Get(argsContextKey).(*Args); ok {
+		return args
+	}
+	return &Args{values: make(map[string]interface{})}
+}
+
+// Has reports whether an argument was given
+func (a *Args) Has(name string) bool {
+	_, ok := a.values[name]
+	return ok
+}
+
+// String returns a word, choice or text argument
+func (a *Args) String(name string) string {
+	value, _ := a.values[name].(string)
+	return value
+}
+
+// Int returns an integer argument or def if it wasn't given
+func (a *Args) Int(name string, def int) int {
+	if value, ok := a.values[name].(int); ok {
+		return value
+	}
+	return def
+}
+
+// Duration returns a duration argument or def if it wasn't given
+func (a *Args) Duration(name string, def time.Duration) time.Duration {
+	if value, ok := a.values[name].(time.Duration); ok {
+		return value
+	}
+	return def
+}
+
+// Parsed reports whether the command was called through the router with parsed arguments
+func (a *Args) Parsed() bool {
+	return a.parsed
+}
+
+// Raw returns the argument words as typed
+func (a *Args) Raw() []string {
+	return a.raw
+}
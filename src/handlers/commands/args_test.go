@@ -0,0 +1,103 @@
+package commands
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseArgs(t *testing.T) {
+	limitSpecs := []ArgSpec{
+		{Name: "режим", Type: ArgChoice, Choices: []string{"все"}},
+		{Name: "N", Type: ArgInt},
+	}
+	muteSpecs := []ArgSpec{
+		{Name: "время", Type: ArgDuration, Required: true},
+		{Name: "причина", Type: ArgText},
+	}
+
+	tests := []struct {
+		name    string
+		specs   []ArgSpec
+		words   string
+		want    map[string]interface{}
+		wantErr bool
+	}{
+		{"no words", limitSpecs, "", map[string]interface{}{}, false},
+		{"both optional", limitSpecs, "все 5", map[string]interface{}{"режим": "все", "N": 5}, false},
+		{"optional skipped", limitSpecs, "5", map[string]interface{}{"N": 5}, false},
+		{"choice ignores case", limitSpecs, "ВСЕ", map[string]interface{}{"режим": "все"}, false},
+		{"extra word", limitSpecs, "5 6", nil, true},
+		{"wrong type", limitSpecs, "пять", nil, true},
+		{"required missing", muteSpecs, "", nil, true},
+		{"required wrong type", muteSpecs, "завтра", nil, true},
+		{"duration only", muteSpecs, "1ч30м", map[string]interface{}{"время": 90 * time.Minute}, false},
+		{"text takes the rest", muteSpecs, "2д за флуд и мат", map[string]interface{}{"время": 48 * time.Hour, "причина": "за флуд и мат"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args, err := ParseArgs(tt.specs, strings.Fields(tt.words))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ParseArgs(%q) succeeded, want an error", tt.words)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseArgs(%q): %v", tt.words, err)
+			}
+			if len(args.values) != len(tt.want) {
+				t.Errorf("ParseArgs(%q) = %v, want %v", tt.words, args.values, tt.want)
+			}
+			for name, want := range tt.want {
+				if got := args.values[name]; got != want {
+					t.Errorf("ParseArgs(%q)[%s] = %v, want %v", tt.words, name, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"30с", 30 * time.Second, false},
+		{"15м", 15 * time.Minute, false},
+		{"1ч30м", 90 * time.Minute, false},
+		{"2д", 48 * time.Hour, false},
+		{"1Н", 7 * 24 * time.Hour, false},
+		{"3часа", 3 * time.Hour, false},
+		{"0м", 0, true},
+		{"ч", 0, true},
+		{"10", 0, true},
+		{"10лет", 0, true},
+		{"", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseDuration(tt.input)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseDuration(%q) = %v, %v, want %v (error %v)", tt.input, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestTextAfterFields(t *testing.T) {
+	tests := []struct {
+		text string
+		n    int
+		want string
+	}{
+		{".приветствие текст Привет,\n{name}!", 2, "Привет,\n{name}!"},
+		{"  .прощание   Пока, {name} ", 1, "Пока, {name}"},
+		{".приветствие текст", 2, ""},
+		{"одно", 0, "одно"},
+	}
+	for _, tt := range tests {
+		if got := textAfterFields(tt.text, tt.n); got != tt.want {
+			t.Errorf("textAfterFields(%q, %d) = %q, want %q", tt.text, tt.n, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/telebot.v3"
+	"gobrev/src/models"
+	"gobrev/src/storage"
+)
+
+// BackupCommand handles .бэкап command (bot owners only)
+type BackupCommand struct {
+	*BaseCommand
+	backupManager *storage.BackupManager
+}
+
+// NewBackupCommand creates a new backup command
+func NewBackupCommand(backupManager *storage.BackupManager) *BackupCommand {
+	return &BackupCommand{
+		BaseCommand: NewBaseCommand(".бэкап", true).
+			WithAliases("backup").
+			WithDescription("Прислать бэкап базы (владелец бота)").
+			WithRole(RoleBotOwner).
+			WithArgs(ArgSpec{Name: "режим", Type: ArgChoice, Choices: []string{"сейчас"}}),
+		backupManager: backupManager,
+	}
+}
+
+// Execute sends the latest backup file, creating a fresh one with "сейчас"
+func (cmd *BackupCommand) Execute(c telebot.Context, metrics *models.Metrics) error {
+	metrics.RecordCommand()
+
+	var path string
+	var err error
+
+	if GetArgs(c).String("режим") == "сейчас" {
+		cmd.SafeSend(c, "🧳 Создаю бэкап...")
+		path, err = cmd.backupManager.CreateBackup()
+	} else {
+		path, err = cmd.backupManager.LatestBackup()
+		if os.IsNotExist(err) {
+			return cmd.SafeSend(c, "🧳 Бэкапов пока нет. Создать: <code>.бэкап сейчас</code>", &telebot.SendOptions{
+				ParseMode: telebot.ModeHTML,
+			})
+		}
+	}
+	if err != nil {
+		fmt.Printf("[-] Failed to get backup: %v\n", err)
+		return cmd.SafeSend(c, "❌ Ошибка бэкапа: "+err.Error())
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return cmd.SafeSend(c, "❌ Ошибка чтения бэкапа: "+err.Error())
+	}
+
+	// Bot API upload limit is 50 MB
+	if info.Size() > 50*1024*1024 {
+		return cmd.SafeSend(c, fmt.Sprintf("❌ Бэкап слишком большой для Telegram (%.1f MB): %s",
+			float64(info.Size())/1024/1024, path))
+	}
+
+	document := &telebot.Document{
+		File:     telebot.FromDisk(path),
+		FileName: filepath.Base(path),
+		Caption: fmt.Sprintf("🧳 %s (%.1f KB)\nВосстановление: gobrev restore %s",
+			filepath.Base(path), float64(info.Size())/1024, filepath.Base(path)),
+	}
+
+	fmt.Printf("[+] Sending backup %s to user %d\n", path, c.Sender().ID)
+	return c.Send(document)
+}
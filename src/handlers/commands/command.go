@@ -1,14 +1,41 @@
 package commands
 
 import (
+	"strings"
+	"time"
+
 	"gopkg.in/telebot.v3"
 	"gobrev/src/models"
 	"gobrev/src/utils"
 )
 
+// CommandPrefixes are the characters a command may start with
+const CommandPrefixes = "/.!"
+
+// Role is the access level required to run a command
+type Role int
+
+const (
+	RoleAnyone    Role = iota // Any user
+	RoleChatAdmin             // Chat administrators (and everyone in private chats)
+	RoleBotOwner              // Bot owners only
+)
+
+// Cooldown limits how often a command can be used
+type Cooldown struct {
+	PerUser time.Duration // Between uses by the same user
+	PerChat time.Duration // Between uses in the same chat by anyone
+}
+
 // Command interface defines the contract for all bot commands
 type Command interface {
 	Name() string
+	Aliases() []string
+	Description() string
+	Usage() string
+	Args() []ArgSpec
+	RequiredRole() Role
+	Cooldown() Cooldown
 	Execute(c telebot.Context, metrics *models.Metrics) error
 	IsPrivateOnly() bool
 }
@@ -16,6 +43,11 @@ type Command interface {
 // BaseCommand provides common functionality for all commands
 type BaseCommand struct {
 	name        string
+	aliases     []string
+	description string
+	args        []ArgSpec
+	role        Role
+	cooldown    Cooldown
 	privateOnly bool
 	safeSender  *utils.SafeSender
 }
@@ -25,6 +57,48 @@ func (b *BaseCommand) Name() string {
 	return b.name
 }
 
+// Aliases returns alternative command names
+func (b *BaseCommand) Aliases() []string {
+	return b.aliases
+}
+
+// Description returns a short description for the help listing
+func (b *BaseCommand) Description() string {
+	return b.description
+}
+
+// Args returns the argument specs
+func (b *BaseCommand) Args() []ArgSpec {
+	return b.args
+}
+
+// RequiredRole returns the access level needed to run the command
+func (b *BaseCommand) RequiredRole() Role {
+	return b.role
+}
+
+// Cooldown returns the command cooldowns
+func (b *BaseCommand) Cooldown() Cooldown {
+	return b.cooldown
+}
+
+// Usage returns the usage string built from the name and argument specs
+func (b *BaseCommand) Usage() string {
+	usage := b.name
+	for _, arg := range b.args {
+		name := arg.Name
+		if arg.Type == ArgChoice {
+			name = strings.Join(arg.Choices, "|")
+		}
+		if arg.Required {
+			usage += " <" + name + ">"
+		} else {
+			usage += " [" + name + "]"
+		}
+	}
+	return usage
+}
+
 // IsPrivateOnly returns whether command should only work in private chats
 func (b *BaseCommand) IsPrivateOnly() bool {
 	return b.privateOnly
@@ -39,6 +113,48 @@ func NewBaseCommand(name string, privateOnly bool) *BaseCommand {
 	}
 }
 
+// WithAliases sets alternative names. Latin aliases are also published as Telegram commands.
+func (b *BaseCommand) WithAliases(aliases ...string) *BaseCommand {
+	b.aliases = aliases
+	return b
+}
+
+// WithDescription sets the help description
+func (b *BaseCommand) WithDescription(description string) *BaseCommand {
+	b.description = description
+	return b
+}
+
+// WithArgs sets the argument specs parsed by the router
+func (b *BaseCommand) WithArgs(args ...ArgSpec) *BaseCommand {
+	b.args = args
+	return b
+}
+
+// NormalizeName lowercases a command name and strips its prefix and @bot mention
+func NormalizeName(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if at := strings.Index(name, "@"); at > 0 {
+		name = name[:at]
+	}
+	if name != "" && strings.ContainsRune(CommandPrefixes, rune(name[0])) {
+		name = name[1:]
+	}
+	return name
+}
+
+// WithRole sets the access level needed to run the command
+func (b *BaseCommand) WithRole(role Role) *BaseCommand {
+	b.role = role
+	return b
+}
+
+// WithCooldown sets per-user and per-chat cooldowns, zero disables either
+func (b *BaseCommand) WithCooldown(perUser, perChat time.Duration) *BaseCommand {
+	b.cooldown = Cooldown{PerUser: perUser, PerChat: perChat}
+	return b
+}
+
 // SafeSend safely sends a message with UTF-8 validation
 func (b *BaseCommand) SafeSend(c telebot.Context, text string, options ...*telebot.SendOptions) error {
 	return b.safeSender.SafeSend(c, text, options...)
@@ -0,0 +1,139 @@
+package commands
+
+import (
+	"fmt"
+	"html"
+	"math/rand"
+	"strings"
+	"time"
+
+	"gopkg.in/telebot.v3"
+	"gobrev/src/models"
+)
+
+const (
+	// dailyActiveDays is how recently a member must have written to take part
+	dailyActiveDays = 7
+	// dailyRevealDelay is the pause between reveal messages
+	dailyRevealDelay = 2 * time.Second
+)
+
+// dailyRevealSteps are the suspense messages, one random line from each step
+var dailyRevealSteps = [][]string{
+	{"🔮 Запускаю ритуал выбора пользователя дня…", "🎰 Кручу барабан судьбы…", "🛰 Подключаюсь к спутнику судьбы…"},
+	{"🔍 Изучаю %d кандидатов…", "📡 Сканирую %d активных участников…", "🧮 Считаю карму %d подозреваемых…"},
+	{"🥁 Барабанная дробь…", "🤔 Хм, неожиданно…", "⚡ Звёзды сошлись…"},
+}
+
+// DailyCommand handles .дня command
+type DailyCommand struct {
+	*BaseCommand
+	dailyManager    *models.DailyWinnerManager
+	statsManager    *models.StatsManager
+	privacyManager  *models.PrivacyManager
+	settingsManager *models.SettingsManager
+}
+
+// NewDailyCommand creates a new user of the day command
+func NewDailyCommand(dailyManager *models.DailyWinnerManager, statsManager *models.StatsManager, privacyManager *models.PrivacyManager, settingsManager *models.SettingsManager) *DailyCommand {
+	return &DailyCommand{
+		BaseCommand: NewBaseCommand(".дня", false).
+			WithAliases("day").
+			WithDescription("Пользователь дня, .дня стат — кто чаще побеждал").
+			WithArgs(ArgSpec{Name: "режим", Type: ArgChoice, Choices: []string{"стат", "топ"}}),
+		dailyManager:    dailyManager,
+		statsManager:    statsManager,
+		privacyManager:  privacyManager,
+		settingsManager: settingsManager,
+	}
+}
+
+// Execute picks the user of the day once per day and shows the stored result afterwards
+func (cmd *DailyCommand) Execute(c telebot.Context, metrics *models.Metrics) error {
+	metrics.RecordCommand()
+
+	if !requireGroup(c, cmd.BaseCommand) {
+		return nil
+	}
+	if GetArgs(c).String("режим") != "" {
+		return cmd.leaderboard(c)
+	}
+
+	chatID := c.Chat().ID
+	now := time.Now().In(cmd.settingsManager.Get(chatID).Location())
+	date := now.Format("2006-01-02")
+
+	candidates, err := cmd.candidates(chatID, now)
+	if err != nil {
+		return replyHTML(c, cmd.BaseCommand, "❌ Ошибка получения участников: "+html.EscapeString(err.Error()))
+	}
+
+	winner, created, err := cmd.dailyManager.Pick(chatID, date, candidates)
+	if err != nil {
+		return replyHTML(c, cmd.BaseCommand, "❌ Ошибка выбора: "+html.EscapeString(err.Error()))
+	}
+	if winner.UserID == 0 {
+		return replyHTML(c, cmd.BaseCommand, fmt.Sprintf("🤷 За последние %d дн. в чате никто не писал, выбирать не из кого", dailyActiveDays))
+	}
+	if !created {
+		return replyHTML(c, cmd.BaseCommand, fmt.Sprintf("🏆 Пользователь дня сегодня уже выбран: <b>%s</b>\nСледующий розыгрыш завтра", html.EscapeString(winner.Username)))
+	}
+
+	fmt.Printf("[+] User of the day in chat %d: %d (%d candidates)\n", chatID, winner.UserID, len(candidates))
+	return cmd.reveal(c, winner, len(candidates))
+}
+
+// candidates returns members who wrote recently and didn't opt out
+func (cmd *DailyCommand) candidates(chatID int64, now time.Time) ([]models.UserStats, error) {
+	active, err := cmd.statsManager.GetActiveUsers(chatID, now.AddDate(0, 0, -dailyActiveDays))
+	if err != nil {
+		return nil, err
+	}
+
+	var candidates []models.UserStats
+	for _, user := range active {
+		if !cmd.privacyManager.IsOptedOut(chatID, user.UserID) {
+			candidates = append(candidates, user)
+		}
+	}
+	return candidates, nil
+}
+
+// reveal announces the winner with a few suspense messages
+func (cmd *DailyCommand) reveal(c telebot.Context, winner models.DailyWinner, candidates int) error {
+	for _, step := range dailyRevealSteps {
+		line := step[rand.Intn(len(step))]
+		if strings.Contains(line, "%d") {
+			line = fmt.Sprintf(line, candidates)
+		}
+		if err := cmd.SafeSend(c, line); err != nil {
+			return err
+		}
+		time.Sleep(dailyRevealDelay)
+	}
+
+	user := &telebot.User{ID: winner.UserID, FirstName: winner.Username}
+	return cmd.SafeSend(c, fmt.Sprintf("🎉 Пользователь дня — %s!", MentionUser(user)), &telebot.SendOptions{
+		ParseMode: telebot.ModeHTML,
+	})
+}
+
+// leaderboard shows who was the user of the day most often
+func (cmd *DailyCommand) leaderboard(c telebot.Context) error {
+	chatID := c.Chat().ID
+
+	board, err := cmd.dailyManager.Leaderboard(chatID, cmd.settingsManager.Get(chatID).StatsTopUsers)
+	if err != nil {
+		return replyHTML(c, cmd.BaseCommand, "❌ Ошибка получения статистики: "+html.EscapeString(err.Error()))
+	}
+	if len(board) == 0 {
+		return replyHTML(c, cmd.BaseCommand, "🏆 Пользователя дня ещё ни разу не выбирали. Попробуй <code>.дня</code>")
+	}
+
+	var sb strings.Builder
+	sb.WriteString("🏆 <b>Пользователи дня</b>\n")
+	for i, entry := range board {
+		sb.WriteString(fmt.Sprintf("\n%s %s — <b>%d</b> раз", rankMedal(i), html.EscapeString(entry.Username), entry.Wins))
+	}
+	return replyHTML(c, cmd.BaseCommand, sb.String())
+}
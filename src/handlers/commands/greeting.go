@@ -0,0 +1,372 @@
+package commands
+
+import (
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/telebot.v3"
+	"gobrev/src/models"
+	"gobrev/src/utils"
+)
+
+const (
+	// aiWelcomeCooldown limits AI welcomes per chat, joins during a raid fall back to the template
+	aiWelcomeCooldown = 30 * time.Second
+	// aiWelcomeMaxTokens keeps AI welcomes short
+	aiWelcomeMaxTokens = 200
+	// defaultWelcome is used by the AI mode when the AI is unavailable and no template is set
+	defaultWelcome = "👋 Добро пожаловать в {chat}, {mention}!"
+)
+
+// greetingHelp explains .приветствие subcommands
+const greetingHelp = `👋 <b>Приветствие и прощание</b>
+
+<code>.приветствие текст Привет, {mention}! Ты {count}-й в {chat}</code>
+<code>.приветствие прощание Пока, {name}</code> или <code>.прощание Пока, {name}</code>
+<code>.приветствие кнопки Правила - https://t.me/rules | Сайт - https://example.com</code>
+<code>.приветствие удалять 10м</code> или <code>выкл</code>
+<code>.приветствие ии вкл</code> — ИИ сочиняет приветствие сам
+<code>.приветствие тест</code> — показать на себе
+<code>.приветствие сброс</code>
+
+Подстановки: {name} — имя, {mention} — ссылка на юзера, {chat} — название чата, {count} — число участников. "-" вместо текста убирает его.`
+
+// GreetingCommand handles .приветствие command
+type GreetingCommand struct {
+	*BaseCommand
+	greetingManager *models.GreetingManager
+	greeter         *Greeter
+}
+
+// NewGreetingCommand creates a new greeting command
+func NewGreetingCommand(greetingManager *models.GreetingManager, greeter *Greeter) *GreetingCommand {
+	return &GreetingCommand{
+		BaseCommand: NewBaseCommand(".приветствие", false).
+			WithAliases("welcome", ".прощание").
+			WithDescription("Приветствие новичков и прощание (админы)").
+			WithRole(RoleChatAdmin).
+			WithArgs(
+				ArgSpec{Name: "действие", Type: ArgChoice, Choices: []string{"текст", "прощание", "кнопки", "удалять", "ии", "тест", "сброс"}},
+				ArgSpec{Name: "значение", Type: ArgText},
+			),
+		greetingManager: greetingManager,
+		greeter:         greeter,
+	}
+}
+
+// Execute shows or changes greeting templates
+func (cmd *GreetingCommand) Execute(c telebot.Context, metrics *models.Metrics) error {
+	metrics.RecordCommand()
+
+	if !requireGroup(c, cmd.BaseCommand) {
+		return nil
+	}
+
+	chatID := c.Chat().ID
+	action := GetArgs(c).String("действие")
+
+	// Templates keep their line breaks, so the value is taken from the raw text.
+	// The .прощание alias sets the goodbye, everything after it is the template.
+	value := ""
+	if fields := strings.Fields(c.Text()); len(fields) > 0 && NormalizeName(fields[0]) == "прощание" {
+		action = "прощание"
+		value = textAfterFields(c.Text(), 1)
+	} else if action != "" {
+		value = textAfterFields(c.Text(), 2)
+	}
+
+	greeting := cmd.greetingManager.Get(chatID)
+
+	switch action {
+	case "":
+		return replyHTML(c, cmd.BaseCommand, formatGreeting(greeting)+"\n\n"+greetingHelp)
+
+	case "тест":
+		if !greeting.Enabled() {
+			return replyHTML(c, cmd.BaseCommand, "ℹ️ Приветствие не настроено\n\n"+greetingHelp)
+		}
+		return cmd.greeter.Welcome(c.Bot(), c.Chat(), c.Sender())
+
+	case "сброс":
+		if err := cmd.greetingManager.Reset(chatID); err != nil {
+			return replyHTML(c, cmd.BaseCommand, "❌ Ошибка сброса: "+html.EscapeString(err.Error()))
+		}
+		fmt.Printf("[+] Greeting reset in chat %d by user %d\n", chatID, c.Sender().ID)
+		return replyHTML(c, cmd.BaseCommand, "♻️ Приветствие и прощание выключены")
+	}
+
+	if value == "" {
+		return replyHTML(c, cmd.BaseCommand, greetingHelp)
+	}
+
+	if err := applyGreeting(&greeting, action, value); err != nil {
+		return replyHTML(c, cmd.BaseCommand, "❌ "+html.EscapeString(err.Error()))
+	}
+	if err := cmd.greetingManager.Save(greeting); err != nil {
+		return replyHTML(c, cmd.BaseCommand, "❌ "+html.EscapeString(err.Error()))
+	}
+
+	fmt.Printf("[+] Greeting %s changed in chat %d by user %d\n", action, chatID, c.Sender().ID)
+	return replyHTML(c, cmd.BaseCommand, "✅ Сохранено\n\n"+formatGreeting(greeting))
+}
+
+// applyGreeting parses a text value into the greeting field named by action
+func applyGreeting(greeting *models.Greeting, action, value string) error {
+	if value == "-" {
+		value = ""
+	}
+
+	switch action {
+	case "текст":
+		greeting.Welcome = value
+
+	case "прощание":
+		greeting.Goodbye = value
+
+	case "кнопки":
+		buttons, err := parseGreetingButtons(value)
+		if err != nil {
+			return err
+		}
+		greeting.Buttons = buttons
+
+	case "удалять":
+		switch strings.ToLower(value) {
+		case "", "выкл", "0":
+			greeting.DeleteMinutes = 0
+		default:
+			duration, err := ParseDuration(value)
+			if err != nil {
+				return fmt.Errorf("автоудаление: время, например 10м, или выкл")
+			}
+			greeting.DeleteMinutes = max(1, int(duration/time.Minute))
+		}
+
+	case "ии":
+		switch strings.ToLower(value) {
+		case "вкл":
+			greeting.AI = true
+		case "выкл", "":
+			greeting.AI = false
+		default:
+			return fmt.Errorf("ии: вкл или выкл")
+		}
+	}
+
+	return nil
+}
+
+// parseGreetingButtons parses "Текст - URL" pairs separated by "|" or new lines
+func parseGreetingButtons(value string) ([]models.GreetingButton, error) {
+	var buttons []models.GreetingButton
+
+	for _, line := range strings.FieldsFunc(value, func(r rune) bool { return r == '|' || r == '\n' }) {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		separator := strings.LastIndex(line, " - ")
+		if separator < 0 {
+			return nil, fmt.Errorf("кнопка %q: нужно «Текст - ссылка»", line)
+		}
+		text := strings.TrimSpace(line[:separator])
+		url := strings.TrimSpace(line[separator+3:])
+
+		if strings.HasPrefix(url, "t.me/") {
+			url = "https://" + url
+		}
+		if text == "" || !(strings.HasPrefix(url, "https://") || strings.HasPrefix(url, "http://") || strings.HasPrefix(url, "tg://")) {
+			return nil, fmt.Errorf("кнопка %q: нужно «Текст - ссылка»", line)
+		}
+
+		buttons = append(buttons, models.GreetingButton{Text: text, URL: url})
+	}
+
+	return buttons, nil
+}
+
+// formatGreeting renders current greeting settings as HTML
+func formatGreeting(greeting models.Greeting) string {
+	welcome := greeting.Welcome
+	if welcome == "" {
+		welcome = "—"
+	}
+	goodbye := greeting.Goodbye
+	if goodbye == "" {
+		goodbye = "—"
+	}
+
+	var buttons []string
+	for _, button := range greeting.Buttons {
+		buttons = append(buttons, html.EscapeString(button.Text))
+	}
+	if len(buttons) == 0 {
+		buttons = []string{"—"}
+	}
+
+	deleteAfter := "выкл"
+	if greeting.DeleteMinutes > 0 {
+		deleteAfter = formatMinutes(time.Duration(greeting.DeleteMinutes) * time.Minute)
+	}
+	ai := "выкл"
+	if greeting.AI {
+		ai = "вкл"
+	}
+
+	return fmt.Sprintf(`👋 Приветствие: <i>%s</i>
+🚪 Прощание: <i>%s</i>
+🔗 Кнопки: %s
+🗑 Автоудаление: <b>%s</b>
+🤖 ИИ-приветствие: <b>%s</b>`,
+		html.EscapeString(welcome),
+		html.EscapeString(goodbye),
+		strings.Join(buttons, ", "),
+		deleteAfter,
+		ai)
+}
+
+// Greeter sends welcome and goodbye messages from chat templates
+type Greeter struct {
+	greetingManager *models.GreetingManager
+	aiClient        *utils.AIClient // nil when AI isn't configured
+	aiCooldowns     *utils.CooldownTracker
+}
+
+// NewGreeter creates a greeter; without AI credentials AI welcomes fall back to templates
+func NewGreeter(greetingManager *models.GreetingManager) *Greeter {
+	aiClient, err := utils.NewAIClient()
+	if err != nil {
+		fmt.Printf("[-] AI welcomes disabled: %v\n", err)
+	}
+
+	return &Greeter{
+		greetingManager: greetingManager,
+		aiClient:        aiClient,
+		aiCooldowns:     utils.NewCooldownTracker(),
+	}
+}
+
+// Welcome greets a newcomer if the chat has a welcome configured
+func (g *Greeter) Welcome(bot *telebot.Bot, chat *telebot.Chat, user *telebot.User) error {
+	greeting := g.greetingManager.Get(chat.ID)
+	if !greeting.Enabled() || user.IsBot {
+		return nil
+	}
+	chat = fullChat(bot, chat)
+
+	text := ""
+	if greeting.AI {
+		text = g.aiWelcome(chat, user)
+	}
+	if text == "" {
+		template := greeting.Welcome
+		if template == "" {
+			template = defaultWelcome
+		}
+		text = renderGreeting(bot, template, chat, user)
+	}
+
+	options := &telebot.SendOptions{ParseMode: telebot.ModeHTML, DisableWebPagePreview: true}
+	if len(greeting.Buttons) > 0 {
+		var rows [][]telebot.InlineButton
+		for _, button := range greeting.Buttons {
+			rows = append(rows, []telebot.InlineButton{{Text: button.Text, URL: button.URL}})
+		}
+		options.ReplyMarkup = &telebot.ReplyMarkup{InlineKeyboard: rows}
+	}
+
+	return g.send(bot, chat, text, options, greeting.DeleteMinutes)
+}
+
+// Goodbye says goodbye to a member who left if the chat has a goodbye configured
+func (g *Greeter) Goodbye(bot *telebot.Bot, chat *telebot.Chat, user *telebot.User) error {
+	greeting := g.greetingManager.Get(chat.ID)
+	if greeting.Goodbye == "" || user.IsBot {
+		return nil
+	}
+	chat = fullChat(bot, chat)
+
+	text := renderGreeting(bot, greeting.Goodbye, chat, user)
+	return g.send(bot, chat, text, &telebot.SendOptions{ParseMode: telebot.ModeHTML, DisableWebPagePreview: true}, greeting.DeleteMinutes)
+}
+
+// send posts a greeting and schedules its deletion
+func (g *Greeter) send(bot *telebot.Bot, chat *telebot.Chat, text string, options *telebot.SendOptions, deleteMinutes int) error {
+	msg, err := bot.Send(chat, text, options)
+	if err != nil {
+		fmt.Printf("[-] Failed to send greeting in chat %d: %v\n", chat.ID, err)
+		return err
+	}
+
+	if deleteMinutes > 0 {
+		time.AfterFunc(time.Duration(deleteMinutes)*time.Minute, func() {
+			bot.Delete(msg)
+		})
+	}
+	return nil
+}
+
+// aiWelcome generates a personalised welcome in the bot persona, empty on failure or cooldown
+func (g *Greeter) aiWelcome(chat *telebot.Chat, user *telebot.User) string {
+	key := strconv.FormatInt(chat.ID, 10)
+	if g.aiClient == nil || g.aiCooldowns.Remaining(key, aiWelcomeCooldown) > 0 {
+		return ""
+	}
+	g.aiCooldowns.Touch(key)
+
+	name := strings.TrimSpace(user.FirstName + " " + user.LastName)
+	prompt := fmt.Sprintf("В чат «%s» только что зашёл новый участник %s. Поприветствуй его одним-двумя предложениями в своём стиле, обращаясь по имени. Без markdown.", chat.Title, name)
+
+	answer, err := g.aiClient.QuickChat(prompt,
+		utils.WithSystemMessage(AISystemPrompt),
+		utils.WithMaxTokens(aiWelcomeMaxTokens),
+		utils.WithUserContext(name, ""),
+	)
+	if err != nil {
+		fmt.Printf("[-] AI welcome failed in chat %d: %v\n", chat.ID, err)
+		return ""
+	}
+
+	answer = strings.TrimSpace(answer)
+	if answer == "" {
+		return ""
+	}
+	return MentionUser(user) + ", " + html.EscapeString(answer)
+}
+
+// renderGreeting escapes a template and fills in its placeholders
+func renderGreeting(bot *telebot.Bot, template string, chat *telebot.Chat, user *telebot.User) string {
+	name := strings.TrimSpace(user.FirstName + " " + user.LastName)
+	if name == "" {
+		name = user.Username
+	}
+
+	count := "?"
+	if strings.Contains(template, "{count}") {
+		if n, err := bot.Len(chat); err == nil {
+			count = strconv.Itoa(n)
+		}
+	}
+
+	return strings.NewReplacer(
+		"{name}", html.EscapeString(name),
+		"{mention}", MentionUser(user),
+		"{chat}", html.EscapeString(chat.Title),
+		"{count}", count,
+	).Replace(html.EscapeString(template))
+}
+
+// fullChat loads the chat title when only the ID is known
+func fullChat(bot *telebot.Bot, chat *telebot.Chat) *telebot.Chat {
+	if chat.Title != "" {
+		return chat
+	}
+	if full, err := bot.ChatByID(chat.ID); err == nil {
+		return full
+	}
+	return chat
+}
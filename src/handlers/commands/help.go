@@ -0,0 +1,118 @@
+package commands
+
+import (
+	"fmt"
+	"html"
+	"strings"
+	"time"
+
+	"gopkg.in/telebot.v3"
+	"gobrev/src/models"
+)
+
+// HelpCommand handles .помощь command
+type HelpCommand struct {
+	*BaseCommand
+	listCommands func() []Command
+}
+
+// NewHelpCommand creates a new help command. listCommands returns commands in registration order.
+func NewHelpCommand(listCommands func() []Command) *HelpCommand {
+	return &HelpCommand{
+		BaseCommand: NewBaseCommand(".помощь", false).
+			WithAliases("help", ".хелп", ".команды").
+			WithDescription("Список команд").
+			WithArgs(ArgSpec{Name: "команда", Type: ArgWord}),
+		listCommands: listCommands,
+	}
+}
+
+// Execute sends the command listing or details of a single command
+func (cmd *HelpCommand) Execute(c telebot.Context, metrics *models.Metrics) error {
+	metrics.RecordCommand()
+
+	isPrivate := c.Chat().Type == telebot.ChatPrivate
+
+	if name := GetArgs(c).String("команда"); name != "" {
+		for _, command := range cmd.listCommands() {
+			if matchesName(command, name) {
+				return cmd.SafeSend(c, formatCommandHelp(command, true), &telebot.SendOptions{
+					ParseMode: telebot.ModeHTML,
+					ReplyTo:   c.Message(),
+				})
+			}
+		}
+		return cmd.SafeSend(c, "❌ Нет такой команды. Список: <code>.помощь</code>", &telebot.SendOptions{
+			ParseMode: telebot.ModeHTML,
+			ReplyTo:   c.Message(),
+		})
+	}
+
+	var message strings.Builder
+	message.WriteString("📖 <b>Команды</b>\n\n")
+
+	for _, command := range cmd.listCommands() {
+		if command.Description() == "" || (command.IsPrivateOnly() && !isPrivate) {
+			continue
+		}
+		message.WriteString(formatCommandHelp(command, false))
+		message.WriteString("\n")
+	}
+
+	message.WriteString("\n<i>Префиксы: . / ! • Подробнее: <code>.помощь стат</code></i>")
+
+	return cmd.SafeSend(c, message.String(), &telebot.SendOptions{
+		ParseMode: telebot.ModeHTML,
+		ReplyTo:   c.Message(),
+	})
+}
+
+// formatCommandHelp formats a command line for the help listing
+func formatCommandHelp(command Command, detailed bool) string {
+	line := fmt.Sprintf("<code>%s</code> — %s", html.EscapeString(command.Usage()), html.EscapeString(command.Description()))
+	if !detailed {
+		return line
+	}
+
+	if aliases := command.Aliases(); len(aliases) > 0 {
+		line += "\n<i>Синонимы:</i> " + html.EscapeString(strings.Join(aliases, ", "))
+	}
+	if command.IsPrivateOnly() {
+		line += "\n<i>Только в личных сообщениях</i>"
+	}
+	switch command.RequiredRole() {
+	case RoleChatAdmin:
+		line += "\n<i>Только для администраторов чата</i>"
+	case RoleBotOwner:
+		line += "\n<i>Только для владельца бота</i>"
+	}
+	if cooldown := command.Cooldown(); cooldown.PerUser > 0 || cooldown.PerChat > 0 {
+		line += "\n<i>Не чаще чем раз в " + formatCooldown(max(cooldown.PerUser, cooldown.PerChat)) + "</i>"
+	}
+	return line
+}
+
+// matchesName checks a command name or alias ignoring the prefix
+func matchesName(command Command, name string) bool {
+	name = NormalizeName(name)
+	if NormalizeName(command.Name()) == name {
+		return true
+	}
+	for _, alias := range command.Aliases() {
+		if NormalizeName(alias) == name {
+			return true
+		}
+	}
+	return false
+}
+
+// formatCooldown formats a cooldown for help text
+func formatCooldown(d time.Duration) string {
+	if d >= time.Hour && d%time.Hour == 0 {
+		return fmt.Sprintf("%d ч", int(d.Hours()))
+	}
+	if d >= time.Minute && d%time.Minute == 0 {
+		return fmt.Sprintf("%d мин", int(d.Minutes()))
+	}
+	return fmt.Sprintf("%d сек", int(d.Seconds()))
+}
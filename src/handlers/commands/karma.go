@@ -0,0 +1,102 @@
+package commands
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"strings"
+
+	"gopkg.in/telebot.v3"
+	"gobrev/src/models"
+	"gobrev/src/utils"
+)
+
+// karmaPodium labels the karma leaderboard image
+var karmaPodium = utils.PodiumLabels{
+	Title: "☯️ Карма чата",
+	Value: func(count int) string { return fmt.Sprintf("карма %d", count) },
+}
+
+// KarmaCommand handles .карма command
+type KarmaCommand struct {
+	*BaseCommand
+	karmaManager    *models.KarmaManager
+	settingsManager *models.SettingsManager
+}
+
+// NewKarmaCommand creates a new karma command
+func NewKarmaCommand(karmaManager *models.KarmaManager, settingsManager *models.SettingsManager) *KarmaCommand {
+	return &KarmaCommand{
+		BaseCommand: NewBaseCommand(".карма", false).
+			WithAliases("karma").
+			WithDescription("Топ кармы чата, ответом — карма участника"),
+		karmaManager:    karmaManager,
+		settingsManager: settingsManager,
+	}
+}
+
+// Execute shows the karma leaderboard, or the karma of the replied member
+func (cmd *KarmaCommand) Execute(c telebot.Context, metrics *models.Metrics) error {
+	metrics.RecordCommand()
+
+	if !requireGroup(c, cmd.BaseCommand) {
+		return nil
+	}
+	chatID := c.Chat().ID
+
+	if reply := c.Message().ReplyTo; reply != nil && reply.Sender != nil && !reply.Sender.IsBot {
+		stats := cmd.karmaManager.Get(chatID, reply.Sender.ID)
+		return replyHTML(c, cmd.BaseCommand, fmt.Sprintf("☯️ Карма %s: <b>%d</b> (👍 %d, 👎 %d)",
+			MentionUser(reply.Sender), stats.Karma, stats.Plus, stats.Minus))
+	}
+
+	top, err := cmd.karmaManager.Top(chatID, cmd.settingsManager.Get(chatID).StatsTopUsers)
+	if err != nil {
+		return replyHTML(c, cmd.BaseCommand, "❌ Ошибка получения кармы: "+html.EscapeString(err.Error()))
+	}
+	if len(top) == 0 {
+		return replyHTML(c, cmd.BaseCommand, "☯️ Карма пока пуста. Ответь <code>+</code> или <code>спасибо</code> на полезное сообщение!")
+	}
+
+	caption := cmd.buildCaption(top, cmd.karmaManager.Get(chatID, c.Sender().ID))
+
+	// Only members with positive karma stand on the podium
+	var podium []models.UserStats
+	for _, stats := range top {
+		if len(podium) == 3 || stats.Karma <= 0 {
+			break
+		}
+		podium = append(podium, models.UserStats{UserID: stats.UserID, Username: stats.Username, MessageCount: stats.Karma})
+	}
+	if len(podium) == 0 {
+		return replyHTML(c, cmd.BaseCommand, caption)
+	}
+
+	imageBuffer, err := utils.GenerateTopUsersImage(podium, c.Bot(), karmaPodium)
+	if err != nil {
+		fmt.Printf("[-] Failed to generate karma image: %v\n", err)
+		return replyHTML(c, cmd.BaseCommand, caption)
+	}
+
+	photo := &telebot.Photo{
+		File:    telebot.FromReader(bytes.NewReader(imageBuffer)),
+		Caption: caption,
+	}
+	return cmd.safeSender.SafeSendPhoto(c, photo, &telebot.SendOptions{
+		ParseMode: telebot.ModeHTML,
+		ReplyTo:   c.Message(),
+	})
+}
+
+// buildCaption lists the leaderboard and the karma of the sender
+func (cmd *KarmaCommand) buildCaption(top []models.KarmaStats, own models.KarmaStats) string {
+	var sb strings.Builder
+	sb.WriteString("☯️ <b>Карма чата</b>\n")
+
+	for i, stats := range top {
+		sb.WriteString(fmt.Sprintf("\n%d. %s — <b>%d</b>", i+1, html.EscapeString(stats.Username), stats.Karma))
+	}
+
+	sb.WriteString(fmt.Sprintf("\n\nТвоя карма: <b>%d</b>, вес голоса: %d", own.Karma, models.KarmaWeight(own.Karma)))
+	return sb.String()
+}
@@ -0,0 +1,483 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/telebot.v3"
+	"gobrev/src/models"
+)
+
+// moderator holds helpers shared by moderation commands
+type moderator struct {
+	warningManager  *models.WarningManager
+	statsManager    *models.StatsManager
+	settingsManager *models.SettingsManager
+}
+
+// resolveTarget returns the user a moderation command is aimed at and the remaining argument words.
+// The target is the author of the replied message, or the first argument: a text mention, @username or user ID.
+func (m *moderator) resolveTarget(c telebot.Context) (*telebot.User, []string, error) {
+	msg := c.Message()
+	words := GetArgs(c).Raw()
+
+	if msg.ReplyTo != nil && msg.ReplyTo.Sender != nil {
+		return msg.ReplyTo.Sender, words, nil
+	}
+
+	// Mentions of users without @username come as text_mention entities
+	for _, entity := range msg.Entities {
+		if entity.Type == telebot.EntityTMention && entity.User != nil {
+			_, rest, _ := strings.Cut(msg.Text, msg.EntityText(entity))
+			return entity.User, strings.Fields(rest), nil
+		}
+	}
+
+	if len(words) == 0 {
+		return nil, nil, errors.New("ответь на сообщение нарушителя или укажи @username")
+	}
+
+	first := words[0]
+	if userID, err := strconv.ParseInt(first, 10, 64); err == nil {
+		member, err := c.Bot().ChatMemberOf(c.Chat(), &telebot.User{ID: userID})
+		if err != nil {
+			return nil, nil, fmt.Errorf("юзер %d не найден в чате", userID)
+		}
+		return member.User, words[1:], nil
+	}
+
+	if strings.HasPrefix(first, "@") {
+		stats, ok := m.statsManager.FindUserByHandle(c.Chat().ID, first)
+		if !ok {
+			return nil, nil, fmt.Errorf("не знаю %s: он ещё ничего не писал, ответь на его сообщение", first)
+		}
+		return &telebot.User{ID: stats.UserID, FirstName: stats.Username, Username: stats.Handle}, words[1:], nil
+	}
+
+	return nil, nil, errors.New("ответь на сообщение нарушителя или укажи @username")
+}
+
+// checkTarget refuses to punish the bot, the sender themselves and chat admins
+func (m *moderator) checkTarget(c telebot.Context, user *telebot.User) error {
+	if user.ID == c.Bot().Me.ID {
+		return errors.New("себя наказывать не буду")
+	}
+	if user.ID == c.Sender().ID {
+		return errors.New("себя наказывать нельзя")
+	}
+
+	member, err := c.Bot().ChatMemberOf(c.Chat(), user)
+	if err != nil {
+		return fmt.Errorf("не удалось проверить юзера: %v", err)
+	}
+	if member.Role == telebot.Creator || member.Role == telebot.Administrator {
+		return errors.New("админов наказывать нельзя")
+	}
+	return nil
+}
+
+// checkBotRights makes sure the bot can restrict and ban members
+func (m *moderator) checkBotRights(c telebot.Context) error {
+	member, err := c.Bot().ChatMemberOf(c.Chat(), c.Bot().Me)
+	if err != nil {
+		return fmt.Errorf("не удалось проверить мои права: %v", err)
+	}
+	if member.Role != telebot.Administrator {
+		return errors.New("сделай меня админом чата")
+	}
+	if !member.CanRestrictMembers {
+		return errors.New("дай мне право блокировать участников")
+	}
+	return nil
+}
+
+// mute takes all rights from the user for the given duration
+func (m *moderator) mute(c telebot.Context, user *telebot.User, duration time.Duration) error {
+	return c.Bot().Restrict(c.Chat(), &telebot.ChatMember{
+		User:            user,
+		Rights:          telebot.NoRights(),
+		RestrictedUntil: time.Now().Add(duration).Unix(),
+	})
+}
+
+// ban removes the user from the chat until unbanned
+func (m *moderator) ban(c telebot.Context, user *telebot.User) error {
+	return c.Bot().Ban(c.Chat(), &telebot.ChatMember{User: user})
+}
+
+// RestoreMemberRights gives the user the chat's default permissions back.
+// Without chat permissions the user gets the rights of a regular member, never a mute.
+func RestoreMemberRights(bot *telebot.Bot, chatID int64, user *telebot.User) error {
+	chat, err := bot.ChatByID(chatID)
+	if err != nil {
+		return err
+	}
+
+	rights := telebot.NoRestrictions()
+	if chat.Permissions != nil {
+		rights = *chat.Permissions
+	}
+	return bot.Restrict(chat, &telebot.ChatMember{User: user, Rights: rights, RestrictedUntil: telebot.Forever()})
+}
+
+// requireGroup replies and returns false outside of groups
+func requireGroup(c telebot.Context, cmd *BaseCommand) bool {
+	if c.Chat().Type == telebot.ChatGroup || c.Chat().Type == telebot.ChatSuperGroup {
+		return true
+	}
+	cmd.SafeSend(c, "❌ Эта команда работает только в группах")
+	return false
+}
+
+// MentionUser returns an HTML link to the user
+func MentionUser(user *telebot.User) string {
+	name := strings.TrimSpace(user.FirstName + " " + user.LastName)
+	if name == "" {
+		name = user.Username
+	}
+	if name == "" {
+		name = strconv.FormatInt(user.ID, 10)
+	}
+	return fmt.Sprintf(`<a href="tg://user?id=%d">%s</a>`, user.ID, html.EscapeString(name))
+}
+
+// formatMinutes formats a duration like "1 ч. 30 мин."
+func formatMinutes(duration time.Duration) string {
+	minutes := int(duration / time.Minute)
+	switch {
+	case minutes >= 24*60 && minutes%(24*60) == 0:
+		return fmt.Sprintf("%d дн.", minutes/(24*60))
+	case minutes >= 60 && minutes%60 == 0:
+		return fmt.Sprintf("%d ч.", minutes/60)
+	case minutes >= 60:
+		return fmt.Sprintf("%d ч. %d мин.", minutes/60, minutes%60)
+	}
+	return fmt.Sprintf("%d мин.", minutes)
+}
+
+// replyHTML sends an HTML reply to the command message
+func replyHTML(c telebot.Context, cmd *BaseCommand, text string) error {
+	return cmd.SafeSend(c, text, &telebot.SendOptions{
+		ParseMode: telebot.ModeHTML,
+		ReplyTo:   c.Message(),
+	})
+}
+
+// WarnCommand handles .варн command
+type WarnCommand struct {
+	*BaseCommand
+	moderator
+}
+
+// NewWarnCommand creates a new warn command
+func NewWarnCommand(warningManager *models.WarningManager, statsManager *models.StatsManager, settingsManager *models.SettingsManager) *WarnCommand {
+	return &WarnCommand{
+		BaseCommand: NewBaseCommand(".варн", false).
+			WithAliases("warn").
+			WithDescription("Выдать варн (ответом на сообщение)").
+			WithRole(RoleChatAdmin).
+			WithArgs(ArgSpec{Name: "причина", Type: ArgText}),
+		moderator: moderator{warningManager, statsManager, settingsManager},
+	}
+}
+
+// Execute warns the user and escalates when the chat's warning limit is reached
+func (cmd *WarnCommand) Execute(c telebot.Context, metrics *models.Metrics) error {
+	metrics.RecordCommand()
+	if !requireGroup(c, cmd.BaseCommand) {
+		return nil
+	}
+
+	user, rest, err := cmd.resolveTarget(c)
+	if err == nil {
+		err = cmd.checkTarget(c, user)
+	}
+	if err != nil {
+		return replyHTML(c, cmd.BaseCommand, "❌ "+html.EscapeString(err.Error()))
+	}
+
+	chatID := c.Chat().ID
+	settings := cmd.settingsManager.Get(chatID)
+	reason := strings.Join(rest, " ")
+
+	count, err := cmd.warningManager.Add(chatID, user.ID, c.Sender().ID, reason, time.Duration(settings.WarnDays)*24*time.Hour)
+	if err != nil {
+		fmt.Printf("[-] Failed to store warning: %v\n", err)
+		return replyHTML(c, cmd.BaseCommand, "❌ Не удалось сохранить варн")
+	}
+	fmt.Printf("[+] User %d warned in chat %d by %d (%d/%d)\n", user.ID, chatID, c.Sender().ID, count, settings.WarnLimit)
+
+	text := fmt.Sprintf("❗ %s получает варн <b>%d/%d</b>", MentionUser(user), count, settings.WarnLimit)
+	if reason != "" {
+		text += "\nПричина: " + html.EscapeString(reason)
+	}
+
+	if count >= settings.WarnLimit {
+		text += "\n\n" + cmd.escalate(c, user, settings)
+	}
+
+	return replyHTML(c, cmd.BaseCommand, text)
+}
+
+// escalate applies the chat's punishment for reaching the warning limit
+func (cmd *WarnCommand) escalate(c telebot.Context, user *telebot.User, settings models.ChatSettings) string {
+	if err := cmd.checkBotRights(c); err != nil {
+		return "⚠️ Лимит варнов достигнут, но наказать не могу: " + html.EscapeString(err.Error())
+	}
+
+	var err error
+	var result string
+	if settings.WarnAction == models.WarnActionBan {
+		err = cmd.ban(c, user)
+		result = "🔨 Лимит варнов: бан"
+	} else {
+		duration := time.Duration(settings.MuteMinutes) * time.Minute
+		err = cmd.mute(c, user, duration)
+		result = "🔇 Лимит варнов: мут на " + formatMinutes(duration)
+	}
+	if err != nil {
+		fmt.Printf("[-] Warning escalation failed for user %d in chat %d: %v\n", user.ID, c.Chat().ID, err)
+		return "⚠️ Лимит варнов достигнут, но наказать не вышло: " + html.EscapeString(err.Error())
+	}
+
+	if _, err := cmd.warningManager.Clear(c.Chat().ID, user.ID); err != nil {
+		fmt.Printf("[-] Failed to clear warnings: %v\n", err)
+	}
+	return result
+}
+
+// MuteCommand handles .мут command
+type MuteCommand struct {
+	*BaseCommand
+	moderator
+}
+
+// NewMuteCommand creates a new mute command
+func NewMuteCommand(warningManager *models.WarningManager, statsManager *models.StatsManager, settingsManager *models.SettingsManager) *MuteCommand {
+	return &MuteCommand{
+		BaseCommand: NewBaseCommand(".мут", false).
+			WithAliases("mute").
+			WithDescription("Замутить юзера (ответом на сообщение)").
+			WithRole(RoleChatAdmin).
+			WithArgs(
+				ArgSpec{Name: "время", Type: ArgDuration},
+				ArgSpec{Name: "причина", Type: ArgText},
+			),
+		moderator: moderator{warningManager, statsManager, settingsManager},
+	}
+}
+
+// Execute mutes the user for the given or the chat's default duration
+func (cmd *MuteCommand) Execute(c telebot.Context, metrics *models.Metrics) error {
+	metrics.RecordCommand()
+	if !requireGroup(c, cmd.BaseCommand) {
+		return nil
+	}
+
+	user, rest, err := cmd.resolveTarget(c)
+	if err == nil {
+		err = cmd.checkTarget(c, user)
+	}
+	if err == nil {
+		err = cmd.checkBotRights(c)
+	}
+	if err != nil {
+		return replyHTML(c, cmd.BaseCommand, "❌ "+html.EscapeString(err.Error()))
+	}
+
+	// Arguments after an explicit target are parsed again without it
+	args, err := ParseArgs(cmd.Args(), rest)
+	if err != nil {
+		return replyHTML(c, cmd.BaseCommand, "❌ "+html.EscapeString(err.Error()))
+	}
+
+	settings := cmd.settingsManager.Get(c.Chat().ID)
+	duration := args.Duration("время", time.Duration(settings.MuteMinutes)*time.Minute)
+
+	if err := cmd.mute(c, user, duration); err != nil {
+		fmt.Printf("[-] Failed to mute user %d in chat %d: %v\n", user.ID, c.Chat().ID, err)
+		return replyHTML(c, cmd.BaseCommand, "❌ Не удалось замутить: "+html.EscapeString(err.Error()))
+	}
+	fmt.Printf("[+] User %d muted in chat %d by %d for %v\n", user.ID, c.Chat().ID, c.Sender().ID, duration)
+
+	text := fmt.Sprintf("🔇 %s в муте на %s", MentionUser(user), formatMinutes(duration))
+	if reason := args.String("причина"); reason != "" {
+		text += "\nПричина: " + html.EscapeString(reason)
+	}
+	return replyHTML(c, cmd.BaseCommand, text)
+}
+
+// BanCommand handles .бан command
+type BanCommand struct {
+	*BaseCommand
+	moderator
+}
+
+// NewBanCommand creates a new ban command
+func NewBanCommand(warningManager *models.WarningManager, statsManager *models.StatsManager, settingsManager *models.SettingsManager) *BanCommand {
+	return &BanCommand{
+		BaseCommand: NewBaseCommand(".бан", false).
+			WithAliases("ban").
+			WithDescription("Забанить юзера (ответом на сообщение)").
+			WithRole(RoleChatAdmin).
+			WithArgs(ArgSpec{Name: "причина", Type: ArgText}),
+		moderator: moderator{warningManager, statsManager, settingsManager},
+	}
+}
+
+// Execute bans the user
+func (cmd *BanCommand) Execute(c telebot.Context, metrics *models.Metrics) error {
+	metrics.RecordCommand()
+	if !requireGroup(c, cmd.BaseCommand) {
+		return nil
+	}
+
+	user, rest, err := cmd.resolveTarget(c)
+	if err == nil {
+		err = cmd.checkTarget(c, user)
+	}
+	if err == nil {
+		err = cmd.checkBotRights(c)
+	}
+	if err != nil {
+		return replyHTML(c, cmd.BaseCommand, "❌ "+html.EscapeString(err.Error()))
+	}
+
+	if err := cmd.ban(c, user); err != nil {
+		fmt.Printf("[-] Failed to ban user %d in chat %d: %v\n", user.ID, c.Chat().ID, err)
+		return replyHTML(c, cmd.BaseCommand, "❌ Не удалось забанить: "+html.EscapeString(err.Error()))
+	}
+	fmt.Printf("[+] User %d banned in chat %d by %d\n", user.ID, c.Chat().ID, c.Sender().ID)
+
+	text := fmt.Sprintf("🔨 %s забанен", MentionUser(user))
+	if reason := strings.Join(rest, " "); reason != "" {
+		text += "\nПричина: " + html.EscapeString(reason)
+	}
+	return replyHTML(c, cmd.BaseCommand, text)
+}
+
+// UnbanCommand handles .разбан command
+type UnbanCommand struct {
+	*BaseCommand
+	moderator
+}
+
+// NewUnbanCommand creates a new unban command
+func NewUnbanCommand(warningManager *models.WarningManager, statsManager *models.StatsManager, settingsManager *models.SettingsManager) *UnbanCommand {
+	return &UnbanCommand{
+		BaseCommand: NewBaseCommand(".разбан", false).
+			WithAliases("unban", ".размут").
+			WithDescription("Снять бан или мут и сбросить варны").
+			WithRole(RoleChatAdmin).
+			WithArgs(ArgSpec{Name: "юзер", Type: ArgText}),
+		moderator: moderator{warningManager, statsManager, settingsManager},
+	}
+}
+
+// Execute lifts a ban or mute and clears the user's warnings
+func (cmd *UnbanCommand) Execute(c telebot.Context, metrics *models.Metrics) error {
+	metrics.RecordCommand()
+	if !requireGroup(c, cmd.BaseCommand) {
+		return nil
+	}
+
+	user, _, err := cmd.resolveTarget(c)
+	if err == nil {
+		err = cmd.checkBotRights(c)
+	}
+	if err != nil {
+		return replyHTML(c, cmd.BaseCommand, "❌ "+html.EscapeString(err.Error()))
+	}
+
+	member, err := c.Bot().ChatMemberOf(c.Chat(), user)
+	if err != nil {
+		return replyHTML(c, cmd.BaseCommand, "❌ Не удалось проверить юзера: "+html.EscapeString(err.Error()))
+	}
+
+	switch member.Role {
+	case telebot.Kicked:
+		err = c.Bot().Unban(c.Chat(), user, true)
+	case telebot.Restricted:
+		// Give back the chat's default permissions
+		err = RestoreMemberRights(c.Bot(), c.Chat().ID, user)
+	}
+	if err != nil {
+		fmt.Printf("[-] Failed to unban user %d in chat %d: %v\n", user.ID, c.Chat().ID, err)
+		return replyHTML(c, cmd.BaseCommand, "❌ Не удалось снять ограничения: "+html.EscapeString(err.Error()))
+	}
+
+	cleared, err := cmd.warningManager.Clear(c.Chat().ID, user.ID)
+	if err != nil {
+		fmt.Printf("[-] Failed to clear warnings: %v\n", err)
+	}
+	fmt.Printf("[+] User %d unbanned in chat %d by %d, %d warnings cleared\n", user.ID, c.Chat().ID, c.Sender().ID, cleared)
+
+	return replyHTML(c, cmd.BaseCommand, fmt.Sprintf("🕊 %s свободен, варны сброшены", MentionUser(user)))
+}
+
+// WarningsCommand handles .варны command
+type WarningsCommand struct {
+	*BaseCommand
+	moderator
+}
+
+// NewWarningsCommand creates a new warnings command
+func NewWarningsCommand(warningManager *models.WarningManager, statsManager *models.StatsManager, settingsManager *models.SettingsManager) *WarningsCommand {
+	return &WarningsCommand{
+		BaseCommand: NewBaseCommand(".варны", false).
+			WithAliases("warns").
+			WithDescription("Варны юзера, «сброс» снимает их").
+			WithRole(RoleChatAdmin).
+			WithArgs(ArgSpec{Name: "юзер и сброс", Type: ArgText}),
+		moderator: moderator{warningManager, statsManager, settingsManager},
+	}
+}
+
+// Execute lists or clears active warnings of a user
+func (cmd *WarningsCommand) Execute(c telebot.Context, metrics *models.Metrics) error {
+	metrics.RecordCommand()
+	if !requireGroup(c, cmd.BaseCommand) {
+		return nil
+	}
+
+	user, rest, err := cmd.resolveTarget(c)
+	if err != nil {
+		return replyHTML(c, cmd.BaseCommand, "❌ "+html.EscapeString(err.Error()))
+	}
+
+	chatID := c.Chat().ID
+	if len(rest) > 0 && strings.ToLower(rest[0]) == "сброс" {
+		cleared, err := cmd.warningManager.Clear(chatID, user.ID)
+		if err != nil {
+			return replyHTML(c, cmd.BaseCommand, "❌ Не удалось сбросить варны: "+html.EscapeString(err.Error()))
+		}
+		fmt.Printf("[+] %d warnings of user %d cleared in chat %d by %d\n", cleared, user.ID, chatID, c.Sender().ID)
+		return replyHTML(c, cmd.BaseCommand, fmt.Sprintf("🧽 Варны %s сброшены (%d)", MentionUser(user), cleared))
+	}
+
+	settings := cmd.settingsManager.Get(chatID)
+	warnings := cmd.warningManager.Active(chatID, user.ID)
+	if len(warnings) == 0 {
+		return replyHTML(c, cmd.BaseCommand, fmt.Sprintf("😇 У %s нет варнов", MentionUser(user)))
+	}
+
+	location := settings.Location()
+	var text strings.Builder
+	fmt.Fprintf(&text, "❗ Варны %s: <b>%d/%d</b>\n", MentionUser(user), len(warnings), settings.WarnLimit)
+	for i, warning := range warnings {
+		reason := warning.Reason
+		if reason == "" {
+			reason = "без причины"
+		}
+		fmt.Fprintf(&text, "\n%d. %s — %s <i>(до %s)</i>", i+1,
+			time.Unix(warning.CreatedAt, 0).In(location).Format("02.01 15:04"),
+			html.EscapeString(reason),
+			time.Unix(warning.ExpiresAt, 0).In(location).Format("02.01.2006"))
+	}
+
+	return replyHTML(c, cmd.BaseCommand, text.String())
+}
@@ -0,0 +1,200 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/telebot.v3"
+	"gobrev/src/models"
+)
+
+// PrivacyCommand handles .приватность command
+type PrivacyCommand struct {
+	*BaseCommand
+	privacyManager   *models.PrivacyManager
+	statsManager     *models.StatsManager
+	reviewManager    *models.ReviewManager
+	messageIDManager *models.MessageIDManager
+	historyManager   *models.UserHistoryManager
+	quoteManager     *models.QuoteManager
+	karmaManager     *models.KarmaManager
+	quizManager      *models.QuizManager
+	dailyManager     *models.DailyWinnerManager
+}
+
+// NewPrivacyCommand creates a new privacy command
+func NewPrivacyCommand(privacyManager *models.PrivacyManager, statsManager *models.StatsManager, reviewManager *models.ReviewManager, messageIDManager *models.MessageIDManager, historyManager *models.UserHistoryManager, quoteManager *models.QuoteManager, karmaManager *models.KarmaManager, quizManager *models.QuizManager, dailyManager *models.DailyWinnerManager) *PrivacyCommand {
+	return &PrivacyCommand{
+		BaseCommand: NewBaseCommand(".приватность", false).
+			WithAliases("privacy").
+			WithDescription("Не сохранять мои сообщения или удалить мои данные").
+			WithArgs(
+				ArgSpec{Name: "действие", Type: ArgChoice, Choices: []string{"выкл", "вкл", "удалить"}},
+				ArgSpec{Name: "где", Type: ArgChoice, Choices: []string{"везде"}},
+			),
+		privacyManager:   privacyManager,
+		statsManager:     statsManager,
+		reviewManager:    reviewManager,
+		messageIDManager: messageIDManager,
+		historyManager:   historyManager,
+		quoteManager:     quoteManager,
+		karmaManager:     karmaManager,
+		quizManager:      quizManager,
+		dailyManager:     dailyManager,
+	}
+}
+
+// Execute executes the privacy command
+func (cmd *PrivacyCommand) Execute(c telebot.Context, metrics *models.Metrics) error {
+	metrics.RecordCommand()
+
+	userID := c.Sender().ID
+	chatID := c.Chat().ID
+	isPrivate := c.Chat().Type == telebot.ChatPrivate
+
+	args := GetArgs(c)
+	if !args.Has("действие") {
+		return cmd.sendStatus(c, chatID, userID, isPrivate)
+	}
+
+	// "везде" applies the setting to all chats; in private chats it's implied
+	global := isPrivate || args.String("где") == "везде"
+
+	switch args.String("действие") {
+	case "выкл":
+		if err := cmd.setOptOut(chatID, userID, global, true); err != nil {
+			return cmd.SafeSend(c, "❌ Ошибка сохранения настроек: "+err.Error())
+		}
+		if global {
+			return cmd.SafeSend(c, "🧣 Готово. Твои сообщения больше не сохраняются и не учитываются в статистике ни в одном чате.", &telebot.SendOptions{ReplyTo: c.Message()})
+		}
+		return cmd.SafeSend(c, "🧣 Готово. Твои сообщения в этом чате больше не сохраняются и не учитываются в статистике.", &telebot.SendOptions{ReplyTo: c.Message()})
+
+	case "вкл":
+		if err := cmd.setOptOut(chatID, userID, global, false); err != nil {
+			return cmd.SafeSend(c, "❌ Ошибка сохранения настроек: "+err.Error())
+		}
+		if global {
+			return cmd.SafeSend(c, "🎓 Сохранение сообщений и статистика снова включены во всех чатах.", &telebot.SendOptions{ReplyTo: c.Message()})
+		}
+		return cmd.SafeSend(c, "🎓 Сохранение сообщений и статистика снова включены в этом чате.", &telebot.SendOptions{ReplyTo: c.Message()})
+
+	case "удалить":
+		return cmd.deleteUserData(c, userID)
+	}
+
+	return cmd.sendUsage(c)
+}
+
+// setOptOut updates chat-level or global opt-out flag
+func (cmd *PrivacyCommand) setOptOut(chatID, userID int64, global, optOut bool) error {
+	if global {
+		return cmd.privacyManager.SetGlobalOptOut(userID, optOut)
+	}
+	return cmd.privacyManager.SetChatOptOut(chatID, userID, optOut)
+}
+
+// deleteUserData removes everything the bot stored about the user
+func (cmd *PrivacyCommand) deleteUserData(c telebot.Context, userID int64) error {
+	statsDeleted, err := cmd.statsManager.DeleteUserStats(userID)
+	if err != nil {
+		fmt.Printf("[-] Failed to delete stats for user %d: %v\n", userID, err)
+		return cmd.SafeSend(c, "❌ Ошибка удаления статистики: "+err.Error())
+	}
+
+	reviewDeleted, err := cmd.reviewManager.DeleteUserMessages(userID)
+	if err != nil {
+		fmt.Printf("[-] Failed to delete review messages for user %d: %v\n", userID, err)
+		return cmd.SafeSend(c, "❌ Ошибка удаления сообщений: "+err.Error())
+	}
+
+	aiDeleted, err := cmd.messageIDManager.DeleteUserMessages(userID)
+	if err != nil {
+		fmt.Printf("[-] Failed to delete AI message records for user %d: %v\n", userID, err)
+		return cmd.SafeSend(c, "❌ Ошибка удаления ответов ИИ: "+err.Error())
+	}
+
+	quotesDeleted, err := cmd.quoteManager.DeleteUserQuotes(userID)
+	if err != nil {
+		fmt.Printf("[-] Failed to delete quotes for user %d: %v\n", userID, err)
+		return cmd.SafeSend(c, "❌ Ошибка удаления цитат: "+err.Error())
+	}
+
+	karmaDeleted, err := cmd.karmaManager.DeleteUserKarma(userID)
+	if err != nil {
+		fmt.Printf("[-] Failed to delete karma for user %d: %v\n", userID, err)
+		return cmd.SafeSend(c, "❌ Ошибка удаления кармы: "+err.Error())
+	}
+
+	quizDeleted, err := cmd.quizManager.DeleteUserStats(userID)
+	if err != nil {
+		fmt.Printf("[-] Failed to delete quiz stats for user %d: %v\n", userID, err)
+		return cmd.SafeSend(c, "❌ Ошибка удаления викторины: "+err.Error())
+	}
+
+	winsDeleted, err := cmd.dailyManager.DeleteUserWins(userID)
+	if err != nil {
+		fmt.Printf("[-] Failed to delete daily wins for user %d: %v\n", userID, err)
+		return cmd.SafeSend(c, "❌ Ошибка удаления побед дня: "+err.Error())
+	}
+
+	cmd.historyManager.DeleteUserHistory(userID)
+
+	fmt.Printf("[+] Deleted data for user %d: %d stats keys, %d review messages, %d AI records, %d quotes, %d karma records, %d quiz records, %d daily wins\n",
+		userID, statsDeleted, reviewDeleted, aiDeleted, quotesDeleted, karmaDeleted, quizDeleted, winsDeleted)
+
+	return cmd.SafeSend(c, fmt.Sprintf(`🧺 <b>Твои данные удалены</b>
+
+• Статистика: <b>%d</b>
+• Сообщения для ревью: <b>%d</b>
+• Ответы ИИ: <b>%d</b>
+• Цитаты: <b>%d</b>
+• Карма: <b>%d</b>
+• Викторина: <b>%d</b>
+• Победы «пользователь дня»: <b>%d</b>
+• История диалога с ИИ очищена
+
+<i>Чтобы новые сообщения не сохранялись, используй</i> <code>.приватность выкл везде</code>`,
+		statsDeleted, reviewDeleted, aiDeleted, quotesDeleted, karmaDeleted, quizDeleted, winsDeleted), &telebot.SendOptions{
+		ParseMode: telebot.ModeHTML,
+		ReplyTo:   c.Message(),
+	})
+}
+
+// sendStatus shows current privacy settings for the user
+func (cmd *PrivacyCommand) sendStatus(c telebot.Context, chatID, userID int64, isPrivate bool) error {
+	chatOptOut, globalOptOut := cmd.privacyManager.GetStatus(chatID, userID)
+
+	status := func(optOut bool) string {
+		if optOut {
+			return "🔕 не сохраняются"
+		}
+		return "🔔 сохраняются"
+	}
+
+	var message strings.Builder
+	message.WriteString("🧣 <b>Приватность</b>\n\n")
+	if !isPrivate {
+		message.WriteString(fmt.Sprintf("В этом чате: %s\n", status(chatOptOut)))
+	}
+	message.WriteString(fmt.Sprintf("Во всех чатах: %s\n\n", status(globalOptOut)))
+	message.WriteString(privacyUsage)
+
+	return cmd.SafeSend(c, message.String(), &telebot.SendOptions{
+		ParseMode: telebot.ModeHTML,
+		ReplyTo:   c.Message(),
+	})
+}
+
+// sendUsage sends command usage
+func (cmd *PrivacyCommand) sendUsage(c telebot.Context) error {
+	return cmd.SafeSend(c, "🧣 <b>Приватность</b>\n\n"+privacyUsage, &telebot.SendOptions{
+		ParseMode: telebot.ModeHTML,
+		ReplyTo:   c.Message(),
+	})
+}
+
+const privacyUsage = `<code>.приватность выкл</code> — не сохранять мои сообщения в этом чате
+<code>.приватность выкл везде</code> — не сохранять нигде
+<code>.приватность вкл</code> / <code>вкл везде</code> — снова сохранять
+<code>.приватность удалить</code> — удалить все мои данные`
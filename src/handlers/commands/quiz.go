@@ -0,0 +1,562 @@
+package commands
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/telebot.v3"
+	"gobrev/src/models"
+	"gobrev/src/utils"
+)
+
+const (
+	// quizDefaultRounds and quizMaxRounds limit questions per game
+	quizDefaultRounds = 5
+	quizMaxRounds     = 10
+	// quizRoundTime is how long each question stays open
+	quizRoundTime = 30 * time.Second
+	// quizRoundPause separates rounds so the closed poll can be read
+	quizRoundPause = 3 * time.Second
+	// quizMaxTopic limits the topic length
+	quizMaxTopic = 100
+	// quizDefaultTopic is used when no topic is given
+	quizDefaultTopic = "общие знания"
+)
+
+// Telegram poll limits
+const (
+	pollMaxQuestion    = 300
+	pollMaxOption      = 100
+	pollMaxExplanation = 200
+	pollMinOptions     = 2
+	pollMaxOptions     = 10
+)
+
+// quizSystemPrompt keeps the model to the answer format
+const quizSystemPrompt = "Ты составитель викторин. Отвечай только JSON без markdown и пояснений."
+
+// quizPrompt asks for questions in a strict format
+const quizPrompt = `Составь %d вопросов для викторины на тему «%s» на русском языке.
+Вопросы разной сложности, с однозначным фактическим ответом, без повторов.
+У каждого вопроса 4 варианта ответа, ровно один верный.
+Ответь строго JSON-массивом:
+[{"question": "текст вопроса", "options": ["вариант 1", "вариант 2", "вариант 3", "вариант 4"], "answer": 0, "explanation": "короткий факт"}]
+answer — номер верного варианта начиная с 0. Вопрос не длиннее 250 символов, вариант не длиннее 100, explanation не длиннее 150.`
+
+// QuizQuestion is a generated quiz question
+type QuizQuestion struct {
+	Question    string   `json:"question"`
+	Options     []string `json:"options"`
+	Answer      int      `json:"answer"`
+	Explanation string   `json:"explanation,omitempty"`
+}
+
+// Validate checks the question against Telegram quiz poll limits
+func (q QuizQuestion) Validate(prefixLen int) error {
+	if q.Question == "" || len([]rune(q.Question))+prefixLen > pollMaxQuestion {
+		return errors.New("bad question length")
+	}
+	if len(q.Options) < pollMinOptions || len(q.Options) > pollMaxOptions {
+		return errors.New("bad option count")
+	}
+
+	seen := make(map[string]bool)
+	for _, option := range q.Options {
+		key := strings.ToLower(option)
+		if option == "" || len([]rune(option)) > pollMaxOption || seen[key] {
+			return errors.New("bad option")
+		}
+		seen[key] = true
+	}
+
+	if q.Answer < 0 || q.Answer >= len(q.Options) {
+		return errors.New("answer out of range")
+	}
+	return nil
+}
+
+// parseQuizQuestions extracts valid questions from a model answer
+func parseQuizQuestions(answer string) ([]QuizQuestion, error) {
+	data := []byte(utils.ExtractJSON(answer))
+
+	var questions []QuizQuestion
+	if err := json.Unmarshal(data, &questions); err != nil {
+		// Some models wrap the array into an object
+		var wrapped struct {
+			Questions []QuizQuestion `json:"questions"`
+		}
+		if err := json.Unmarshal(data, &wrapped); err != nil {
+			return nil, fmt.Errorf("invalid quiz JSON: %w", err)
+		}
+		questions = wrapped.Questions
+	}
+
+	var valid []QuizQuestion
+	for _, question := range questions {
+		question.Question = strings.TrimSpace(question.Question)
+		for i := range question.Options {
+			question.Options[i] = strings.TrimSpace(question.Options[i])
+		}
+		question.Explanation = strings.TrimSpace(question.Explanation)
+		if len([]rune(question.Explanation)) > pollMaxExplanation {
+			question.Explanation = ""
+		}
+
+		// Room for the "10/10. " round prefix
+		if err := question.Validate(7); err != nil {
+			fmt.Printf("[-] Skipping quiz question %q: %v\n", question.Question, err)
+			continue
+		}
+		valid = append(valid, question)
+	}
+
+	if len(valid) == 0 {
+		return nil, errors.New("no valid questions")
+	}
+	return valid, nil
+}
+
+// shuffle mixes options so the answer isn't always where the model put it
+func (q QuizQuestion) shuffle() QuizQuestion {
+	correct := q.Options[q.Answer]
+	options := append([]string(nil), q.Options...)
+	rand.Shuffle(len(options), func(i, j int) { options[i], options[j] = options[j], options[i] })
+
+	for i, option := range options {
+		if option == correct {
+			q.Answer = i
+		}
+	}
+	q.Options = options
+	return q
+}
+
+// quizScore is the score of a player in one game
+type quizScore struct {
+	userID  int64
+	name    string
+	correct int
+}
+
+// quizSession is a game running in a chat
+type quizSession struct {
+	chat      *telebot.Chat
+	topic     string
+	starterID int64
+	rounds    int
+	scores    map[int64]*quizScore
+	stop      chan struct{}
+	stopped   bool
+}
+
+// quizRound is an open question waiting for answers
+type quizRound struct {
+	session  *quizSession
+	answer   int
+	answered map[int64]bool
+}
+
+// QuizGame runs quiz games, one per chat, and scores poll answers
+type QuizGame struct {
+	aiClient       *utils.AIClient
+	quizManager    *models.QuizManager
+	privacyManager *models.PrivacyManager
+
+	mu       sync.Mutex
+	sessions map[int64]*quizSession
+	rounds   map[string]*quizRound // By poll ID
+}
+
+// NewQuizGame creates a new quiz game, it needs AI to write questions
+func NewQuizGame(quizManager *models.QuizManager, privacyManager *models.PrivacyManager) (*QuizGame, error) {
+	aiClient, err := utils.NewAIClient()
+	if err != nil {
+		return nil, err
+	}
+
+	return &QuizGame{
+		aiClient:       aiClient,
+		quizManager:    quizManager,
+		privacyManager: privacyManager,
+		sessions:       make(map[int64]*quizSession),
+		rounds:         make(map[string]*quizRound),
+	}, nil
+}
+
+// Start generates questions and runs a game in the background
+func (g *QuizGame) Start(bot *telebot.Bot, chat *telebot.Chat, starterID int64, topic string, rounds int) error {
+	session := &quizSession{
+		chat:      chat,
+		topic:     topic,
+		starterID: starterID,
+		scores:    make(map[int64]*quizScore),
+		stop:      make(chan struct{}),
+	}
+
+	// The session is taken before generating so two games can't start at once
+	g.mu.Lock()
+	if _, ok := g.sessions[chat.ID]; ok {
+		g.mu.Unlock()
+		return errors.New("в этом чате уже идёт викторина")
+	}
+	g.sessions[chat.ID] = session
+	g.mu.Unlock()
+
+	questions, err := g.generate(topic, rounds)
+	if err != nil {
+		fmt.Printf("[-] Failed to generate quiz in chat %d: %v\n", chat.ID, err)
+		g.finish(session)
+		return errors.New("не получилось придумать вопросы, попробуй ещё раз или другую тему")
+	}
+	if len(questions) > rounds {
+		questions = questions[:rounds]
+	}
+	session.rounds = len(questions)
+
+	fmt.Printf("[+] Quiz started in chat %d: %d questions about %q\n", chat.ID, len(questions), topic)
+	go g.run(bot, session, questions)
+	return nil
+}
+
+// Stop ends the game in a chat early, false if there is none
+func (g *QuizGame) Stop(chatID int64) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	session, ok := g.sessions[chatID]
+	if !ok || session.stopped {
+		return false
+	}
+	session.stopped = true
+	close(session.stop)
+	return true
+}
+
+// Starter returns who started the game in a chat, 0 if there is none
+func (g *QuizGame) Starter(chatID int64) int64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if session, ok := g.sessions[chatID]; ok {
+		return session.starterID
+	}
+	return 0
+}
+
+// HandleAnswer scores an answer to a quiz poll of a running game
+func (g *QuizGame) HandleAnswer(answer *telebot.PollAnswer) {
+	if answer == nil || answer.Sender == nil || len(answer.Options) == 0 {
+		return
+	}
+	user := answer.Sender
+	name := strings.TrimSpace(user.FirstName + " " + user.LastName)
+
+	g.mu.Lock()
+	round, ok := g.rounds[answer.PollID]
+	if !ok || round.answered[user.ID] {
+		g.mu.Unlock()
+		return
+	}
+	round.answered[user.ID] = true
+
+	correct := answer.Options[0] == round.answer
+	score, ok := round.session.scores[user.ID]
+	if !ok {
+		score = &quizScore{userID: user.ID}
+		round.session.scores[user.ID] = score
+	}
+	score.name = name
+	if correct {
+		score.correct++
+	}
+	chatID := round.session.chat.ID
+	g.mu.Unlock()
+
+	// Opted-out members still play, but the leaderboard doesn't keep them
+	if g.privacyManager.IsOptedOut(chatID, user.ID) {
+		return
+	}
+	if err := g.quizManager.RecordAnswer(chatID, user.ID, name, correct); err != nil {
+		fmt.Printf("[-] Failed to record quiz answer in chat %d: %v\n", chatID, err)
+	}
+}
+
+// generate asks the AI for questions on a topic
+func (g *QuizGame) generate(topic string, rounds int) ([]QuizQuestion, error) {
+	answer, err := g.aiClient.QuickChat(fmt.Sprintf(quizPrompt, rounds, topic),
+		utils.WithSystemMessage(quizSystemPrompt),
+		utils.WithTemperature(0.9),
+		utils.WithMaxTokens(300*rounds),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return parseQuizQuestions(answer)
+}
+
+// run posts questions one by one and announces the results
+func (g *QuizGame) run(bot *telebot.Bot, session *quizSession, questions []QuizQuestion) {
+	defer g.finish(session)
+
+	stopped := false
+	for i := 0; i < len(questions) && !stopped; i++ {
+		// The game may be stopped while questions were generated or during the pause
+		select {
+		case <-session.stop:
+			stopped = true
+			continue
+		default:
+		}
+
+		msg, err := g.ask(bot, session, questions[i].shuffle(), i+1, len(questions))
+		if err != nil {
+			fmt.Printf("[-] Failed to send quiz question in chat %d: %v\n", session.chat.ID, err)
+			bot.Send(session.chat, "❌ Не удалось отправить вопрос, викторина остановлена")
+			return
+		}
+
+		wait := quizRoundTime + quizRoundPause
+		if i == len(questions)-1 {
+			wait = quizRoundTime
+		}
+
+		select {
+		case <-time.After(wait):
+		case <-session.stop:
+			stopped = true
+			bot.StopPoll(msg)
+		}
+		g.closeRound(msg.Poll.ID)
+	}
+
+	if stopped {
+		bot.Send(session.chat, g.results(session, "⏹ <b>Викторина остановлена</b>"), telebot.ModeHTML)
+		return
+	}
+	bot.Send(session.chat, g.results(session, "🏁 <b>Викторина окончена!</b>"), telebot.ModeHTML)
+	g.recordWinners(session)
+}
+
+// ask posts a question as a quiz poll and opens its round
+func (g *QuizGame) ask(bot *telebot.Bot, session *quizSession, question QuizQuestion, number, total int) (*telebot.Message, error) {
+	poll := &telebot.Poll{
+		Type:          telebot.PollQuiz,
+		Question:      fmt.Sprintf("%d/%d. %s", number, total, question.Question),
+		CorrectOption: question.Answer,
+		Explanation:   question.Explanation,
+		OpenPeriod:    int(quizRoundTime.Seconds()),
+	}
+	for _, option := range question.Options {
+		poll.Options = append(poll.Options, telebot.PollOption{Text: option})
+	}
+
+	msg, err := bot.Send(session.chat, poll)
+	if err != nil {
+		return nil, err
+	}
+	if msg.Poll == nil {
+		return nil, errors.New("sent message has no poll")
+	}
+
+	g.mu.Lock()
+	g.rounds[msg.Poll.ID] = &quizRound{session: session, answer: question.Answer, answered: make(map[int64]bool)}
+	g.mu.Unlock()
+
+	return msg, nil
+}
+
+// closeRound stops accepting answers of a round
+func (g *QuizGame) closeRound(pollID string) {
+	g.mu.Lock()
+	delete(g.rounds, pollID)
+	g.mu.Unlock()
+}
+
+// finish removes the session of a chat
+func (g *QuizGame) finish(session *quizSession) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.sessions[session.chat.ID] == session {
+		delete(g.sessions, session.chat.ID)
+	}
+}
+
+// ranking returns players of a game, best first
+func (g *QuizGame) ranking(session *quizSession) []quizScore {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	var scores []quizScore
+	for _, score := range session.scores {
+		scores = append(scores, *score)
+	}
+	sort.Slice(scores, func(i, j int) bool {
+		return scores[i].correct > scores[j].correct
+	})
+	return scores
+}
+
+// results renders the scoreboard of a game
+func (g *QuizGame) results(session *quizSession, title string) string {
+	scores := g.ranking(session)
+
+	var sb strings.Builder
+	sb.WriteString(title)
+	sb.WriteString("\nТема: " + html.EscapeString(session.topic) + "\n")
+
+	if len(scores) == 0 {
+		sb.WriteString("\nНикто не ответил 🦗")
+		return sb.String()
+	}
+
+	for i, score := range scores {
+		user := &telebot.User{ID: score.userID, FirstName: score.name}
+		sb.WriteString(fmt.Sprintf("\n%s %s — <b>%d</b>/%d", rankMedal(i), MentionUser(user), score.correct, session.rounds))
+	}
+	return sb.String()
+}
+
+// recordWinners counts a win for everyone who shares the best non-zero score
+func (g *QuizGame) recordWinners(session *quizSession) {
+	scores := g.ranking(session)
+	if len(scores) == 0 || scores[0].correct == 0 {
+		return
+	}
+
+	for _, score := range scores {
+		if score.correct < scores[0].correct {
+			break
+		}
+		if g.privacyManager.IsOptedOut(session.chat.ID, score.userID) {
+			continue
+		}
+		if err := g.quizManager.AddWin(session.chat.ID, score.userID, score.name); err != nil {
+			fmt.Printf("[-] Failed to record quiz win in chat %d: %v\n", session.chat.ID, err)
+		}
+	}
+}
+
+// rankMedal returns a medal for the first three places and a number for the rest
+func rankMedal(i int) string {
+	medals := []string{"🥇", "🥈", "🥉"}
+	if i < len(medals) {
+		return medals[i]
+	}
+	return strconv.Itoa(i+1) + "."
+}
+
+// QuizCommand handles .викторина command
+type QuizCommand struct {
+	*BaseCommand
+	game            *QuizGame
+	quizManager     *models.QuizManager
+	settingsManager *models.SettingsManager
+	adminManager    *utils.AdminManager
+}
+
+// NewQuizCommand creates a new quiz command
+func NewQuizCommand(game *QuizGame, quizManager *models.QuizManager, settingsManager *models.SettingsManager) *QuizCommand {
+	return &QuizCommand{
+		BaseCommand: NewBaseCommand(".викторина", false).
+			WithAliases("quiz").
+			WithDescription("Викторина от ИИ: .викторина [раундов] [тема], топ, стоп").
+			WithArgs(ArgSpec{Name: "тема", Type: ArgText}),
+		game:            game,
+		quizManager:     quizManager,
+		settingsManager: settingsManager,
+		adminManager:    utils.NewAdminManager(),
+	}
+}
+
+// Execute starts or stops a game or shows the leaderboard
+func (cmd *QuizCommand) Execute(c telebot.Context, metrics *models.Metrics) error {
+	metrics.RecordCommand()
+
+	if !requireGroup(c, cmd.BaseCommand) {
+		return nil
+	}
+
+	words := GetArgs(c).Raw()
+	if len(words) > 0 {
+		switch strings.ToLower(words[0]) {
+		case "топ", "стат":
+			return cmd.leaderboard(c)
+		case "стоп":
+			return cmd.stop(c)
+		}
+	}
+
+	rounds := quizDefaultRounds
+	if len(words) > 0 {
+		if n, err := strconv.Atoi(words[0]); err == nil {
+			if n < 1 || n > quizMaxRounds {
+				return replyHTML(c, cmd.BaseCommand, fmt.Sprintf("❌ Раундов может быть от 1 до %d", quizMaxRounds))
+			}
+			rounds, words = n, words[1:]
+		}
+	}
+
+	topic := strings.Join(words, " ")
+	if topic == "" {
+		topic = quizDefaultTopic
+	}
+	if len([]rune(topic)) > quizMaxTopic {
+		return replyHTML(c, cmd.BaseCommand, fmt.Sprintf("❌ Тема не длиннее %d символов", quizMaxTopic))
+	}
+
+	if cmd.game.Starter(c.Chat().ID) != 0 {
+		return replyHTML(c, cmd.BaseCommand, "❌ В этом чате уже идёт викторина. Остановить: <code>.викторина стоп</code>")
+	}
+
+	replyHTML(c, cmd.BaseCommand, fmt.Sprintf("🧠 Готовлю %d вопросов на тему «%s»… На каждый — %d секунд.",
+		rounds, html.EscapeString(topic), int(quizRoundTime.Seconds())))
+
+	if err := cmd.game.Start(c.Bot(), c.Chat(), c.Sender().ID, topic, rounds); err != nil {
+		return replyHTML(c, cmd.BaseCommand, "❌ "+html.EscapeString(err.Error()))
+	}
+	return nil
+}
+
+// stop ends the game, allowed to whoever started it and chat admins
+func (cmd *QuizCommand) stop(c telebot.Context) error {
+	starter := cmd.game.Starter(c.Chat().ID)
+	if starter == 0 {
+		return replyHTML(c, cmd.BaseCommand, "🤷 Викторина сейчас не идёт")
+	}
+	if starter != c.Sender().ID && !cmd.adminManager.IsChatAdmin(c) {
+		return replyHTML(c, cmd.BaseCommand, "❌ Остановить викторину может тот, кто её начал, или админ")
+	}
+
+	cmd.game.Stop(c.Chat().ID)
+	return nil
+}
+
+// leaderboard shows the best players of the chat
+func (cmd *QuizCommand) leaderboard(c telebot.Context) error {
+	chatID := c.Chat().ID
+
+	top, err := cmd.quizManager.Top(chatID, cmd.settingsManager.Get(chatID).StatsTopUsers)
+	if err != nil {
+		return replyHTML(c, cmd.BaseCommand, "❌ Ошибка получения рейтинга: "+html.EscapeString(err.Error()))
+	}
+	if len(top) == 0 {
+		return replyHTML(c, cmd.BaseCommand, "🧠 Рейтинг пуст. Начни игру: <code>.викторина космос</code>")
+	}
+
+	var sb strings.Builder
+	sb.WriteString("🧠 <b>Рейтинг викторины</b>\n")
+	for i, stats := range top {
+		sb.WriteString(fmt.Sprintf("\n%s %s — <b>%d</b> верных из %d, побед: %d",
+			rankMedal(i), html.EscapeString(stats.Username), stats.Correct, stats.Answered, stats.Wins))
+	}
+	return replyHTML(c, cmd.BaseCommand, sb.String())
+}
@@ -0,0 +1,70 @@
+package commands
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestQuizQuestionValidate(t *testing.T) {
+	valid := func() QuizQuestion {
+		return QuizQuestion{Question: "Столица Франции?", Options: []string{"Париж", "Лион", "Ницца"}, Answer: 0}
+	}
+
+	tests := []struct {
+		name   string
+		modify func(q *QuizQuestion)
+		ok     bool
+	}{
+		{"valid", func(q *QuizQuestion) {}, true},
+		{"empty question", func(q *QuizQuestion) { q.Question = "" }, false},
+		{"question too long with prefix", func(q *QuizQuestion) { q.Question = strings.Repeat("я", pollMaxQuestion-6) }, false},
+		{"question fits with prefix", func(q *QuizQuestion) { q.Question = strings.Repeat("я", pollMaxQuestion-7) }, true},
+		{"one option", func(q *QuizQuestion) { q.Options = q.Options[:1] }, false},
+		{"too many options", func(q *QuizQuestion) { q.Options = strings.Fields("1 2 3 4 5 6 7 8 9 10 11") }, false},
+		{"empty option", func(q *QuizQuestion) { q.Options[1] = "" }, false},
+		{"option too long", func(q *QuizQuestion) { q.Options[1] = strings.Repeat("я", pollMaxOption+1) }, false},
+		{"duplicate options", func(q *QuizQuestion) { q.Options[2] = "париж" }, false},
+		{"negative answer", func(q *QuizQuestion) { q.Answer = -1 }, false},
+		{"answer out of range", func(q *QuizQuestion) { q.Answer = 3 }, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			question := valid()
+			tt.modify(&question)
+			if err := question.Validate(7); (err == nil) != tt.ok {
+				t.Errorf("Validate() = %v, want ok %v", err, tt.ok)
+			}
+		})
+	}
+}
+
+func TestParseQuizQuestions(t *testing.T) {
+	const good = `{"question": " Столица Франции? ", "options": ["Париж ", "Лион", "Ницца", "Марсель"], "answer": 0, "explanation": "Факт"}`
+	const bad = `{"question": "Без вариантов?", "options": ["Да"], "answer": 0}`
+
+	tests := []struct {
+		name    string
+		answer  string
+		want    int
+		wantErr bool
+	}{
+		{"array", "[" + good + "," + good + "]", 2, false},
+		{"wrapped", `{"questions": [` + good + `]}`, 1, false},
+		{"markdown fence", "Вот вопросы:\n```json\n[" + good + "]\n```", 1, false},
+		{"invalid dropped", "[" + bad + "," + good + "]", 1, false},
+		{"no valid questions", "[" + bad + "]", 0, true},
+		{"empty array", "[]", 0, true},
+		{"not JSON", "Не могу составить викторину", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			questions, err := parseQuizQuestions(tt.answer)
+			if (err != nil) != tt.wantErr || len(questions) != tt.want {
+				t.Fatalf("parseQuizQuestions() = %d questions, %v, want %d (error %v)", len(questions), err, tt.want, tt.wantErr)
+			}
+			if len(questions) > 0 && (questions[0].Question != "Столица Франции?" || questions[0].Options[0] != "Париж") {
+				t.Errorf("question not trimmed: %+v", questions[0])
+			}
+		})
+	}
+}
@@ -0,0 +1,189 @@
+package commands
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"strings"
+	"time"
+
+	"gopkg.in/telebot.v3"
+	"gobrev/src/models"
+	"gobrev/src/utils"
+)
+
+// maxQuoteMessages limits how many consecutive messages one quote may take
+const maxQuoteMessages = 5
+
+// QuoteCommand handles .цитата command
+type QuoteCommand struct {
+	*BaseCommand
+	quoteManager    *models.QuoteManager
+	reviewManager   *models.ReviewManager
+	privacyManager  *models.PrivacyManager
+	settingsManager *models.SettingsManager
+}
+
+// NewQuoteCommand creates a new quote command
+func NewQuoteCommand(quoteManager *models.QuoteManager, reviewManager *models.ReviewManager, privacyManager *models.PrivacyManager, settingsManager *models.SettingsManager) *QuoteCommand {
+	return &QuoteCommand{
+		BaseCommand: NewBaseCommand(".цитата", false).
+			WithAliases("quote").
+			WithDescription("Ответом на сообщение: картинка-цитата в цитатник").
+			WithArgs(ArgSpec{Name: "сообщений", Type: ArgInt}),
+		quoteManager:    quoteManager,
+		reviewManager:   reviewManager,
+		privacyManager:  privacyManager,
+		settingsManager: settingsManager,
+	}
+}
+
+// Execute renders the replied message and the following ones as an image and saves them to the quote book
+func (cmd *QuoteCommand) Execute(c telebot.Context, metrics *models.Metrics) error {
+	metrics.RecordCommand()
+
+	if !requireGroup(c, cmd.BaseCommand) {
+		return nil
+	}
+
+	reply := c.Message().ReplyTo
+	if reply == nil {
+		return replyHTML(c, cmd.BaseCommand, fmt.Sprintf("↩️ Ответь командой на сообщение. <code>.цитата 3</code> — оно и следующие, до %d сообщений", maxQuoteMessages))
+	}
+
+	count := GetArgs(c).Int("сообщений", 1)
+	if count < 1 || count > maxQuoteMessages {
+		return replyHTML(c, cmd.BaseCommand, fmt.Sprintf("❌ Можно процитировать от 1 до %d сообщений", maxQuoteMessages))
+	}
+
+	first, ok := quoteLineOf(reply)
+	if !ok {
+		return replyHTML(c, cmd.BaseCommand, "❌ В этом сообщении нет текста")
+	}
+	chatID := c.Chat().ID
+	if cmd.privacyManager.IsOptedOut(chatID, first.UserID) {
+		return replyHTML(c, cmd.BaseCommand, "🔒 Автор сообщения запретил сохранять свои сообщения")
+	}
+
+	// Following messages come from the review store, which already skips opted-out members. A gap ends the quote.
+	lines := []models.QuoteLine{first}
+	if count > 1 {
+		following, err := cmd.reviewManager.GetMessagesFrom(chatID, reply.ID+1, count-1)
+		if err != nil {
+			fmt.Printf("[-] Failed to load messages for quote in chat %d: %v\n", chatID, err)
+		}
+		for _, message := range following {
+			lines = append(lines, models.QuoteLine{
+				UserID:    message.UserID,
+				Username:  message.Username,
+				Text:      message.Content,
+				Timestamp: message.Timestamp,
+			})
+		}
+	}
+
+	quote := models.Quote{
+		ChatID:    chatID,
+		MessageID: reply.ID,
+		Lines:     lines,
+		SavedBy:   c.Sender().ID,
+		CreatedAt: time.Now().Unix(),
+	}
+	if err := cmd.quoteManager.Save(quote); err != nil {
+		fmt.Printf("[-] Failed to save quote in chat %d: %v\n", chatID, err)
+	} else {
+		fmt.Printf("[+] Quote of %d messages saved in chat %d by user %d\n", len(lines), chatID, c.Sender().ID)
+	}
+
+	caption := fmt.Sprintf("📖 Сохранено в цитатник, цитат: <b>%d</b>", cmd.quoteManager.Count(chatID))
+	if len(lines) < count {
+		caption += fmt.Sprintf("\n<i>Нашлось только %d сообщ. подряд</i>", len(lines))
+	}
+	return sendQuote(c, cmd.BaseCommand, quote, cmd.settingsManager.Get(chatID).Location(), caption)
+}
+
+// QuotesCommand handles .цитаты command
+type QuotesCommand struct {
+	*BaseCommand
+	quoteManager    *models.QuoteManager
+	settingsManager *models.SettingsManager
+}
+
+// NewQuotesCommand creates a new quotes command
+func NewQuotesCommand(quoteManager *models.QuoteManager, settingsManager *models.SettingsManager) *QuotesCommand {
+	return &QuotesCommand{
+		BaseCommand: NewBaseCommand(".цитаты", false).
+			WithAliases("quotes").
+			WithDescription("Случайная цитата из цитатника чата"),
+		quoteManager:    quoteManager,
+		settingsManager: settingsManager,
+	}
+}
+
+// Execute shows a random quote of the chat
+func (cmd *QuotesCommand) Execute(c telebot.Context, metrics *models.Metrics) error {
+	metrics.RecordCommand()
+
+	if !requireGroup(c, cmd.BaseCommand) {
+		return nil
+	}
+	chatID := c.Chat().ID
+
+	quote, total, err := cmd.quoteManager.Random(chatID)
+	if err != nil {
+		return replyHTML(c, cmd.BaseCommand, "❌ Ошибка чтения цитатника: "+html.EscapeString(err.Error()))
+	}
+	if total == 0 {
+		return replyHTML(c, cmd.BaseCommand, "📖 Цитатник пуст. Ответь <code>.цитата</code> на сообщение, достойное вечности")
+	}
+
+	return sendQuote(c, cmd.BaseCommand, quote, cmd.settingsManager.Get(chatID).Location(), fmt.Sprintf("📖 Из цитатника, всего цитат: <b>%d</b>", total))
+}
+
+// quoteLineOf turns a Telegram message into a quote line, false if it has no text
+func quoteLineOf(msg *telebot.Message) (models.QuoteLine, bool) {
+	text := msg.Text
+	if text == "" {
+		text = msg.Caption
+	}
+	if strings.TrimSpace(text) == "" {
+		return models.QuoteLine{}, false
+	}
+
+	line := models.QuoteLine{Text: text, Timestamp: msg.Unixtime}
+	switch {
+	case msg.SenderChat != nil:
+		// Channel posts and anonymous admins
+		line.UserID, line.Username = msg.SenderChat.ID, msg.SenderChat.Title
+	case msg.Sender != nil:
+		line.UserID = msg.Sender.ID
+		line.Username = strings.TrimSpace(msg.Sender.FirstName + " " + msg.Sender.LastName)
+		if line.Username == "" {
+			line.Username = msg.Sender.Username
+		}
+	}
+	return line, true
+}
+
+// sendQuote renders a quote and sends it as a photo, falling back to text
+func sendQuote(c telebot.Context, cmd *BaseCommand, quote models.Quote, loc *time.Location, caption string) error {
+	imageBuffer, err := utils.GenerateQuoteImage(quote.Lines, c.Bot(), loc)
+	if err != nil {
+		fmt.Printf("[-] Failed to generate quote image: %v\n", err)
+
+		var sb strings.Builder
+		for _, line := range quote.Lines {
+			sb.WriteString(fmt.Sprintf("<b>%s</b>: %s\n", html.EscapeString(line.Username), html.EscapeString(clipText(line.Text, 600))))
+		}
+		return replyHTML(c, cmd, sb.String()+"\n"+caption)
+	}
+
+	photo := &telebot.Photo{
+		File:    telebot.FromReader(bytes.NewReader(imageBuffer)),
+		Caption: caption,
+	}
+	return cmd.safeSender.SafeSendPhoto(c, photo, &telebot.SendOptions{
+		ParseMode: telebot.ModeHTML,
+		ReplyTo:   c.Message(),
+	})
+}
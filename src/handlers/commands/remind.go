@@ -0,0 +1,258 @@
+package commands
+
+import (
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/telebot.v3"
+	"gobrev/src/models"
+)
+
+const (
+	// maxReminderText limits the reminder text
+	maxReminderText = 500
+	// maxReminderAhead limits how far ahead reminders may be set
+	maxReminderAhead = 366 * 24 * time.Hour
+	// reminderLateNote is the delay after which a delivered reminder mentions that it is late
+	reminderLateNote = 2 * time.Minute
+)
+
+// remindHelp explains .напомни forms
+const remindHelp = `⏰ <b>Напоминания</b>
+
+<code>.напомни через 2ч купить пиво</code>
+<code>.напомни завтра в 10:00 созвон</code>
+<code>.напомни в пт в 18:30 пятница!</code>
+<code>.напомни 31.12 в 23:59 шампанское</code>
+<code>.напомни 10.05 позвонить</code> — в 10:05, дата пишется со временем или с годом
+<code>.напомни каждый пн в 9:00 планёрка</code>
+<code>.напомни по будням в 8:00 зарядка</code>
+Ответом на сообщение — напомню о нём.
+
+<code>.напомни список</code> — мои напоминания
+<code>.напомни отмена 2</code> или <code>отмена все</code>`
+
+// RemindCommand handles .напомни command
+type RemindCommand struct {
+	*BaseCommand
+	reminderManager *models.ReminderManager
+	settingsManager *models.SettingsManager
+}
+
+// NewRemindCommand creates a new remind command
+func NewRemindCommand(reminderManager *models.ReminderManager, settingsManager *models.SettingsManager) *RemindCommand {
+	return &RemindCommand{
+		BaseCommand: NewBaseCommand(".напомни", false).
+			WithAliases("remind", ".напоминания").
+			WithDescription("Напоминания: .напомни через 2ч текст, список, отмена").
+			WithArgs(ArgSpec{Name: "когда и что", Type: ArgText}),
+		reminderManager: reminderManager,
+		settingsManager: settingsManager,
+	}
+}
+
+// Execute creates, lists or cancels reminders
+func (cmd *RemindCommand) Execute(c telebot.Context, metrics *models.Metrics) error {
+	metrics.RecordCommand()
+
+	words := GetArgs(c).Raw()
+	if len(words) == 0 {
+		return cmd.list(c, true)
+	}
+
+	switch strings.ToLower(words[0]) {
+	case "список", "list":
+		return cmd.list(c, false)
+	case "отмена", "отменить", "удалить":
+		return cmd.cancel(c, words[1:])
+	}
+
+	settings := cmd.settingsManager.Get(c.Chat().ID)
+	now := time.Now().In(settings.Location())
+
+	parsed, used, err := parseReminderTime(words, now)
+	if err != nil {
+		return replyHTML(c, cmd.BaseCommand, "❌ "+html.EscapeString(err.Error()))
+	}
+	if parsed.at.Sub(now) > maxReminderAhead {
+		return replyHTML(c, cmd.BaseCommand, "❌ Не дальше чем на год вперёд")
+	}
+
+	text := strings.Join(words[used:], " ")
+	if len([]rune(text)) > maxReminderText {
+		return replyHTML(c, cmd.BaseCommand, fmt.Sprintf("❌ Текст не длиннее %d символов", maxReminderText))
+	}
+
+	// A reply reminds about the replied message
+	messageID := c.Message().ID
+	if c.Message().ReplyTo != nil {
+		messageID = c.Message().ReplyTo.ID
+	} else if text == "" {
+		return replyHTML(c, cmd.BaseCommand, "❌ Что напомнить? Например: <code>.напомни через 2ч купить пиво</code>")
+	}
+
+	reminder := models.Reminder{
+		ChatID:    c.Chat().ID,
+		UserID:    c.Sender().ID,
+		UserName:  c.Sender().FirstName,
+		Text:      text,
+		MessageID: messageID,
+		At:        parsed.at.Unix(),
+		Repeat:    parsed.repeat,
+		Weekday:   int(parsed.weekday),
+		Hour:      parsed.hour,
+		Minute:    parsed.minute,
+		Timezone:  settings.Timezone,
+	}
+	if err := cmd.reminderManager.Add(&reminder); err != nil {
+		return replyHTML(c, cmd.BaseCommand, "❌ "+html.EscapeString(err.Error()))
+	}
+
+	fmt.Printf("[+] Reminder %s set in chat %d by user %d for %s\n", reminder.ID, reminder.ChatID, reminder.UserID, parsed.at.Format(time.RFC3339))
+
+	answer := "⏰ Напомню " + formatReminderTime(parsed.at, now)
+	if repeat := formatRepeat(reminder); repeat != "" {
+		answer += "\n🔁 " + repeat
+	}
+	if text != "" {
+		answer += "\n📝 " + html.EscapeString(text)
+	}
+	return replyHTML(c, cmd.BaseCommand, answer)
+}
+
+// list shows reminders of the sender
+func (cmd *RemindCommand) list(c telebot.Context, withHelp bool) error {
+	reminders := cmd.reminderManager.List(c.Chat().ID, c.Sender().ID)
+	now := time.Now().In(cmd.settingsManager.Get(c.Chat().ID).Location())
+
+	var sb strings.Builder
+	if len(reminders) == 0 {
+		sb.WriteString("📭 У тебя нет напоминаний в этом чате")
+	} else {
+		sb.WriteString("⏰ <b>Твои напоминания</b>\n")
+		for i, reminder := range reminders {
+			sb.WriteString(fmt.Sprintf("\n%d. %s", i+1, formatReminderTime(time.Unix(reminder.At, 0).In(now.Location()), now)))
+			if repeat := formatRepeat(reminder); repeat != "" {
+				sb.WriteString(" 🔁 " + repeat)
+			}
+			if reminder.Text != "" {
+				sb.WriteString(" — " + html.EscapeString(clipText(reminder.Text, 60)))
+			}
+		}
+	}
+
+	if withHelp {
+		sb.WriteString("\n\n" + remindHelp)
+	}
+	return replyHTML(c, cmd.BaseCommand, sb.String())
+}
+
+// cancel deletes reminders of the sender by list number, or all of them
+func (cmd *RemindCommand) cancel(c telebot.Context, words []string) error {
+	reminders := cmd.reminderManager.List(c.Chat().ID, c.Sender().ID)
+	if len(reminders) == 0 {
+		return replyHTML(c, cmd.BaseCommand, "📭 У тебя нет напоминаний в этом чате")
+	}
+	if len(words) == 0 {
+		return replyHTML(c, cmd.BaseCommand, "❌ Укажи номер из <code>.напомни список</code> или <code>все</code>")
+	}
+
+	var selected []models.Reminder
+	if strings.ToLower(words[0]) == "все" {
+		selected = reminders
+	} else {
+		for _, word := range words {
+			n, err := strconv.Atoi(strings.Trim(word, ","))
+			if err != nil || n < 1 || n > len(reminders) {
+				return replyHTML(c, cmd.BaseCommand, fmt.Sprintf("❌ Нет напоминания №%s", html.EscapeString(word)))
+			}
+			selected = append(selected, reminders[n-1])
+		}
+	}
+
+	for _, reminder := range selected {
+		if err := cmd.reminderManager.Delete(reminder.ChatID, reminder.ID); err != nil {
+			return replyHTML(c, cmd.BaseCommand, "❌ Ошибка удаления: "+html.EscapeString(err.Error()))
+		}
+	}
+
+	fmt.Printf("[+] %d reminders cancelled in chat %d by user %d\n", len(selected), c.Chat().ID, c.Sender().ID)
+	return replyHTML(c, cmd.BaseCommand, fmt.Sprintf("🗑 Отменено напоминаний: %d", len(selected)))
+}
+
+// FormatReminder renders a delivered reminder as HTML
+func FormatReminder(reminder models.Reminder, now time.Time) string {
+	user := &telebot.User{ID: reminder.UserID, FirstName: reminder.UserName}
+
+	text := "⏰ " + MentionUser(user) + ", напоминаю"
+	if reminder.Text != "" {
+		text += ": " + html.EscapeString(reminder.Text)
+	} else {
+		text += " об этом сообщении"
+	}
+
+	if late := now.Sub(time.Unix(reminder.At, 0)); late > reminderLateNote {
+		text += fmt.Sprintf("\n<i>С опозданием на %s: бот был недоступен</i>", formatMinutes(late))
+	}
+	if next := reminder.Next(now); !next.IsZero() {
+		text += "\n🔁 Следующее " + formatReminderTime(next, now.In(next.Location()))
+	}
+	return text
+}
+
+// formatReminderTime formats a reminder time relative to now
+func formatReminderTime(at, now time.Time) string {
+	clock := at.Format("15:04")
+	days := int(time.Date(at.Year(), at.Month(), at.Day(), 0, 0, 0, 0, at.Location()).Sub(
+		time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())).Hours() / 24)
+
+	switch days {
+	case 0:
+		return "сегодня в " + clock
+	case 1:
+		return "завтра в " + clock
+	case 2:
+		return "послезавтра в " + clock
+	}
+	if at.Year() == now.Year() {
+		return fmt.Sprintf("%s (%s) в %s", at.Format("02.01"), weekdayShortName(at.Weekday()), clock)
+	}
+	return fmt.Sprintf("%s (%s) в %s", at.Format("02.01.2006"), weekdayShortName(at.Weekday()), clock)
+}
+
+// formatRepeat describes the repeat mode of a reminder, empty for one-off reminders
+func formatRepeat(reminder models.Reminder) string {
+	clock := fmt.Sprintf("%02d:%02d", reminder.Hour, reminder.Minute)
+
+	switch reminder.Repeat {
+	case models.RepeatDaily:
+		return "каждый день в " + clock
+	case models.RepeatWeekdays:
+		return "по будням в " + clock
+	case models.RepeatWeekly:
+		return fmt.Sprintf("каждый %s в %s", weekdayShortName(time.Weekday(reminder.Weekday)), clock)
+	}
+	return ""
+}
+
+// weekdayShortName returns a short Russian weekday name
+func weekdayShortName(weekday time.Weekday) string {
+	for _, day := range weekdayWords {
+		if day.weekday == weekday {
+			return day.short
+		}
+	}
+	return ""
+}
+
+// clipText shortens text to limit runes for lists and previews
+func clipText(text string, limit int) string {
+	runes := []rune(text)
+	if len(runes) <= limit {
+		return text
+	}
+	return string(runes[:limit]) + "…"
+}
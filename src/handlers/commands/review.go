@@ -16,21 +16,26 @@ type ReviewCommand struct {
 	aiClient        *utils.AIClient
 	reviewManager   *models.ReviewManager
 	statsManager    *models.StatsManager
+	settingsManager *models.SettingsManager
 	messageSplitter *utils.MessageSplitter
 }
 
 // NewReviewCommand creates a new review command
-func NewReviewCommand(reviewManager *models.ReviewManager, statsManager *models.StatsManager) (*ReviewCommand, error) {
+func NewReviewCommand(reviewManager *models.ReviewManager, statsManager *models.StatsManager, settingsManager *models.SettingsManager) (*ReviewCommand, error) {
 	aiClient, err := utils.NewAIClient()
 	if err != nil {
 		return nil, err
 	}
 
 	return &ReviewCommand{
-		BaseCommand:     NewBaseCommand(".рев", false),
+		BaseCommand: NewBaseCommand(".рев", false).
+			WithAliases("review", ".ревью").
+			WithDescription("Дейли новости чата от ИИ").
+			WithCooldown(10*time.Minute, 5*time.Minute),
 		aiClient:        aiClient,
 		reviewManager:   reviewManager,
 		statsManager:    statsManager,
+		settingsManager: settingsManager,
 		messageSplitter: utils.NewMessageSplitter(),
 	}, nil
 }
@@ -94,13 +99,15 @@ func (cmd *ReviewCommand) Execute(c telebot.Context, metrics *models.Metrics) er
 	}
 
 	// Create AI prompt for daily news generation
-	prompt := cmd.createDailyNewsPrompt(messageTexts, isAdmin)
+	settings := cmd.settingsManager.Get(chatID)
+	prompt := cmd.createDailyNewsPrompt(messageTexts, isAdmin, settings.ReviewPrompt)
 
 	// Get AI response
 	fmt.Printf("[i] Generating daily news for %d messages\n", len(messages))
 	response, err := cmd.aiClient.QuickChat(prompt,
 		utils.WithTemperature(0.9),
-		utils.WithMaxTokens(4000))
+		utils.WithMaxTokens(4000),
+		utils.WithTimezone(settings.Timezone))
 	if err != nil {
 		fmt.Printf("[-] AI request failed: %v\n", err)
 		_, editErr := c.Bot().Edit(generatingMsg, "❌ <b>Ошибка ИИ:</b> <code>"+err.Error()+"</code>", &telebot.SendOptions{
@@ -188,7 +195,7 @@ func (cmd *ReviewCommand) Execute(c telebot.Context, metrics *models.Metrics) er
 }
 
 // createDailyNewsPrompt creates a prompt for AI to generate daily news
-func (cmd *ReviewCommand) createDailyNewsPrompt(messages []string, isAdmin bool) string {
+func (cmd *ReviewCommand) createDailyNewsPrompt(messages []string, isAdmin bool, reviewPrompt string) string {
 	messagesText := strings.Join(messages, "\n")
 	
 	userStatus := "обычный участник"
@@ -235,7 +242,14 @@ func (cmd *ReviewCommand) createDailyNewsPrompt(messages []string, isAdmin bool)
 
 Создай МАКСИМАЛЬНО ПОДРОБНЫЕ и увлекательные "дейли новости" этого чата!`
 	
-	return fmt.Sprintf(promptTemplate, userStatus, messagesText)
+	prompt := fmt.Sprintf(promptTemplate, userStatus, messagesText)
+	
+	// Chat-specific wishes from settings
+	if reviewPrompt = strings.TrimSpace(reviewPrompt); reviewPrompt != "" {
+		prompt += "\n\nОСОБЫЕ ПОЖЕЛАНИЯ ЧАТА (учти их в первую очередь): " + reviewPrompt
+	}
+	
+	return prompt
 }
 
 // convertMarkdownToHTML converts Markdown formatting to HTML
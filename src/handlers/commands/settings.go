@@ -0,0 +1,516 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/telebot.v3"
+	"gobrev/src/handlers/callbacks"
+	"gobrev/src/models"
+	"gobrev/src/utils"
+)
+
+// settingsCallbackPrefix routes settings menu buttons
+const settingsCallbackPrefix = "settings"
+
+// settingsMenuTTL is how long settings menu buttons work
+const settingsMenuTTL = 24 * time.Hour
+
+// settingsKeys maps command argument keys to their descriptions and examples
+var settingsKeys = []struct {
+	key     string
+	title   string
+	example string
+}{
+	{"триггеры", "🔤 Слова-триггеры ИИ, через запятую", ".настройки триггеры брев, бот"},
+	{"температура", "🌡 Температура ИИ", ".настройки температура 0.7"},
+	{"токены", "📏 Лимит токенов ответа ИИ", ".настройки токены 1500"},
+	{"стат", "📊 Юзеров в .стат", ".настройки стат 10"},
+	{"ревью", "📰 Пожелания к .рев (\"-\" чтобы убрать)", ".настройки ревью пиши как спортивный комментатор"},
+	{"пояс", "🕰 Часовой пояс", ".настройки пояс Asia/Yekaterinburg"},
+	{"антиспам", "🛡 Антиспам: выкл, предупреждать, удалять или мут", ".настройки антиспам удалять"},
+	{"флуд", "🌊 Флуд: сообщений за секунд", ".настройки флуд 5/10"},
+	{"мут", "🔇 Длительность мута (антиспам, .мут, варны)", ".настройки мут 30м"},
+	{"варны", "❗ Варны: лимит, наказание (мут или бан) и срок", ".настройки варны 3 бан 30д"},
+	{"капча", "🧩 Капча для новичков: выкл, вкл или время на решение", ".настройки капча 2м"},
+	{"белый", "✅ Белый список антиспама: ID юзеров и домены", ".настройки белый youtube.com, t.me/ourchannel, 123456789"},
+	{"ии-мод", "🤖 ИИ-модерация: выкл, отчёт или удалять, ID лог-чата, порог уверенности и проверок в день", ".настройки ии-мод отчёт -1001234567890 80% 50"},
+}
+
+// warnActionNames maps warning escalations to their names in settings
+var warnActionNames = map[string]string{
+	models.WarnActionMute: "мут",
+	models.WarnActionBan:  "бан",
+}
+
+// spamActionNames maps anti-spam actions to their names in settings
+var spamActionNames = map[string]string{
+	models.SpamActionOff:    "выкл",
+	models.SpamActionWarn:   "предупреждать",
+	models.SpamActionDelete: "удалять",
+	models.SpamActionMute:   "мут",
+}
+
+// aiModerationNames maps AI moderation modes to their names in settings
+var aiModerationNames = map[string]string{
+	models.AIModerationOff:    "выкл",
+	models.AIModerationReport: "отчёт",
+	models.AIModerationDelete: "удалять",
+}
+
+// SettingsCommand handles .настройки command
+type SettingsCommand struct {
+	*BaseCommand
+	settingsManager *models.SettingsManager
+	callbackRouter  *callbacks.Router
+	adminManager    *utils.AdminManager
+}
+
+// NewSettingsCommand creates a new settings command
+func NewSettingsCommand(settingsManager *models.SettingsManager, callbackRouter *callbacks.Router) *SettingsCommand {
+	choices := []string{"сброс"}
+	for _, setting := range settingsKeys {
+		choices = append(choices, setting.key)
+	}
+
+	cmd := &SettingsCommand{
+		BaseCommand: NewBaseCommand(".настройки", false).
+			WithAliases("settings", ".настройка").
+			WithDescription("Настройки бота в этом чате (админы)").
+			WithRole(RoleChatAdmin).
+			WithArgs(
+				ArgSpec{Name: "ключ", Type: ArgChoice, Choices: choices},
+				ArgSpec{Name: "значение", Type: ArgText},
+			),
+		settingsManager: settingsManager,
+		callbackRouter:  callbackRouter,
+		adminManager:    utils.NewAdminManager(),
+	}
+	
+	callbackRouter.Handle(settingsCallbackPrefix, cmd.handleButton)
+	return cmd
+}
+
+// Execute shows the settings menu or changes a single value
+func (cmd *SettingsCommand) Execute(c telebot.Context, metrics *models.Metrics) error {
+	metrics.RecordCommand()
+
+	chatID := c.Chat().ID
+	args := GetArgs(c)
+	key := args.String("ключ")
+	value := strings.TrimSpace(args.String("значение"))
+
+	switch {
+	case key == "":
+		keyboard, err := cmd.callbackRouter.NewKeyboard(settingsCallbackPrefix, callbacks.KeyboardOptions{
+			ChatID: chatID,
+			TTL:    settingsMenuTTL,
+		})
+		if err != nil {
+			return cmd.SafeSend(c, "❌ Ошибка открытия меню: "+err.Error())
+		}
+		settings := cmd.settingsManager.Get(chatID)
+		return cmd.SafeSend(c, cmd.formatSettings(settings), &telebot.SendOptions{
+			ParseMode:   telebot.ModeHTML,
+			ReplyMarkup: cmd.buildMenu(keyboard, settings),
+		})
+
+	case key == "сброс":
+		if err := cmd.settingsManager.Reset(chatID); err != nil {
+			return cmd.SafeSend(c, "❌ Ошибка сброса настроек: "+err.Error())
+		}
+		fmt.Printf("[+] Settings reset in chat %d by user %d\n", chatID, c.Sender().ID)
+		return cmd.SafeSend(c, "♻️ Настройки сброшены по умолчанию", &telebot.SendOptions{ReplyTo: c.Message()})
+
+	case value == "":
+		for _, setting := range settingsKeys {
+			if setting.key == key {
+				return cmd.SafeSend(c, fmt.Sprintf("%s\nПример: <code>%s</code>", setting.title, html.EscapeString(setting.example)), &telebot.SendOptions{
+					ParseMode: telebot.ModeHTML,
+					ReplyTo:   c.Message(),
+				})
+			}
+		}
+	}
+
+	settings := cmd.settingsManager.Get(chatID)
+	logChat := settings.AIModLogChat
+	err := applySetting(&settings, key, value)
+	if err == nil && settings.AIModLogChat != 0 && settings.AIModLogChat != logChat {
+		err = checkLogChat(c, settings.AIModLogChat)
+	}
+	if err == nil {
+		err = cmd.settingsManager.Save(settings)
+	}
+	if err != nil {
+		return cmd.SafeSend(c, "❌ "+err.Error(), &telebot.SendOptions{ReplyTo: c.Message()})
+	}
+
+	fmt.Printf("[+] Setting %s changed in chat %d by user %d\n", key, chatID, c.Sender().ID)
+	return cmd.SafeSend(c, "✅ Сохранено\n\n"+cmd.formatSettings(settings), &telebot.SendOptions{
+		ParseMode: telebot.ModeHTML,
+		ReplyTo:   c.Message(),
+	})
+}
+
+// applySetting parses a text value into the settings field named by key
+func applySetting(settings *models.ChatSettings, key, value string) error {
+	switch key {
+	case "триггеры":
+		var words []string
+		for _, word := range strings.Split(value, ",") {
+			if word = strings.ToLower(strings.TrimSpace(word)); word != "" {
+				words = append(words, word)
+			}
+		}
+		settings.TriggerWords = words
+
+	case "температура":
+		temperature, err := strconv.ParseFloat(strings.ReplaceAll(value, ",", "."), 64)
+		if err != nil {
+			return fmt.Errorf("температура должна быть числом, например 0.7")
+		}
+		settings.AITemperature = temperature
+
+	case "токены":
+		tokens, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("лимит токенов должен быть числом")
+		}
+		settings.AIMaxTokens = tokens
+
+	case "стат":
+		count, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("число юзеров должно быть числом")
+		}
+		settings.StatsTopUsers = count
+
+	case "ревью":
+		if value == "-" {
+			value = ""
+		}
+		settings.ReviewPrompt = value
+
+	case "пояс":
+		settings.Timezone = value
+
+	case "антиспам":
+		for action, name := range spamActionNames {
+			if strings.ToLower(value) == name {
+				settings.SpamAction = action
+				return nil
+			}
+		}
+		return fmt.Errorf("антиспам: выкл, предупреждать, удалять или мут")
+
+	case "флуд":
+		messages, seconds, ok := strings.Cut(strings.ReplaceAll(value, " ", "/"), "/")
+		count, err1 := strconv.Atoi(messages)
+		window, err2 := strconv.Atoi(strings.TrimSpace(seconds))
+		if !ok || err1 != nil || err2 != nil {
+			return fmt.Errorf("флуд задаётся как сообщений/секунд, например 5/10")
+		}
+		settings.FloodMessages = count
+		settings.FloodSeconds = window
+
+	case "мут":
+		duration, err := ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		settings.MuteMinutes = int(duration / time.Minute)
+
+	case "варны":
+		for _, word := range strings.Fields(strings.ToLower(value)) {
+			if limit, err := strconv.Atoi(word); err == nil {
+				settings.WarnLimit = limit
+				continue
+			}
+			switch word {
+			case "мут":
+				settings.WarnAction = models.WarnActionMute
+			case "бан":
+				settings.WarnAction = models.WarnActionBan
+			default:
+				duration, err := ParseDuration(word)
+				if err != nil {
+					return fmt.Errorf("варны задаются как лимит, мут или бан и срок, например 3 бан 30д")
+				}
+				settings.WarnDays = max(1, int(duration/(24*time.Hour)))
+			}
+		}
+
+	case "капча":
+		switch strings.ToLower(value) {
+		case "выкл":
+			settings.Captcha = false
+		case "вкл":
+			settings.Captcha = true
+		default:
+			duration, err := ParseDuration(value)
+			if err != nil {
+				return fmt.Errorf("капча: выкл, вкл или время на решение, например 2м")
+			}
+			settings.Captcha = true
+			settings.CaptchaSecs = int(duration / time.Second)
+		}
+
+	case "ии-мод":
+		for _, word := range strings.Fields(strings.ToLower(value)) {
+			word = strings.ReplaceAll(word, "ё", "е")
+			switch {
+			case word == "выкл":
+				settings.AIModeration = models.AIModerationOff
+			case word == "отчет":
+				settings.AIModeration = models.AIModerationReport
+			case word == "удалять":
+				settings.AIModeration = models.AIModerationDelete
+			case word == "-":
+				settings.AIModLogChat = 0
+			case strings.HasSuffix(word, "%"):
+				percent, err := strconv.ParseFloat(strings.TrimSuffix(word, "%"), 64)
+				if err != nil {
+					return fmt.Errorf("порог задаётся в процентах, например 80%%")
+				}
+				settings.AIModMinScore = percent / 100
+			default:
+				number, err := strconv.ParseInt(word, 10, 64)
+				if err != nil {
+					return fmt.Errorf("ии-мод задаётся как режим (выкл, отчёт, удалять), ID лог-чата, порог и лимит, например отчёт -1001234567890 80%% 50")
+				}
+				// Chat IDs are negative, small numbers are the daily limit
+				if number < 0 {
+					settings.AIModLogChat = number
+				} else {
+					settings.AIModDaily = int(number)
+				}
+			}
+		}
+
+	case "белый":
+		var entries []string
+		for _, entry := range strings.Split(value, ",") {
+			entry = strings.ToLower(strings.TrimSpace(entry))
+			entry = strings.TrimPrefix(strings.TrimPrefix(entry, "https://"), "http://")
+			if entry != "" && entry != "-" {
+				entries = append(entries, strings.TrimSuffix(entry, "/"))
+			}
+		}
+		settings.SpamAllowList = entries
+	}
+
+	return nil
+}
+
+// handleButton handles settings menu buttons. Actions are "<field>:<direction>".
+func (cmd *SettingsCommand) handleButton(c telebot.Context, data *models.CallbackData, action string) error {
+	if c.Chat().Type != telebot.ChatPrivate && !cmd.adminManager.IsAdmin(c) {
+		return c.Respond(&telebot.CallbackResponse{Text: "❌ Настройки меняют только админы чата", ShowAlert: true})
+	}
+
+	chatID := c.Chat().ID
+	field, direction, _ := strings.Cut(action, ":")
+
+	switch field {
+	case "spam":
+		// Cycle through anti-spam actions
+		_, err := cmd.settingsManager.Update(chatID, func(settings *models.ChatSettings) {
+			for i, spamAction := range models.SpamActions {
+				if spamAction == settings.SpamAction {
+					settings.SpamAction = models.SpamActions[(i+1)%len(models.SpamActions)]
+					break
+				}
+			}
+		})
+		if err != nil {
+			return c.Respond(&telebot.CallbackResponse{Text: "❌ " + err.Error(), ShowAlert: true})
+		}
+
+	case "captcha":
+		_, err := cmd.settingsManager.Update(chatID, func(settings *models.ChatSettings) {
+			settings.Captcha = !settings.Captcha
+		})
+		if err != nil {
+			return c.Respond(&telebot.CallbackResponse{Text: "❌ " + err.Error(), ShowAlert: true})
+		}
+
+	case "aimod":
+		// Cycle through AI moderation modes, reports are skipped until a log chat is set
+		_, err := cmd.settingsManager.Update(chatID, func(settings *models.ChatSettings) {
+			switch settings.AIModeration {
+			case models.AIModerationOff:
+				settings.AIModeration = models.AIModerationReport
+				if settings.AIModLogChat == 0 {
+					settings.AIModeration = models.AIModerationDelete
+				}
+			case models.AIModerationReport:
+				settings.AIModeration = models.AIModerationDelete
+			default:
+				settings.AIModeration = models.AIModerationOff
+			}
+		})
+		if err != nil {
+			return c.Respond(&telebot.CallbackResponse{Text: "❌ " + err.Error(), ShowAlert: true})
+		}
+
+	case "close":
+		cmd.callbackRouter.Disable(data.ID)
+		c.Respond()
+		return c.Delete()
+
+	case "reset":
+		if err := cmd.settingsManager.Reset(chatID); err != nil {
+			return c.Respond(&telebot.CallbackResponse{Text: "❌ " + err.Error(), ShowAlert: true})
+		}
+
+	case "help":
+		for _, setting := range settingsKeys {
+			if setting.key == direction {
+				return c.Respond(&telebot.CallbackResponse{Text: setting.title + "\n\nОтправь:\n" + setting.example, ShowAlert: true})
+			}
+		}
+		return c.Respond()
+
+	default:
+		step := 1.0
+		if direction == "dec" {
+			step = -1
+		}
+
+		_, err := cmd.settingsManager.Update(chatID, func(settings *models.ChatSettings) {
+			switch field {
+			case "temp":
+				settings.AITemperature = clampFloat(math.Round((settings.AITemperature+step*0.1)*10)/10, models.MinAITemperature, models.MaxAITemperature)
+			case "tokens":
+				settings.AIMaxTokens = clampInt(settings.AIMaxTokens+int(step)*100, models.MinAIMaxTokens, models.MaxAIMaxTokens)
+			case "stats":
+				settings.StatsTopUsers = clampInt(settings.StatsTopUsers+int(step), models.MinStatsTopUsers, models.MaxStatsTopUsers)
+			}
+		})
+		if err != nil {
+			return c.Respond(&telebot.CallbackResponse{Text: "❌ " + err.Error(), ShowAlert: true})
+		}
+	}
+
+	fmt.Printf("[+] Settings button %s in chat %d by user %d\n", action, chatID, c.Sender().ID)
+	settings := cmd.settingsManager.Get(chatID)
+
+	c.Respond(&telebot.CallbackResponse{Text: "✅ Сохранено"})
+	err := c.Edit(cmd.formatSettings(settings), &telebot.SendOptions{
+		ParseMode:   telebot.ModeHTML,
+		ReplyMarkup: cmd.buildMenu(cmd.callbackRouter.Keyboard(data), settings),
+	})
+	if errors.Is(err, telebot.ErrSameMessageContent) {
+		return nil
+	}
+	return err
+}
+
+// formatSettings renders current settings as HTML
+func (cmd *SettingsCommand) formatSettings(settings models.ChatSettings) string {
+	reviewPrompt := settings.ReviewPrompt
+	if reviewPrompt == "" {
+		reviewPrompt = "—"
+	}
+	allowList := strings.Join(settings.SpamAllowList, ", ")
+	if allowList == "" {
+		allowList = "—"
+	}
+
+	return fmt.Sprintf(`⚙️ <b>Настройки чата</b>
+
+🔤 Триггеры: <code>%s</code>
+🌡 Температура ИИ: <b>%.1f</b>
+📏 Лимит токенов: <b>%d</b>
+📊 Юзеров в .стат: <b>%d</b>
+📰 Пожелания к ревью: <i>%s</i>
+🕰 Часовой пояс: <code>%s</code>
+🛡 Антиспам: <b>%s</b> (флуд %d/%d сек., мут %d мин.)
+✅ Белый список: <code>%s</code>
+❗ Варны: <b>%d</b> → %s, срок %d дн.
+🧩 Капча: <b>%s</b>
+🤖 ИИ-модерация: <b>%s</b>
+
+<i>Текстовые значения: <code>.настройки ключ значение</code></i>`,
+		html.EscapeString(strings.Join(settings.TriggerWords, ", ")),
+		settings.AITemperature,
+		settings.AIMaxTokens,
+		settings.StatsTopUsers,
+		html.EscapeString(reviewPrompt),
+		html.EscapeString(settings.Timezone),
+		spamActionNames[settings.SpamAction],
+		settings.FloodMessages,
+		settings.FloodSeconds,
+		settings.MuteMinutes,
+		html.EscapeString(allowList),
+		settings.WarnLimit,
+		warnActionNames[settings.WarnAction],
+		settings.WarnDays,
+		captchaStatus(settings),
+		aiModerationStatus(settings))
+}
+
+// buildMenu builds the inline keyboard of the settings menu
+func (cmd *SettingsCommand) buildMenu(keyboard *callbacks.Keyboard, settings models.ChatSettings) *telebot.ReplyMarkup {
+	button := keyboard.Button
+
+	return &telebot.ReplyMarkup{
+		InlineKeyboard: [][]telebot.InlineButton{
+			{button("🌡 −0.1", "temp:dec"), button(fmt.Sprintf("🌡 %.1f", settings.AITemperature), "help:температура"), button("🌡 +0.1", "temp:inc")},
+			{button("📏 −100", "tokens:dec"), button(fmt.Sprintf("📏 %d", settings.AIMaxTokens), "help:токены"), button("📏 +100", "tokens:inc")},
+			{button("📊 −1", "stats:dec"), button(fmt.Sprintf("📊 %d", settings.StatsTopUsers), "help:стат"), button("📊 +1", "stats:inc")},
+			{button("🔤 Триггеры", "help:триггеры"), button("📰 Ревью", "help:ревью"), button("🕰 Пояс", "help:пояс")},
+			{button("🛡 Антиспам: "+spamActionNames[settings.SpamAction], "spam:next"), button("🌊 Флуд", "help:флуд"), button("✅ Белый", "help:белый")},
+			{button("🧩 Капча: "+captchaStatus(settings), "captcha:toggle"), button("❗ Варны", "help:варны")},
+			{button("🤖 ИИ-модерация: "+aiModerationNames[settings.AIModeration], "aimod:next"), button("🤖 Лог-чат", "help:ии-мод")},
+			{button("♻️ Сбросить", "reset:"), button("✖️ Закрыть", "close:")},
+		},
+	}
+}
+
+// captchaStatus describes the captcha setting
+func captchaStatus(settings models.ChatSettings) string {
+	if !settings.Captcha {
+		return "выкл"
+	}
+	return fmt.Sprintf("%d сек.", settings.CaptchaSecs)
+}
+
+// aiModerationStatus describes the AI moderation settings
+func aiModerationStatus(settings models.ChatSettings) string {
+	if settings.AIModeration == models.AIModerationOff {
+		return "выкл"
+	}
+
+	logChat := "без лог-чата"
+	if settings.AIModLogChat != 0 {
+		logChat = fmt.Sprintf("лог-чат %d", settings.AIModLogChat)
+	}
+	return fmt.Sprintf("%s, %s, порог %.0f%%, %d проверок в день",
+		aiModerationNames[settings.AIModeration], logChat, settings.AIModMinScore*100, settings.AIModDaily)
+}
+
+// checkLogChat makes sure the admin also runs the log chat, so reports can't be pushed into someone else's chat
+func checkLogChat(c telebot.Context, chatID int64) error {
+	member, err := c.Bot().ChatMemberOf(&telebot.Chat{ID: chatID}, c.Sender())
+	if err != nil || (member.Role != telebot.Creator && member.Role != telebot.Administrator) {
+		return fmt.Errorf("лог-чат %d: добавь туда бота, и ты должен быть его админом", chatID)
+	}
+	return nil
+}
+
+// clampInt limits n to [lo, hi]
+func clampInt(n, lo, hi int) int {
+	return max(lo, min(hi, n))
+}
+
+// clampFloat limits f to [lo, hi]
+func clampFloat(f, lo, hi float64) float64 {
+	return math.Max(lo, math.Min(hi, f))
+}
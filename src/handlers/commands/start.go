@@ -16,7 +16,10 @@ type StartCommand struct {
 // NewStartCommand creates a new start command
 func NewStartCommand() *StartCommand {
 	return &StartCommand{
-		BaseCommand: NewBaseCommand("/start", false),
+		BaseCommand: NewBaseCommand("/start", false).
+			WithAliases("start", ".старт").
+			WithDescription("О боте").
+			WithArgs(ArgSpec{Name: "параметр", Type: ArgText}),
 	}
 }
 
@@ -15,14 +15,19 @@ import (
 type StatsCommand struct {
 	*BaseCommand
 	statsManager    *models.StatsManager
+	settingsManager *models.SettingsManager
 	messageSplitter *utils.MessageSplitter
 }
 
 // NewStatsCommand creates a new stats command
-func NewStatsCommand(statsManager *models.StatsManager) *StatsCommand {
+func NewStatsCommand(statsManager *models.StatsManager, settingsManager *models.SettingsManager) *StatsCommand {
 	return &StatsCommand{
-		BaseCommand:     NewBaseCommand(".стат", false),
+		BaseCommand: NewBaseCommand(".стат", false).
+			WithAliases("stats", ".статистика").
+			WithDescription("Топ активных участников за день").
+			WithArgs(ArgSpec{Name: "период", Type: ArgChoice, Choices: []string{"все"}}),
 		statsManager:    statsManager,
+		settingsManager: settingsManager,
 		messageSplitter: utils.NewMessageSplitter(),
 	}
 }
@@ -37,13 +42,12 @@ func (cmd *StatsCommand) Execute(c telebot.Context, metrics *models.Metrics) err
 	}
 	
 	chatID := c.Chat().ID
-	text := c.Text()
 	
 	// Determine if showing all time stats
-	showAllTime := strings.Contains(strings.ToLower(text), "все")
+	showAllTime := GetArgs(c).String("период") == "все"
 	
 	// Get top users
-	topUsers, err := cmd.statsManager.GetTopUsers(chatID, 20, showAllTime)
+	topUsers, err := cmd.statsManager.GetTopUsers(chatID, cmd.settingsManager.Get(chatID).StatsTopUsers, showAllTime)
 	if err != nil {
 		return cmd.SafeSend(c, "❌ Ошибка получения статистики: " + err.Error())
 	}
@@ -65,7 +69,7 @@ func (cmd *StatsCommand) Execute(c telebot.Context, metrics *models.Metrics) err
 	}
 	
 	// Generate image for top 3 users
-	imageBuffer, err := utils.GenerateTopUsersImage(topUsers[:min(3, len(topUsers))], c.Bot())
+	imageBuffer, err := utils.GenerateTopUsersImage(topUsers[:min(3, len(topUsers))], c.Bot(), utils.ActivityPodium)
 	if err != nil {
 		// If image generation fails, send text-only stats
 		return cmd.sendTextStats(c, topUsers, totalMessages, popularWords, showAllTime)
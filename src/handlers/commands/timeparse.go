@@ -0,0 +1,301 @@
+package commands
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"gobrev/src/models"
+)
+
+// defaultReminderHour is used when a day is given without a time
+const defaultReminderHour = 9
+
+// reminderTime is a reminder time parsed from Russian text
+type reminderTime struct {
+	at      time.Time
+	repeat  string
+	weekday time.Weekday
+	hour    int
+	minute  int
+}
+
+// durationUnits maps Russian unit words to durations, for "через 2 часа"
+var durationUnits = map[string]time.Duration{
+	"мин": time.Minute, "минута": time.Minute, "минуту": time.Minute, "минуты": time.Minute, "минут": time.Minute,
+	"час": time.Hour, "часа": time.Hour, "часов": time.Hour,
+	"день": 24 * time.Hour, "дня": 24 * time.Hour, "дней": 24 * time.Hour, "сутки": 24 * time.Hour, "суток": 24 * time.Hour,
+	"неделю": 7 * 24 * time.Hour, "недели": 7 * 24 * time.Hour, "недель": 7 * 24 * time.Hour,
+	"месяц": 30 * 24 * time.Hour, "месяца": 30 * 24 * time.Hour, "месяцев": 30 * 24 * time.Hour,
+}
+
+// relativeDays maps day words to offsets from today
+var relativeDays = map[string]int{"сегодня": 0, "завтра": 1, "послезавтра": 2}
+
+// weekdayWords maps short weekday names and stems of full names in any case form
+var weekdayWords = []struct {
+	short   string
+	stem    string
+	weekday time.Weekday
+}{
+	{"пн", "понедельник", time.Monday},
+	{"вт", "вторник", time.Tuesday},
+	{"ср", "сред", time.Wednesday},
+	{"чт", "четверг", time.Thursday},
+	{"пт", "пятниц", time.Friday},
+	{"сб", "суббот", time.Saturday},
+	{"вс", "воскресень", time.Sunday},
+}
+
+// errNoReminderTime is returned when text doesn't start with a time
+var errNoReminderTime = errors.New("не понял когда. Примеры: через 2ч, завтра в 10:00, в пт в 18:30, 31.12 в 23:59, каждый пн в 9:00, по будням в 8:00")
+
+// parseReminderTime parses a time at the start of words and returns it with the number of words used.
+// now must be in the chat timezone.
+func parseReminderTime(words []string, now time.Time) (reminderTime, int, error) {
+	lower := make([]string, len(words))
+	for i, word := range words {
+		lower[i] = strings.Trim(strings.ToLower(word), ",")
+	}
+	at := func(i int) string {
+		if i < len(lower) {
+			return lower[i]
+		}
+		return ""
+	}
+
+	switch first := at(0); {
+	case first == "через":
+		duration, used := parseSpokenDuration(lower[1:])
+		if used == 0 {
+			return reminderTime{}, 0, errors.New("через сколько? Например: через 30м, через 2 часа, через 1ч30м")
+		}
+		return reminderTime{at: now.Add(duration)}, 1 + used, nil
+
+	case first == "ежедневно" || first == "каждый" || first == "каждое" || first == "каждую" || first == "по":
+		return parseRecurring(lower, now)
+	}
+
+	// Day: сегодня, завтра, послезавтра, a weekday or a date. Without a day the nearest matching time is used.
+	i := 0
+	day := now
+	kind := ""
+	if offset, ok := relativeDays[at(0)]; ok {
+		day, i, kind = now.AddDate(0, 0, offset), 1, "relative"
+	} else {
+		if at(0) == "в" || at(0) == "во" {
+			i = 1
+		}
+		if weekday, ok := parseWeekday(at(i)); ok {
+			day, i, kind = now.AddDate(0, 0, (int(weekday)-int(now.Weekday())+7)%7), i+1, "weekday"
+		} else if date, ok := parseDate(at(0), now); ok && !isClockNotDate(lower) {
+			day, i, kind = date, 1, "date"
+		} else {
+			i = 0
+		}
+	}
+
+	hour, minute, used, ok := parseClock(lower[i:])
+	if !ok {
+		if kind == "" {
+			return reminderTime{}, 0, errNoReminderTime
+		}
+		hour, minute = defaultReminderHour, 0
+	}
+	i += used
+
+	result := time.Date(day.Year(), day.Month(), day.Day(), hour, minute, 0, 0, now.Location())
+	if !result.After(now) {
+		switch {
+		case kind == "":
+			// "в 9:00" after nine means tomorrow
+			result = result.AddDate(0, 0, 1)
+		case kind == "weekday":
+			// Today's weekday with a time that has passed means next week
+			result = result.AddDate(0, 0, 7)
+		case kind == "date" && strings.Count(at(0), ".") == 1:
+			// A date without a year that has passed means next year
+			result = result.AddDate(1, 0, 0)
+		}
+	}
+	if !result.After(now) {
+		return reminderTime{}, 0, errors.New("это время уже прошло")
+	}
+
+	return reminderTime{at: result}, i, nil
+}
+
+// parseRecurring parses "ежедневно", "каждый день", "каждый пн", "каждую пятницу", "по будням", "по средам" with an optional time
+func parseRecurring(words []string, now time.Time) (reminderTime, int, error) {
+	result := reminderTime{hour: defaultReminderHour}
+	i := 1
+
+	switch {
+	case words[0] == "ежедневно":
+		result.repeat = models.RepeatDaily
+	case len(words) > 1 && words[1] == "день":
+		result.repeat, i = models.RepeatDaily, 2
+	case len(words) > 1 && words[1] == "будням":
+		result.repeat, i = models.RepeatWeekdays, 2
+	case len(words) > 1:
+		weekday, ok := parseWeekday(words[1])
+		if !ok {
+			return reminderTime{}, 0, errNoReminderTime
+		}
+		result.repeat, result.weekday, i = models.RepeatWeekly, weekday, 2
+	default:
+		return reminderTime{}, 0, errNoReminderTime
+	}
+
+	if hour, minute, used, ok := parseClock(words[i:]); ok {
+		result.hour, result.minute = hour, minute
+		i += used
+	}
+
+	rule := models.Reminder{
+		Repeat:   result.repeat,
+		Weekday:  int(result.weekday),
+		Hour:     result.hour,
+		Minute:   result.minute,
+		Timezone: now.Location().String(),
+	}
+	result.at = rule.Next(now)
+	if result.at.IsZero() {
+		return reminderTime{}, 0, errNoReminderTime
+	}
+
+	return result, i, nil
+}
+
+// parseSpokenDuration parses "2 часа", "30 минут", "час", "полчаса", "1ч30м" and chains like "1 час 30 минут"
+func parseSpokenDuration(words []string) (time.Duration, int) {
+	var total time.Duration
+	i := 0
+
+	for i < len(words) {
+		word := words[i]
+
+		if word == "полчаса" {
+			total += 30 * time.Minute
+			i++
+			continue
+		}
+		if unit, ok := durationUnits[word]; ok {
+			total += unit
+			i++
+			continue
+		}
+		if n, err := strconv.Atoi(word); err == nil && i+1 < len(words) {
+			if unit, ok := durationUnits[words[i+1]]; ok {
+				total += time.Duration(n) * unit
+				i += 2
+				continue
+			}
+		}
+		if duration, err := ParseDuration(word); err == nil {
+			total += duration
+			i++
+			continue
+		}
+		break
+	}
+
+	return total, i
+}
+
+// parseClock parses "в 10:00", "10:00", "в 9", "в 7 вечера" and returns the hour, minute and words used
+func parseClock(words []string) (int, int, int, bool) {
+	i := 0
+	if i < len(words) && (words[i] == "в" || words[i] == "во") {
+		i++
+	}
+	if i >= len(words) {
+		return 0, 0, 0, false
+	}
+
+	clock := strings.ReplaceAll(words[i], ".", ":")
+	hourText, minuteText, hasMinutes := strings.Cut(clock, ":")
+	hour, err := strconv.Atoi(hourText)
+	if err != nil {
+		return 0, 0, 0, false
+	}
+	minute := 0
+	if hasMinutes {
+		if minute, err = strconv.Atoi(minuteText); err != nil {
+			return 0, 0, 0, false
+		}
+	} else if i == 0 {
+		// A bare number without "в" is more likely the start of the text
+		return 0, 0, 0, false
+	}
+	i++
+
+	if i < len(words) {
+		switch words[i] {
+		case "утра", "ночи":
+			if hour == 12 {
+				hour = 0
+			}
+			i++
+		case "дня", "вечера":
+			if hour < 12 {
+				hour += 12
+			}
+			i++
+		}
+	}
+
+	if hour < 0 || hour > 23 || minute < 0 || minute > 59 {
+		return 0, 0, 0, false
+	}
+	return hour, minute, i, true
+}
+
+// isClockNotDate reports whether "10.05" at the start is a time rather than a date.
+// It is a date only with a year, when it can't be a time or when a time follows it: "10.05 в 18:00".
+func isClockNotDate(words []string) bool {
+	if strings.Count(words[0], ".") != 1 {
+		return false
+	}
+	if _, _, _, ok := parseClock(words[:1]); !ok {
+		return false
+	}
+	_, _, _, timed := parseClock(words[1:])
+	return !timed
+}
+
+// parseWeekday parses "пн", "понедельник", "пятницу", "средам"
+func parseWeekday(word string) (time.Weekday, bool) {
+	for _, day := range weekdayWords {
+		if word == day.short || strings.HasPrefix(word, day.stem) {
+			return day.weekday, true
+		}
+	}
+	return 0, false
+}
+
+// parseDate parses "31.12" and "31.12.2027"
+func parseDate(word string, now time.Time) (time.Time, bool) {
+	parts := strings.Split(word, ".")
+	if len(parts) != 2 && len(parts) != 3 {
+		return time.Time{}, false
+	}
+
+	day, err1 := strconv.Atoi(parts[0])
+	month, err2 := strconv.Atoi(parts[1])
+	year := now.Year()
+	var err3 error
+	if len(parts) == 3 {
+		year, err3 = strconv.Atoi(parts[2])
+	}
+	if err1 != nil || err2 != nil || err3 != nil || month < 1 || month > 12 || day < 1 || day > 31 {
+		return time.Time{}, false
+	}
+
+	date := time.Date(year, time.Month(month), day, 0, 0, 0, 0, now.Location())
+	if date.Day() != day {
+		return time.Time{}, false
+	}
+	return date, true
+}
@@ -0,0 +1,79 @@
+package commands
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"gobrev/src/models"
+)
+
+func TestParseReminderTime(t *testing.T) {
+	// Chat timezones are named, recurring reminders load them by name
+	location, err := time.LoadLocation("Europe/Moscow")
+	if err != nil {
+		t.Skip("no timezone database")
+	}
+	// Wednesday, 12:00
+	now := time.Date(2026, 10, 14, 12, 0, 0, 0, location)
+	at := func(year int, month time.Month, day, hour, minute int) time.Time {
+		return time.Date(year, month, day, hour, minute, 0, 0, location)
+	}
+
+	tests := []struct {
+		input  string
+		want   time.Time
+		repeat string
+		used   int
+	}{
+		{"через 2 часа купить пиво", at(2026, 10, 14, 14, 0), "", 3},
+		{"через 30м", at(2026, 10, 14, 12, 30), "", 2},
+		{"через 1 час 30 минут", at(2026, 10, 14, 13, 30), "", 5},
+		{"через полчаса", at(2026, 10, 14, 12, 30), "", 2},
+		{"завтра в 10:00 созвон", at(2026, 10, 15, 10, 0), "", 3},
+		{"завтра созвон", at(2026, 10, 15, 9, 0), "", 1},
+		{"в пт в 18:30 пятница!", at(2026, 10, 16, 18, 30), "", 4},
+		{"в ср в 9:00", at(2026, 10, 21, 9, 0), "", 4},
+		{"в 7 вечера", at(2026, 10, 14, 19, 0), "", 3},
+		{"в 9 проснуться", at(2026, 10, 15, 9, 0), "", 2},
+		{"31.12 в 23:59 шампанское", at(2026, 12, 31, 23, 59), "", 3},
+		{"31.12", at(2026, 12, 31, 9, 0), "", 1},
+		{"10.05 позвонить", at(2026, 10, 15, 10, 5), "", 1},
+		{"10.05 в 18:00", at(2027, 5, 10, 18, 0), "", 3},
+		{"10.05.2027", at(2027, 5, 10, 9, 0), "", 1},
+		{"каждый пн в 9:00 планёрка", at(2026, 10, 19, 9, 0), models.RepeatWeekly, 4},
+		{"по будням в 8:00 зарядка", at(2026, 10, 15, 8, 0), models.RepeatWeekdays, 4},
+		{"ежедневно в 20:00", at(2026, 10, 14, 20, 0), models.RepeatDaily, 3},
+		{"каждый день", at(2026, 10, 15, 9, 0), models.RepeatDaily, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			parsed, used, err := parseReminderTime(strings.Fields(tt.input), now)
+			if err != nil {
+				t.Fatalf("parseReminderTime(%q): %v", tt.input, err)
+			}
+			if !parsed.at.Equal(tt.want) || parsed.repeat != tt.repeat || used != tt.used {
+				t.Errorf("parseReminderTime(%q) = %s %q, %d words, want %s %q, %d words",
+					tt.input, parsed.at, parsed.repeat, used, tt.want, tt.repeat, tt.used)
+			}
+		})
+	}
+}
+
+func TestParseReminderTimeErrors(t *testing.T) {
+	now := time.Date(2026, 10, 14, 12, 0, 0, 0, time.UTC)
+
+	for _, input := range []string{
+		"купить пиво",
+		"через",
+		"через пару часов",
+		"10.05.2020",
+		"сегодня в 8:00",
+		"каждый раз",
+		"в 25:00",
+	} {
+		if parsed, _, err := parseReminderTime(strings.Fields(input), now); err == nil {
+			t.Errorf("parseReminderTime(%q) = %s, want an error", input, parsed.at)
+		}
+	}
+}
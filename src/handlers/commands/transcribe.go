@@ -0,0 +1,73 @@
+package commands
+
+import (
+	"fmt"
+	"html"
+	"time"
+
+	"gopkg.in/telebot.v3"
+	"gobrev/src/models"
+	"gobrev/src/utils"
+)
+
+// TranscribeCommand handles .текст command (reply to a voice message)
+type TranscribeCommand struct {
+	*BaseCommand
+	transcriber     utils.Transcriber
+	messageSplitter *utils.MessageSplitter
+}
+
+// NewTranscribeCommand creates a new transcribe command
+func NewTranscribeCommand(transcriber utils.Transcriber) *TranscribeCommand {
+	return &TranscribeCommand{
+		BaseCommand: NewBaseCommand(".текст", false).
+			WithAliases("text", ".расшифруй").
+			WithDescription("Расшифровать голосовое (ответом на него)").
+			WithCooldown(10*time.Second, 0),
+		transcriber:     transcriber,
+		messageSplitter: utils.NewMessageSplitter(),
+	}
+}
+
+// Execute transcribes the voice message, video note or audio the command replies to
+func (cmd *TranscribeCommand) Execute(c telebot.Context, metrics *models.Metrics) error {
+	metrics.RecordCommand()
+
+	reply := c.Message().ReplyTo
+	if reply == nil {
+		return cmd.SafeSend(c, "🎤 Ответь этой командой на голосовое или кружок", &telebot.SendOptions{ReplyTo: c.Message()})
+	}
+
+	var file *telebot.File
+	var filename string
+	switch {
+	case reply.Voice != nil:
+		file, filename = &reply.Voice.File, "voice.ogg"
+	case reply.VideoNote != nil:
+		file, filename = &reply.VideoNote.File, "video_note.mp4"
+	case reply.Audio != nil:
+		file, filename = &reply.Audio.File, reply.Audio.FileName
+		if filename == "" {
+			filename = "audio.mp3"
+		}
+	default:
+		return cmd.SafeSend(c, "🎤 В этом сообщении нет голосового", &telebot.SendOptions{ReplyTo: c.Message()})
+	}
+
+	c.Notify(telebot.Typing)
+
+	text, err := utils.TranscribeFile(c.Bot(), cmd.transcriber, file, filename)
+	if err != nil {
+		fmt.Printf("[-] Transcription failed: %v\n", err)
+		return cmd.SafeSend(c, "❌ Ошибка расшифровки: "+err.Error(), &telebot.SendOptions{ReplyTo: reply})
+	}
+	if text == "" {
+		return cmd.SafeSend(c, "🎤 <i>Слов не разобрать</i>", &telebot.SendOptions{ParseMode: telebot.ModeHTML, ReplyTo: reply})
+	}
+
+	fmt.Printf("[+] Transcribed message %d in chat %d (%d chars)\n", reply.ID, c.Chat().ID, len(text))
+	return cmd.messageSplitter.SendLongMessage(c, "🎤 "+html.EscapeString(text), &telebot.SendOptions{
+		ParseMode: telebot.ModeHTML,
+		ReplyTo:   reply,
+	})
+}
@@ -2,25 +2,51 @@ package factory
 
 import (
 	"fmt"
+	"html"
+	"regexp"
+	"strings"
 	"time"
 
 	"gopkg.in/telebot.v3"
+	"gobrev/src/handlers/callbacks"
 	"gobrev/src/handlers/commands"
 	"gobrev/src/models"
+	"gobrev/src/storage"
+	"gobrev/src/utils"
 )
 
-// CommandFactory manages command registration and execution
+// telegramCommandRx matches names Telegram accepts in the command menu
+var telegramCommandRx = regexp.MustCompile(`^[a-z0-9_]{1,32}$`)
+
+// CommandFactory registers commands and routes messages to them by name or alias
 type CommandFactory struct {
 	commands         map[string]commands.Command
+	ordered          []commands.Command
 	metrics          *models.Metrics
 	historyManager   *models.UserHistoryManager
 	messageIDManager *models.MessageIDManager
 	statsManager     *models.StatsManager
 	reviewManager    *models.ReviewManager
+	privacyManager   *models.PrivacyManager
+	warningManager   *models.WarningManager
+	greetingManager  *models.GreetingManager
+	reminderManager  *models.ReminderManager
+	karmaManager     *models.KarmaManager
+	quoteManager     *models.QuoteManager
+	quizManager      *models.QuizManager
+	dailyManager     *models.DailyWinnerManager
+	settingsManager  *models.SettingsManager
+	backupManager    *storage.BackupManager
+	transcriber      utils.Transcriber
+	callbackRouter   *callbacks.Router
+	greeter          *commands.Greeter
+	quizGame         *commands.QuizGame
+	adminManager     *utils.AdminManager
+	cooldowns        *utils.CooldownTracker
 }
 
 // NewCommandFactory creates a new command factory
-func NewCommandFactory(metrics *models.Metrics, historyManager *models.UserHistoryManager, messageIDManager *models.MessageIDManager, statsManager *models.StatsManager, reviewManager *models.ReviewManager, startTime time.Time) *CommandFactory {
+func NewCommandFactory(metrics *models.Metrics, historyManager *models.UserHistoryManager, messageIDManager *models.MessageIDManager, statsManager *models.StatsManager, reviewManager *models.ReviewManager, privacyManager *models.PrivacyManager, warningManager *models.WarningManager, greetingManager *models.GreetingManager, reminderManager *models.ReminderManager, karmaManager *models.KarmaManager, quoteManager *models.QuoteManager, quizManager *models.QuizManager, dailyManager *models.DailyWinnerManager, settingsManager *models.SettingsManager, callbackManager *models.CallbackManager, backupManager *storage.BackupManager, transcriber utils.Transcriber, startTime time.Time) *CommandFactory {
 	factory := &CommandFactory{
 		commands:         make(map[string]commands.Command),
 		metrics:           metrics,
@@ -28,6 +54,21 @@ func NewCommandFactory(metrics *models.Metrics, historyManager *models.UserHisto
 		messageIDManager:  messageIDManager,
 		statsManager:      statsManager,
 		reviewManager:     reviewManager,
+		privacyManager:    privacyManager,
+		warningManager:    warningManager,
+		greetingManager:   greetingManager,
+		reminderManager:   reminderManager,
+		karmaManager:      karmaManager,
+		quoteManager:      quoteManager,
+		quizManager:       quizManager,
+		dailyManager:      dailyManager,
+		settingsManager:   settingsManager,
+		backupManager:     backupManager,
+		transcriber:       transcriber,
+		callbackRouter:    callbacks.NewRouter(callbackManager),
+		greeter:           commands.NewGreeter(greetingManager),
+		adminManager:      utils.NewAdminManager(),
+		cooldowns:         utils.NewCooldownTracker(),
 	}
 	
 	// Register all commands
@@ -42,7 +83,7 @@ func (f *CommandFactory) registerCommands(startTime time.Time) {
 	f.Register(commands.NewStartCommand())
 	
 	// Register AI command
-	aiCommand, err := commands.NewAICommand(f.historyManager, f.messageIDManager)
+	aiCommand, err := commands.NewAICommand(f.historyManager, f.messageIDManager, f.settingsManager, f.callbackRouter)
 	if err != nil {
 		// Log error but don't fail - AI is optional
 		fmt.Printf("Warning: Failed to initialize AI command: %v\n", err)
@@ -53,12 +94,12 @@ func (f *CommandFactory) registerCommands(startTime time.Time) {
 	}
 	
 	// Register stats command
-	statsCommand := commands.NewStatsCommand(f.statsManager)
+	statsCommand := commands.NewStatsCommand(f.statsManager, f.settingsManager)
 	f.Register(statsCommand)
 	fmt.Printf("Stats command registered successfully\n")
 	
 	// Register review command
-	reviewCommand, err := commands.NewReviewCommand(f.reviewManager, f.statsManager)
+	reviewCommand, err := commands.NewReviewCommand(f.reviewManager, f.statsManager, f.settingsManager)
 	if err != nil {
 		// Log error but don't fail - Review is optional
 		fmt.Printf("Warning: Failed to initialize review command: %v\n", err)
@@ -67,20 +108,138 @@ func (f *CommandFactory) registerCommands(startTime time.Time) {
 		f.Register(reviewCommand)
 		fmt.Printf("Review command registered successfully\n")
 	}
+	
+	// Register privacy command
+	privacyCommand := commands.NewPrivacyCommand(f.privacyManager, f.statsManager, f.reviewManager, f.messageIDManager, f.historyManager, f.quoteManager, f.karmaManager, f.quizManager, f.dailyManager)
+	f.Register(privacyCommand)
+	fmt.Printf("Privacy command registered successfully\n")
+	
+	// Register backup command
+	f.Register(commands.NewBackupCommand(f.backupManager))
+	fmt.Printf("Backup command registered successfully\n")
+	
+	// Register settings command
+	f.Register(commands.NewSettingsCommand(f.settingsManager, f.callbackRouter))
+	fmt.Printf("Settings command registered successfully\n")
+	
+	// Register moderation commands
+	f.Register(commands.NewWarnCommand(f.warningManager, f.statsManager, f.settingsManager))
+	f.Register(commands.NewMuteCommand(f.warningManager, f.statsManager, f.settingsManager))
+	f.Register(commands.NewBanCommand(f.warningManager, f.statsManager, f.settingsManager))
+	f.Register(commands.NewUnbanCommand(f.warningManager, f.statsManager, f.settingsManager))
+	f.Register(commands.NewWarningsCommand(f.warningManager, f.statsManager, f.settingsManager))
+	fmt.Printf("Moderation commands registered successfully\n")
+	
+	// Register greeting command
+	f.Register(commands.NewGreetingCommand(f.greetingManager, f.greeter))
+	fmt.Printf("Greeting command registered successfully\n")
+	
+	// Register remind command
+	f.Register(commands.NewRemindCommand(f.reminderManager, f.settingsManager))
+	fmt.Printf("Remind command registered successfully\n")
+	
+	// Register karma command
+	f.Register(commands.NewKarmaCommand(f.karmaManager, f.settingsManager))
+	fmt.Printf("Karma command registered successfully\n")
+	
+	// Register quote commands
+	f.Register(commands.NewQuoteCommand(f.quoteManager, f.reviewManager, f.privacyManager, f.settingsManager))
+	f.Register(commands.NewQuotesCommand(f.quoteManager, f.settingsManager))
+	fmt.Printf("Quote commands registered successfully\n")
+	
+	// Register quiz command
+	quizGame, err := commands.NewQuizGame(f.quizManager, f.privacyManager)
+	if err != nil {
+		// Log error but don't fail - quiz questions need AI
+		fmt.Printf("Warning: Failed to initialize quiz: %v\n", err)
+		fmt.Printf("Quiz command will not be available. Please set ZAI_AUTH_TOKEN in .env\n")
+	} else {
+		f.quizGame = quizGame
+		f.Register(commands.NewQuizCommand(quizGame, f.quizManager, f.settingsManager))
+		fmt.Printf("Quiz command registered successfully\n")
+	}
+	
+	// Register user of the day command
+	f.Register(commands.NewDailyCommand(f.dailyManager, f.statsManager, f.privacyManager, f.settingsManager))
+	fmt.Printf("Daily command registered successfully\n")
+	
+	// Register transcribe command
+	if f.transcriber != nil {
+		f.Register(commands.NewTranscribeCommand(f.transcriber))
+		fmt.Printf("Transcribe command registered successfully\n")
+	} else {
+		fmt.Printf("Transcribe command will not be available. Please set TRANSCRIBE_URL in .env\n")
+	}
+	
+	// Register help command
+	f.Register(commands.NewHelpCommand(f.GetCommands))
+	fmt.Printf("Help command registered successfully\n")
 }
 
-// Register adds a command to the factory
+// Register adds a command to the factory under its name and aliases
 func (f *CommandFactory) Register(cmd commands.Command) {
-	f.commands[cmd.Name()] = cmd
+	f.ordered = append(f.ordered, cmd)
+	
+	for _, name := range append([]string{cmd.Name()}, cmd.Aliases()...) {
+		key := commands.NormalizeName(name)
+		if existing, ok := f.commands[key]; ok {
+			fmt.Printf("[-] Command name %s of %s is already taken by %s\n", name, cmd.Name(), existing.Name())
+			continue
+		}
+		f.commands[key] = cmd
+	}
 }
 
-// Get retrieves a command by name
+// Get retrieves a command by name or alias, with or without prefix
 func (f *CommandFactory) Get(name string) commands.Command {
-	return f.commands[name]
+	return f.commands[commands.NormalizeName(name)]
+}
+
+// GetCommands returns all registered commands in registration order
+func (f *CommandFactory) GetCommands() []commands.Command {
+	return f.ordered
+}
+
+// Route executes the command in the message text, if any.
+// Returns false when the text isn't a known command so it can be handled as a regular message.
+func (f *CommandFactory) Route(c telebot.Context) (bool, error) {
+	fields := strings.Fields(c.Text())
+	if len(fields) == 0 || !strings.ContainsRune(commands.CommandPrefixes, rune(fields[0][0])) {
+		return false, nil
+	}
+	
+	cmd := f.Get(fields[0])
+	if cmd == nil {
+		return false, nil
+	}
+	
+	args, err := commands.ParseArgs(cmd.Args(), fields[1:])
+	if err != nil {
+		fmt.Printf("[-] Invalid arguments for %s: %v\n", cmd.Name(), err)
+		return true, c.Send(fmt.Sprintf("❌ %s\nИспользование: <code>%s</code>",
+			html.EscapeString(err.Error()), html.EscapeString(cmd.Usage())), &telebot.SendOptions{
+			ParseMode: telebot.ModeHTML,
+			ReplyTo:   c.Message(),
+		})
+	}
+	commands.SetArgs(c, args)
+	
+	return true, f.Execute(cmd.Name(), c)
 }
 
 // Execute executes a command
 func (f *CommandFactory) Execute(cmdName string, c telebot.Context) error {
+	return f.execute(cmdName, c, true)
+}
+
+// Trigger executes a command invoked implicitly, like the AI on a trigger word.
+// A cooldown silently skips it instead of answering a regular chat message with a notice.
+func (f *CommandFactory) Trigger(cmdName string, c telebot.Context) error {
+	return f.execute(cmdName, c, false)
+}
+
+// execute runs a command after access and cooldown checks
+func (f *CommandFactory) execute(cmdName string, c telebot.Context, explicit bool) error {
 	fmt.Printf("[i] Factory executing command: %s\n", cmdName)
 	cmd := f.Get(cmdName)
 	if cmd == nil {
@@ -96,19 +255,159 @@ func (f *CommandFactory) Execute(cmdName string, c telebot.Context) error {
 		return nil // Ignore private-only commands in groups
 	}
 	
+	// Check required role
+	if !f.hasRole(c, cmd.RequiredRole()) {
+		fmt.Printf("[-] User %d lacks role for %s\n", c.Sender().ID, cmdName)
+		return f.reply(c, roleDeniedMessage(cmd.RequiredRole()))
+	}
+	
+	// Check cooldowns, bot owners aren't limited
+	if !f.adminManager.IsBotAdmin(c.Sender().ID) {
+		if wait := f.cooldownRemaining(c, cmd); wait > 0 {
+			fmt.Printf("[-] Command %s on cooldown for user %d in chat %d: %v\n", cmdName, c.Sender().ID, c.Chat().ID, wait)
+			if !explicit {
+				return nil
+			}
+			return f.reply(c, fmt.Sprintf("⏳ Не так быстро! Подожди ещё %s", formatWait(wait)))
+		}
+		f.touchCooldowns(c, cmd)
+	}
+	
 	fmt.Printf("[i] Executing command: %s\n", cmdName)
 	return cmd.Execute(c, f.metrics)
 }
 
+// hasRole checks if the sender has the required role in the current chat
+func (f *CommandFactory) hasRole(c telebot.Context, role commands.Role) bool {
+	switch role {
+	case commands.RoleBotOwner:
+		return f.adminManager.IsBotAdmin(c.Sender().ID)
+	case commands.RoleChatAdmin:
+		// Everyone manages their own private chat
+		if c.Chat().Type == telebot.ChatPrivate {
+			return true
+		}
+		return f.adminManager.IsAdmin(c)
+	}
+	return true
+}
+
+// cooldownRemaining returns the longest remaining cooldown of a command for the sender and chat
+func (f *CommandFactory) cooldownRemaining(c telebot.Context, cmd commands.Command) time.Duration {
+	cooldown := cmd.Cooldown()
+	userKey, chatKey := cooldownKeys(c, cmd)
+	
+	return max(f.cooldowns.Remaining(userKey, cooldown.PerUser), f.cooldowns.Remaining(chatKey, cooldown.PerChat))
+}
+
+// touchCooldowns starts command cooldowns for the sender and chat
+func (f *CommandFactory) touchCooldowns(c telebot.Context, cmd commands.Command) {
+	cooldown := cmd.Cooldown()
+	userKey, chatKey := cooldownKeys(c, cmd)
+	
+	if cooldown.PerUser > 0 {
+		f.cooldowns.Touch(userKey)
+	}
+	if cooldown.PerChat > 0 {
+		f.cooldowns.Touch(chatKey)
+	}
+}
+
+// cooldownKeys returns tracker keys of per-user and per-chat cooldowns
+func cooldownKeys(c telebot.Context, cmd commands.Command) (userKey, chatKey string) {
+	return fmt.Sprintf("%s:user:%d", cmd.Name(), c.Sender().ID), fmt.Sprintf("%s:chat:%d", cmd.Name(), c.Chat().ID)
+}
+
+// reply sends a short reply to the command message
+func (f *CommandFactory) reply(c telebot.Context, text string) error {
+	return c.Send(text, &telebot.SendOptions{ReplyTo: c.Message()})
+}
+
+// roleDeniedMessage returns the reply for users without the required role
+func roleDeniedMessage(role commands.Role) string {
+	if role == commands.RoleBotOwner {
+		return "❌ Команда доступна только владельцу бота"
+	}
+	return "❌ Команда доступна только администраторам чата"
+}
+
+// formatWait formats remaining cooldown time, rounding up
+func formatWait(d time.Duration) string {
+	seconds := int((d + time.Second - 1) / time.Second)
+	if seconds < 90 {
+		return fmt.Sprintf("%d сек.", seconds)
+	}
+	return fmt.Sprintf("%d мин.", (seconds+59)/60)
+}
+
+// GetCallbackRouter returns the inline button router
+func (f *CommandFactory) GetCallbackRouter() *callbacks.Router {
+	return f.callbackRouter
+}
+
+// GetGreeter returns the welcome and goodbye sender
+func (f *CommandFactory) GetGreeter() *commands.Greeter {
+	return f.greeter
+}
+
+// GetQuizGame returns the quiz game, nil when AI isn't configured
+func (f *CommandFactory) GetQuizGame() *commands.QuizGame {
+	return f.quizGame
+}
+
 // GetAllCommands returns all registered command names
 func (f *CommandFactory) GetAllCommands() []string {
 	var names []string
-	for name := range f.commands {
-		names = append(names, name)
+	for _, cmd := range f.ordered {
+		names = append(names, cmd.Name())
 	}
 	return names
 }
 
+// SetCommands publishes the command menu to Telegram.
+// Telegram only accepts latin names, so each command is listed under its first latin alias.
+func (f *CommandFactory) SetCommands(bot *telebot.Bot) error {
+	var groupCommands, privateCommands []telebot.Command
+	
+	for _, cmd := range f.ordered {
+		if cmd.Description() == "" {
+			continue
+		}
+		
+		name := telegramCommandName(cmd)
+		if name == "" {
+			continue
+		}
+		
+		command := telebot.Command{Text: name, Description: cmd.Description()}
+		privateCommands = append(privateCommands, command)
+		if !cmd.IsPrivateOnly() {
+			groupCommands = append(groupCommands, command)
+		}
+	}
+	
+	if err := bot.SetCommands(groupCommands, telebot.CommandScope{Type: telebot.CommandScopeDefault}); err != nil {
+		return fmt.Errorf("failed to set default commands: %w", err)
+	}
+	if err := bot.SetCommands(privateCommands, telebot.CommandScope{Type: telebot.CommandScopeAllPrivateChats}); err != nil {
+		return fmt.Errorf("failed to set private chat commands: %w", err)
+	}
+	
+	fmt.Printf("[+] Published %d commands to Telegram\n", len(privateCommands))
+	return nil
+}
+
+// telegramCommandName returns the first name of a command Telegram accepts
+func telegramCommandName(cmd commands.Command) string {
+	for _, name := range append([]string{cmd.Name()}, cmd.Aliases()...) {
+		name = commands.NormalizeName(name)
+		if telegramCommandRx.MatchString(name) {
+			return name
+		}
+	}
+	return ""
+}
+
 // GetMessageIDManager returns the message ID manager
 func (f *CommandFactory) GetMessageIDManager() *models.MessageIDManager {
 	return f.messageIDManager
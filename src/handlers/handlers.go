@@ -6,35 +6,13 @@ import (
 	"time"
 
 	"gopkg.in/telebot.v3"
+	"gobrev/src/handlers/commands"
 	"gobrev/src/handlers/factory"
 	"gobrev/src/models"
+	"gobrev/src/storage"
+	"gobrev/src/utils"
 )
 
-// containsBrev checks if text contains "брев" in any form (case insensitive)
-func containsBrev(text string) bool {
-	text = strings.ToLower(text)
-	
-	// Check for various forms of "брев"
-	brevVariants := []string{
-		"брев",     // брев
-		"брева",    // брева
-		"бреве",    // бреве
-		"бревом",   // бревом
-		"бревец",   // бревец
-		"бревик",   // бревик
-		"бревочка", // бревочка
-		"бревоныш", // бревоныш
-	}
-	
-	for _, variant := range brevVariants {
-		if strings.Contains(text, variant) {
-			return true
-		}
-	}
-	
-	return false
-}
-
 // isReplyToBot checks if the message is a reply to bot's AI message
 func isReplyToBot(c telebot.Context, messageIDManager *models.MessageIDManager) bool {
 	// Check if message is a reply
@@ -50,73 +28,181 @@ func isReplyToBot(c telebot.Context, messageIDManager *models.MessageIDManager)
 	return messageIDManager.IsAIMessage(messageID)
 }
 
+// isDirectReplyToBot checks if the message replies to any message of the bot
+func isDirectReplyToBot(c telebot.Context) bool {
+	reply := c.Message().ReplyTo
+	return reply != nil && reply.Sender != nil && reply.Sender.ID == c.Bot().Me.ID
+}
+
 // SetupHandlers registers all command handlers using command factory
-func SetupHandlers(bot *telebot.Bot, metrics *models.Metrics, historyManager *models.UserHistoryManager, messageIDManager *models.MessageIDManager, statsManager *models.StatsManager, reviewManager *models.ReviewManager, startTime time.Time) {
+func SetupHandlers(bot *telebot.Bot, metrics *models.Metrics, historyManager *models.UserHistoryManager, messageIDManager *models.MessageIDManager, statsManager *models.StatsManager, reviewManager *models.ReviewManager, privacyManager *models.PrivacyManager, warningManager *models.WarningManager, captchaManager *models.CaptchaManager, greetingManager *models.GreetingManager, reminderManager *models.ReminderManager, karmaManager *models.KarmaManager, quoteManager *models.QuoteManager, quizManager *models.QuizManager, dailyManager *models.DailyWinnerManager, settingsManager *models.SettingsManager, callbackManager *models.CallbackManager, backupManager *storage.BackupManager, transcriber utils.Transcriber, startTime time.Time) {
 	// Create command factory
-	cmdFactory := factory.NewCommandFactory(metrics, historyManager, messageIDManager, statsManager, reviewManager, startTime)
+	cmdFactory := factory.NewCommandFactory(metrics, historyManager, messageIDManager, statsManager, reviewManager, privacyManager, warningManager, greetingManager, reminderManager, karmaManager, quoteManager, quizManager, dailyManager, settingsManager, callbackManager, backupManager, transcriber, startTime)
 	
-	// Register each command individually
-	bot.Handle("/start", func(c telebot.Context) error {
-		return cmdFactory.Execute("/start", c)
+	// Captcha for new members, registered before the router starts routing its buttons
+	greeter := cmdFactory.GetGreeter()
+	captchaHandler := NewCaptchaHandler(bot, captchaManager, settingsManager, cmdFactory.GetCallbackRouter())
+	captchaHandler.OnPass(func(chat *telebot.Chat, user *telebot.User) {
+		greeter.Welcome(bot, chat, user)
 	})
+	captchaHandler.Restore()
 	
-	// Register stats command
-	bot.Handle(".стат", func(c telebot.Context) error {
-		return cmdFactory.Execute(".стат", c)
-	})
+	// Karma votes are replies, so they are picked up with message statistics
+	karmaVoter := NewKarmaVoter(karmaManager, privacyManager)
 	
-	// Register review command
-	bot.Handle(".рев", func(c telebot.Context) error {
-		return cmdFactory.Execute(".рев", c)
-	})
+	// Route inline button presses
+	cmdFactory.GetCallbackRouter().Register(bot)
+	
+	// Publish command menu
+	if err := cmdFactory.SetCommands(bot); err != nil {
+		fmt.Printf("[-] Failed to publish commands: %v\n", err)
+	}
 	
 	// Register AI command with text handler
 	bot.Handle(telebot.OnText, func(c telebot.Context) error {
-		text := c.Text()
+		// Route commands with any prefix and their aliases
+		if handled, err := cmdFactory.Route(c); handled {
+			return err
+		}
 		
 		// Process message for statistics (always)
-		processMessageForStats(c, statsManager, reviewManager)
+		processMessageForStats(c, statsManager, reviewManager, privacyManager, karmaVoter)
 		
-		// Check if message contains "брев" in any form
-		if containsBrev(text) {
-			fmt.Printf("[i] Брев detected in text: %s\n", text)
-			err := cmdFactory.Execute(".ии", c)
-			if err != nil {
-				fmt.Printf("[-] AI command failed: %v\n", err)
+		return triggerAI(c, c.Text(), cmdFactory, settingsManager)
+	})
+	
+	// Photos with a trigger word in the caption or replying to the bot go to AI as images
+	bot.Handle(telebot.OnPhoto, func(c telebot.Context) error {
+		processMessageForStats(c, statsManager, reviewManager, privacyManager, karmaVoter)
+		return triggerAI(c, c.Text(), cmdFactory, settingsManager)
+	})
+	
+	// New members must solve a captcha if the chat enabled it, the others are welcomed right away
+	bot.Handle(telebot.OnUserJoined, func(c telebot.Context) error {
+		for _, user := range joinedUsers(c.Message()) {
+			if !captchaHandler.Challenge(c, user) {
+				greeter.Welcome(c.Bot(), c.Chat(), user)
 			}
-			return err
+		}
+		return nil
+	})
+	
+	bot.Handle(telebot.OnUserLeft, func(c telebot.Context) error {
+		user := c.Message().UserLeft
+		if user == nil {
+			return nil
 		}
 		
-		// Check if this is a reply to bot's message
-		if isReplyToBot(c, cmdFactory.GetMessageIDManager()) {
-			fmt.Printf("[i] Reply to bot detected: %s\n", text)
-			err := cmdFactory.Execute(".ии", c)
+		// Newcomers who left before solving the captcha weren't welcomed either.
+		// Kicked and banned members are removed by someone else and get no goodbye.
+		if captchaHandler.HandleLeave(c.Chat().ID, user) || c.Sender() == nil || c.Sender().ID != user.ID {
+			return nil
+		}
+		return greeter.Goodbye(c.Bot(), c.Chat(), user)
+	})
+	
+	// Answers to quiz polls score points in the running game
+	if quizGame := cmdFactory.GetQuizGame(); quizGame != nil {
+		bot.Handle(telebot.OnPollAnswer, func(c telebot.Context) error {
+			quizGame.HandleAnswer(c.PollAnswer())
+			return nil
+		})
+	}
+	
+	// Voice messages are transcribed and treated like text
+	if transcriber != nil {
+		bot.Handle(telebot.OnVoice, func(c telebot.Context) error {
+			if c.Sender().IsBot {
+				return nil
+			}
+			
+			// Audio of opted-out members goes to the transcription service only when they talk to the bot
+			if privacyManager.IsOptedOut(c.Chat().ID, c.Sender().ID) && !isDirectReplyToBot(c) {
+				return nil
+			}
+			
+			text, err := utils.TranscribeFile(c.Bot(), transcriber, &c.Message().Voice.File, "voice.ogg")
 			if err != nil {
-				fmt.Printf("[-] AI command failed: %v\n", err)
+				fmt.Printf("[-] Voice transcription failed: %v\n", err)
+				return nil
 			}
-			return err
+			if text == "" {
+				return nil
+			}
+			fmt.Printf("[+] Voice message transcribed in chat %d (%d chars)\n", c.Chat().ID, len(text))
+			
+			recordMessage(c, "🎤 "+text, statsManager, reviewManager, privacyManager)
+			
+			// The transcript becomes the question if the AI is triggered
+			if aiCommand := cmdFactory.Get(".ии"); aiCommand != nil {
+				if args, err := commands.ParseArgs(aiCommand.Args(), strings.Fields(text)); err == nil {
+					commands.SetArgs(c, args)
+				}
+			}
+			return triggerAI(c, text, cmdFactory, settingsManager)
+		})
+	}
+}
+
+// joinedUsers returns members added by the message, one or many
+func joinedUsers(msg *telebot.Message) []*telebot.User {
+	var users []*telebot.User
+	for i := range msg.UsersJoined {
+		users = append(users, &msg.UsersJoined[i])
+	}
+	if len(users) == 0 && msg.UserJoined != nil {
+		users = append(users, msg.UserJoined)
+	}
+	return users
+}
+
+// triggerAI runs the AI command when text has a trigger word or the message replies to the bot
+func triggerAI(c telebot.Context, text string, cmdFactory *factory.CommandFactory, settingsManager *models.SettingsManager) error {
+	// Check if message contains a trigger word of this chat ("брев" by default)
+	if settingsManager.Get(c.Chat().ID).ContainsTrigger(text) {
+		fmt.Printf("[i] Trigger word detected in text: %s\n", text)
+		err := cmdFactory.Trigger(".ии", c)
+		if err != nil {
+			fmt.Printf("[-] AI command failed: %v\n", err)
 		}
-		
-		// Ignore other messages
-		return nil
-	})
+		return err
+	}
+	
+	// Check if this is a reply to bot's message
+	if isReplyToBot(c, cmdFactory.GetMessageIDManager()) {
+		fmt.Printf("[i] Reply to bot detected: %s\n", text)
+		err := cmdFactory.Trigger(".ии", c)
+		if err != nil {
+			fmt.Printf("[-] AI command failed: %v\n", err)
+		}
+		return err
+	}
+	
+	// Ignore other messages
+	return nil
 }
 
-// processMessageForStats processes a message for statistics and review
-func processMessageForStats(c telebot.Context, statsManager *models.StatsManager, reviewManager *models.ReviewManager) {
+// processMessageForStats processes a message for statistics, review and karma votes
+func processMessageForStats(c telebot.Context, statsManager *models.StatsManager, reviewManager *models.ReviewManager, privacyManager *models.PrivacyManager, karmaVoter *KarmaVoter) {
 	// Only process text messages
 	if c.Text() == "" {
 		return
 	}
 	
-	// Skip bot messages
-	if c.Sender().IsBot {
+	// Skip commands, including mistyped ones
+	text := strings.TrimSpace(c.Text())
+	if text == "" || strings.ContainsRune(commands.CommandPrefixes, rune(text[0])) {
 		return
 	}
 	
-	// Skip commands
-	text := strings.TrimSpace(c.Text())
-	if strings.HasPrefix(text, "/") || strings.HasPrefix(text, ".") {
+	recordMessage(c, text, statsManager, reviewManager, privacyManager)
+	karmaVoter.HandleVote(c, text)
+}
+
+// recordMessage adds message text (or a voice transcript) to statistics and review
+func recordMessage(c telebot.Context, text string, statsManager *models.StatsManager, reviewManager *models.ReviewManager, privacyManager *models.PrivacyManager) {
+	// Skip bot messages
+	if c.Sender().IsBot {
 		return
 	}
 	
@@ -125,6 +211,11 @@ func processMessageForStats(c telebot.Context, statsManager *models.StatsManager
 	chatID := c.Chat().ID
 	userID := user.ID
 	
+	// Respect user opt-out before writing anything
+	if privacyManager.IsOptedOut(chatID, userID) {
+		return
+	}
+	
 	// Build username
 	username := user.FirstName
 	if user.LastName != "" {
@@ -138,7 +229,7 @@ func processMessageForStats(c telebot.Context, statsManager *models.StatsManager
 	}
 	
 	// Add message to statistics
-	err := statsManager.AddMessage(chatID, userID, username, text)
+	err := statsManager.AddMessage(chatID, userID, username, user.Username, text)
 	if err != nil {
 		fmt.Printf("[-] Failed to add message to stats: %v\n", err)
 		// Don't return error to avoid breaking the bot
@@ -146,12 +237,14 @@ func processMessageForStats(c telebot.Context, statsManager *models.StatsManager
 	
 	// Extract reply information
 	var replyToMessageID, replyToUsername, replyToContent string
+	var replyToUserID int64
 	if c.Message().ReplyTo != nil {
 		replyMsg := c.Message().ReplyTo
 		replyToMessageID = fmt.Sprintf("%d", replyMsg.ID)
 		
 		// Get reply author username
 		if replyMsg.Sender != nil {
+			replyToUserID = replyMsg.Sender.ID
 			replyToUsername = replyMsg.Sender.FirstName
 			if replyMsg.Sender.LastName != "" {
 				replyToUsername += " " + replyMsg.Sender.LastName
@@ -164,15 +257,18 @@ func processMessageForStats(c telebot.Context, statsManager *models.StatsManager
 			}
 		}
 		
-		// Get reply content (truncate if too long)
+		// Get reply content (truncate if too long), unless its author opted out
 		replyToContent = replyMsg.Text
+		if replyToUserID != 0 && privacyManager.IsOptedOut(chatID, replyToUserID) {
+			replyToContent = ""
+		}
 		if len(replyToContent) > 100 {
 			replyToContent = replyToContent[:100] + "..."
 		}
 	}
 
 	// Add message to review manager
-	err = reviewManager.AddMessage(chatID, userID, username, text, replyToMessageID, replyToUsername, replyToContent)
+	err = reviewManager.AddMessage(chatID, userID, c.Message().ID, username, text, replyToMessageID, replyToUsername, replyToContent, replyToUserID)
 	if err != nil {
 		fmt.Printf("[-] Failed to add message to review: %v\n", err)
 		// Don't return error to avoid breaking the bot
@@ -0,0 +1,262 @@
+package handlers
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"gopkg.in/telebot.v3"
+	"gobrev/src/handlers/commands"
+	"gobrev/src/models"
+	"gobrev/src/utils"
+)
+
+const (
+	// inlineMinQueryLength skips half-typed queries
+	inlineMinQueryLength = 3
+	// inlineDebounce is how long to wait for the user to stop typing
+	inlineDebounce = 700 * time.Millisecond
+	// inlineDescriptionLength is the preview length shown in the results list
+	inlineDescriptionLength = 100
+	// inlineMemberTTL is how long a membership check of a user is reused
+	inlineMemberTTL = 10 * time.Minute
+)
+
+// InlineConfig holds inline mode settings
+type InlineConfig struct {
+	MaxTokens    int           // Token budget of inline answers
+	CacheTTL     time.Duration // How long answers are reused for the same query
+	Cooldown     time.Duration // Minimum time between AI requests of one user
+	AllowedUsers []int64       // Users allowed besides members of chats with the bot
+}
+
+// inlineAnswer is a cached AI answer
+type inlineAnswer struct {
+	text      string
+	expiresAt time.Time
+}
+
+// inlineMember is a cached membership check
+type inlineMember struct {
+	known     bool
+	expiresAt time.Time
+}
+
+// InlineHandler answers inline queries (@bot question) with AI.
+// Only members of chats with the bot and allowed users get answers, so strangers can't spend the AI quota.
+type InlineHandler struct {
+	aiClient     *utils.AIClient
+	statsManager *models.StatsManager
+	config       InlineConfig
+	cooldowns    *utils.CooldownTracker
+
+	mu      sync.Mutex
+	cache   map[string]inlineAnswer
+	latest  map[int64]string // Latest query ID per user, for debouncing
+	members map[int64]inlineMember
+}
+
+// SetupInlineMode registers the inline query handler.
+// Inline mode also has to be enabled for the bot in BotFather (/setinline).
+func SetupInlineMode(bot *telebot.Bot, statsManager *models.StatsManager, config InlineConfig) {
+	aiClient, err := utils.NewAIClient()
+	if err != nil {
+		fmt.Printf("[-] Inline mode disabled: %v\n", err)
+		return
+	}
+
+	handler := &InlineHandler{
+		aiClient:     aiClient,
+		statsManager: statsManager,
+		config:       config,
+		cooldowns:    utils.NewCooldownTracker(),
+		cache:        make(map[string]inlineAnswer),
+		latest:       make(map[int64]string),
+		members:      make(map[int64]inlineMember),
+	}
+
+	bot.Handle(telebot.OnQuery, handler.Handle)
+	fmt.Printf("[+] Inline mode enabled (max %d tokens, cache %v, cooldown %v, %d allowed users)\n",
+		config.MaxTokens, config.CacheTTL, config.Cooldown, len(config.AllowedUsers))
+}
+
+// Handle answers a single inline query
+func (h *InlineHandler) Handle(c telebot.Context) error {
+	query := c.Query()
+	text := strings.TrimSpace(query.Text)
+	key := strings.ToLower(text)
+	userID := query.Sender.ID
+
+	if !h.allowed(userID) {
+		return h.hint(c, "Брев отвечает только участникам своих чатов")
+	}
+
+	if utf8.RuneCountInString(text) < inlineMinQueryLength {
+		return c.Answer(&telebot.QueryResponse{
+			Results:           telebot.Results{},
+			CacheTime:         1,
+			SwitchPMText:      "Напиши вопрос после @" + c.Bot().Me.Username,
+			SwitchPMParameter: "inline",
+		})
+	}
+
+	if answer, ok := h.cached(key); ok {
+		return h.answer(c, text, answer)
+	}
+
+	// Telegram sends a query on every keystroke: wait and answer only the last one
+	h.mu.Lock()
+	h.latest[userID] = query.ID
+	h.mu.Unlock()
+
+	time.Sleep(inlineDebounce)
+
+	h.mu.Lock()
+	superseded := h.latest[userID] != query.ID
+	if !superseded {
+		delete(h.latest, userID)
+	}
+	h.mu.Unlock()
+	if superseded {
+		return nil
+	}
+
+	cooldownKey := strconv.FormatInt(userID, 10)
+	if wait := h.cooldowns.Remaining(cooldownKey, h.config.Cooldown); wait > 0 {
+		return h.hint(c, fmt.Sprintf("⏳ Подожди ещё %d сек.", int(wait.Seconds())+1))
+	}
+	h.cooldowns.Touch(cooldownKey)
+
+	fmt.Printf("[i] Inline AI request from user %d: %s\n", userID, text)
+	response, err := h.aiClient.Chat([]utils.ChatMessage{
+		{Role: "system", Content: commands.AISystemPrompt + "\n\nОтвечай коротко: не больше нескольких предложений."},
+		{Role: "user", Content: text},
+	}, utils.WithMaxTokens(h.config.MaxTokens))
+	if err != nil {
+		fmt.Printf("[-] Inline AI request failed: %v\n", err)
+		return c.Answer(&telebot.QueryResponse{
+			Results:   telebot.Results{h.article("error", "❌ Ошибка ИИ", err.Error(), "❌ Брев сейчас не отвечает")},
+			CacheTime: 1,
+		})
+	}
+	if len(response.Choices) == 0 || strings.TrimSpace(response.Choices[0].Message.Content) == "" {
+		return c.Answer(&telebot.QueryResponse{Results: telebot.Results{}, CacheTime: 1})
+	}
+
+	answer := strings.TrimSpace(response.Choices[0].Message.Content)
+	h.store(key, answer)
+
+	return h.answer(c, text, answer)
+}
+
+// allowed checks whether a user may use inline mode: listed users and members of chats with the bot
+func (h *InlineHandler) allowed(userID int64) bool {
+	for _, id := range h.config.AllowedUsers {
+		if id == userID {
+			return true
+		}
+	}
+
+	h.mu.Lock()
+	member, ok := h.members[userID]
+	h.mu.Unlock()
+	if ok && time.Now().Before(member.expiresAt) {
+		return member.known
+	}
+
+	known, err := h.statsManager.IsKnownUser(userID)
+	if err != nil {
+		fmt.Printf("[-] Failed to check inline user %d: %v\n", userID, err)
+		return false
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	now := time.Now()
+	for id, member := range h.members {
+		if now.After(member.expiresAt) {
+			delete(h.members, id)
+		}
+	}
+	h.members[userID] = inlineMember{known: known, expiresAt: now.Add(inlineMemberTTL)}
+
+	if !known {
+		fmt.Printf("[i] Inline query from unknown user %d ignored\n", userID)
+	}
+	return known
+}
+
+// hint answers with no results and a short note above the results list
+func (h *InlineHandler) hint(c telebot.Context, text string) error {
+	return c.Answer(&telebot.QueryResponse{
+		Results:           telebot.Results{},
+		CacheTime:         1,
+		IsPersonal:        true,
+		SwitchPMText:      text,
+		SwitchPMParameter: "inline",
+	})
+}
+
+// answer sends the AI answer as a single article result
+func (h *InlineHandler) answer(c telebot.Context, question, answer string) error {
+	description := answer
+	if utf8.RuneCountInString(description) > inlineDescriptionLength {
+		description = string([]rune(description)[:inlineDescriptionLength]) + "…"
+	}
+
+	hash := fnv.New64a()
+	hash.Write([]byte(answer))
+	result := h.article(strconv.FormatUint(hash.Sum64(), 36), "🤖 Ответ Брева", description, fmt.Sprintf("❓ %s\n\n🤖 %s", question, answer))
+
+	// Personal so Telegram doesn't serve the cached answer to users who never pass allowed() and the cooldown
+	return c.Answer(&telebot.QueryResponse{
+		Results:    telebot.Results{result},
+		CacheTime:  int(h.config.CacheTTL.Seconds()),
+		IsPersonal: true,
+	})
+}
+
+// article builds a text article result
+func (h *InlineHandler) article(id, title, description, text string) *telebot.ArticleResult {
+	result := &telebot.ArticleResult{
+		Title:       title,
+		Description: description,
+		Text:        text,
+	}
+	result.SetResultID(id)
+	return result
+}
+
+// cached returns a non-expired answer for the query
+func (h *InlineHandler) cached(key string) (string, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	answer, ok := h.cache[key]
+	if !ok || time.Now().After(answer.expiresAt) {
+		return "", false
+	}
+	return answer.text, true
+}
+
+// store caches an answer and drops expired ones
+func (h *InlineHandler) store(key, text string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	now := time.Now()
+	for k, answer := range h.cache {
+		if now.After(answer.expiresAt) {
+			delete(h.cache, k)
+		}
+	}
+
+	h.cache[key] = inlineAnswer{
+		text:      text,
+		expiresAt: now.Add(h.config.CacheTTL),
+	}
+}
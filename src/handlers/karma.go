@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"fmt"
+	"html"
+	"math"
+	"strings"
+	"time"
+
+	"gopkg.in/telebot.v3"
+	"gobrev/src/models"
+	"gobrev/src/utils"
+)
+
+// karmaVoteCooldown is how often one member may vote for the same member
+const karmaVoteCooldown = time.Hour
+
+// karmaUpWords and karmaDownWords are whole replies that count as a vote
+var (
+	karmaUpWords   = []string{"+", "++", "+1", "👍", "спасибо", "спс", "пасиб", "благодарю", "плюс"}
+	karmaDownWords = []string{"-", "--", "-1", "👎", "минус"}
+)
+
+// KarmaVoter changes karma of members whose messages get "+" or "-" replies
+type KarmaVoter struct {
+	karmaManager   *models.KarmaManager
+	privacyManager *models.PrivacyManager
+	cooldowns      *utils.CooldownTracker
+}
+
+// NewKarmaVoter creates a new karma voter
+func NewKarmaVoter(karmaManager *models.KarmaManager, privacyManager *models.PrivacyManager) *KarmaVoter {
+	return &KarmaVoter{
+		karmaManager:   karmaManager,
+		privacyManager: privacyManager,
+		cooldowns:      utils.NewCooldownTracker(),
+	}
+}
+
+// HandleVote applies a vote if text is a karma reply to another member's message
+func (kv *KarmaVoter) HandleVote(c telebot.Context, text string) {
+	up, ok := parseKarmaVote(text)
+	if !ok || c.Message().ReplyTo == nil {
+		return
+	}
+	if c.Chat().Type != telebot.ChatGroup && c.Chat().Type != telebot.ChatSuperGroup {
+		return
+	}
+
+	voter := c.Sender()
+	target := c.Message().ReplyTo.Sender
+	if voter == nil || target == nil || voter.IsBot || target.IsBot || voter.ID == target.ID {
+		return
+	}
+
+	chatID := c.Chat().ID
+	if kv.privacyManager.IsOptedOut(chatID, target.ID) {
+		return
+	}
+
+	// Thanks are often said twice in a row, only explicit +/- get a cooldown notice
+	cooldownKey := fmt.Sprintf("%d_%d_%d", chatID, voter.ID, target.ID)
+	if remaining := kv.cooldowns.Remaining(cooldownKey, karmaVoteCooldown); remaining > 0 {
+		if strings.ContainsAny(text, "+-") {
+			minutes := int(math.Ceil(remaining.Minutes()))
+			c.Reply(fmt.Sprintf("⏳ Голосовать за %s снова можно через %d мин.", html.EscapeString(target.FirstName), minutes), telebot.ModeHTML)
+		}
+		return
+	}
+
+	name := strings.TrimSpace(target.FirstName + " " + target.LastName)
+	if name == "" {
+		name = target.Username
+	}
+
+	stats, weight, err := kv.karmaManager.Vote(chatID, voter.ID, target.ID, name, up)
+	if err != nil {
+		fmt.Printf("[-] Failed to apply karma vote in chat %d: %v\n", chatID, err)
+		return
+	}
+	kv.cooldowns.Touch(cooldownKey)
+
+	fmt.Printf("[+] Karma of user %d in chat %d changed by user %d: %d\n", target.ID, chatID, voter.ID, stats.Karma)
+
+	sign, arrow := "+", "🔼"
+	if !up {
+		sign, arrow = "-", "🔽"
+	}
+	c.Reply(fmt.Sprintf("%s <b>%s</b> %s%d → карма <b>%d</b>", arrow, html.EscapeString(name), sign, weight, stats.Karma), telebot.ModeHTML)
+}
+
+// parseKarmaVote reports whether text is a vote and its direction
+func parseKarmaVote(text string) (bool, bool) {
+	// Drop skin tone modifiers of 👍 and trailing punctuation like "спасибо!"
+	text = strings.Map(func(r rune) rune {
+		if r >= 0x1F3FB && r <= 0x1F3FF {
+			return -1
+		}
+		return r
+	}, text)
+	text = strings.TrimRight(strings.ToLower(strings.TrimSpace(text)), "!.)")
+
+	for _, word := range karmaUpWords {
+		if text == word {
+			return true, true
+		}
+	}
+	for _, word := range karmaDownWords {
+		if text == word {
+			return false, true
+		}
+	}
+	return false, false
+}
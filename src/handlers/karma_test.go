@@ -0,0 +1,31 @@
+package handlers
+
+import "testing"
+
+func TestParseKarmaVote(t *testing.T) {
+	tests := []struct {
+		text   string
+		up     bool
+		isVote bool
+	}{
+		{"+", true, true},
+		{" +1 ", true, true},
+		{"Спасибо!", true, true},
+		{"спс)", true, true},
+		{"👍", true, true},
+		{"👍🏻", true, true},
+		{"-", false, true},
+		{"МИНУС", false, true},
+		{"👎🏿", false, true},
+		{"плюс плюс", false, false},
+		{"спасибо за помощь", false, false},
+		{"+-", false, false},
+		{"", false, false},
+	}
+	for _, tt := range tests {
+		up, isVote := parseKarmaVote(tt.text)
+		if up != tt.up || isVote != tt.isVote {
+			t.Errorf("parseKarmaVote(%q) = %v, %v, want %v, %v", tt.text, up, isVote, tt.up, tt.isVote)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gopkg.in/telebot.v3"
+	"gobrev/src/handlers/commands"
+	"gobrev/src/models"
+)
+
+const (
+	// reminderCheckInterval is how often due reminders are looked up
+	reminderCheckInterval = 20 * time.Second
+	// reminderRetryLimit drops one-off reminders that still can't be delivered after this delay
+	reminderRetryLimit = 24 * time.Hour
+)
+
+// ReminderScheduler delivers due reminders. Reminders missed while the bot was down are delivered on start.
+type ReminderScheduler struct {
+	bot             *telebot.Bot
+	reminderManager *models.ReminderManager
+	done            chan struct{}
+}
+
+// NewReminderScheduler creates a new reminder scheduler
+func NewReminderScheduler(bot *telebot.Bot, reminderManager *models.ReminderManager) *ReminderScheduler {
+	return &ReminderScheduler{
+		bot:             bot,
+		reminderManager: reminderManager,
+		done:            make(chan struct{}),
+	}
+}
+
+// Start delivers overdue reminders immediately and then checks on every interval until ctx is cancelled
+func (rs *ReminderScheduler) Start(ctx context.Context) {
+	go func() {
+		defer close(rs.done)
+
+		ticker := time.NewTicker(reminderCheckInterval)
+		defer ticker.Stop()
+
+		rs.deliverDue()
+		for {
+			select {
+			case <-ticker.C:
+				rs.deliverDue()
+			case <-ctx.Done():
+				fmt.Printf("[i] Reminder scheduler stopped\n")
+				return
+			}
+		}
+	}()
+
+	fmt.Printf("[+] Reminder scheduler started, interval: %v\n", reminderCheckInterval)
+}
+
+// Wait blocks until the scheduler goroutine has exited
+func (rs *ReminderScheduler) Wait() {
+	<-rs.done
+}
+
+// deliverDue sends all due reminders and reschedules recurring ones
+func (rs *ReminderScheduler) deliverDue() {
+	now := time.Now()
+	reminders, err := rs.reminderManager.Due(now)
+	if err != nil {
+		fmt.Printf("[-] Failed to load due reminders: %v\n", err)
+		return
+	}
+
+	for _, reminder := range reminders {
+		err := rs.deliver(reminder, now)
+
+		switch {
+		case err == nil:
+			fmt.Printf("[+] Reminder %s delivered in chat %d\n", reminder.ID, reminder.ChatID)
+		case isChatGone(err):
+			fmt.Printf("[-] Dropping reminder %s, chat %d is unavailable: %v\n", reminder.ID, reminder.ChatID, err)
+			rs.reminderManager.Delete(reminder.ChatID, reminder.ID)
+			continue
+		case reminder.Repeat == models.RepeatNone && now.Sub(time.Unix(reminder.At, 0)) < reminderRetryLimit:
+			// Temporary failure, retry on the next check
+			fmt.Printf("[-] Failed to deliver reminder %s: %v\n", reminder.ID, err)
+			continue
+		default:
+			fmt.Printf("[-] Failed to deliver reminder %s: %v\n", reminder.ID, err)
+		}
+
+		// Recurring reminders skip occurrences missed while the bot was down, one reminder is enough
+		if next := reminder.Next(now); !next.IsZero() {
+			reminder.At = next.Unix()
+			if err := rs.reminderManager.Save(reminder); err != nil {
+				fmt.Printf("[-] Failed to reschedule reminder %s: %v\n", reminder.ID, err)
+			}
+			continue
+		}
+		if err := rs.reminderManager.Delete(reminder.ChatID, reminder.ID); err != nil {
+			fmt.Printf("[-] Failed to delete delivered reminder %s: %v\n", reminder.ID, err)
+		}
+	}
+}
+
+// deliver sends a reminder as a reply to the message it was set on
+func (rs *ReminderScheduler) deliver(reminder models.Reminder, now time.Time) error {
+	chat := &telebot.Chat{ID: reminder.ChatID}
+	_, err := rs.bot.Send(chat, commands.FormatReminder(reminder, now.In(reminder.Location())), &telebot.SendOptions{
+		ParseMode:         telebot.ModeHTML,
+		ReplyTo:           &telebot.Message{ID: reminder.MessageID, Chat: chat},
+		AllowWithoutReply: true,
+	})
+	return err
+}
+
+// isChatGone reports errors after which the chat will never receive messages from the bot
+func isChatGone(err error) bool {
+	return errors.Is(err, telebot.ErrChatNotFound) ||
+		errors.Is(err, telebot.ErrKickedFromGroup) ||
+		errors.Is(err, telebot.ErrKickedFromSuperGroup) ||
+		errors.Is(err, telebot.ErrBlockedByUser) ||
+		errors.Is(err, telebot.ErrGroupMigrated)
+}
@@ -13,9 +13,16 @@ import (
 	"gobrev/src/handlers"
 	"gobrev/src/middleware"
 	"gobrev/src/models"
+	"gobrev/src/storage"
+	"gobrev/src/utils"
 )
 
 func main() {
+	// Handle CLI subcommands
+	if len(os.Args) > 1 {
+		os.Exit(runCLI(os.Args[1:]))
+	}
+	
 	// Load configuration
 	cfg := config.Load()
 	
@@ -25,18 +32,76 @@ func main() {
 	// Create user history manager
 	historyManager := models.NewUserHistoryManager()
 	
-	// Create message ID manager
-	messageIDManager, err := models.NewMessageIDManager("./data/message_ids")
+	// Open shared storage
+	badgerConfig, err := loadBadgerConfig(cfg)
+	if err != nil {
+		log.Fatal("Invalid storage configuration:", err)
+	}
+	
+	store, err := storage.OpenBadger(cfg.DBPath, badgerConfig)
 	if err != nil {
-		log.Fatal("Failed to create message ID manager:", err)
+		log.Fatal("Failed to open storage:", err)
+	}
+	defer store.Close()
+	
+	// Migrate stored data before any manager touches it
+	if !runMigrations(cfg, store) {
+		return
 	}
-	defer messageIDManager.Close()
 	
-	// Create stats manager (reuse the same BadgerDB instance)
-	statsManager := models.NewStatsManager(messageIDManager.GetDB())
+	// Create message ID manager
+	messageIDManager := models.NewMessageIDManager(store)
+	
+	// Create stats manager
+	statsManager := models.NewStatsManager(store)
+	
+	// Create review manager
+	reviewManager := models.NewReviewManager(store)
+	
+	// Create privacy manager
+	privacyManager := models.NewPrivacyManager(store)
+	
+	// Create moderation warning manager
+	warningManager := models.NewWarningManager(store)
+	
+	// Create pending captcha manager
+	captchaManager := models.NewCaptchaManager(store)
+	
+	// Create welcome and goodbye template manager
+	greetingManager := models.NewGreetingManager(store)
 	
-	// Create review manager (reuse the same BadgerDB instance)
-	reviewManager := models.NewReviewManager(messageIDManager.GetDB())
+	// Create reminder manager
+	reminderManager := models.NewReminderManager(store)
+	
+	// Create karma manager
+	karmaManager := models.NewKarmaManager(store)
+	
+	// Create quote book manager
+	quoteManager := models.NewQuoteManager(store)
+	
+	// Create quiz leaderboard manager
+	quizManager := models.NewQuizManager(store)
+	
+	// Create user of the day manager
+	dailyManager := models.NewDailyWinnerManager(store)
+	
+	// Create daily AI moderation budget manager
+	aiBudgetManager := models.NewAIBudgetManager(store)
+	
+	// Create chat settings manager
+	settingsManager := models.NewSettingsManager(store)
+	
+	// Create inline keyboard callback manager
+	callbackManager := models.NewCallbackManager(store)
+	
+	// Create backup manager
+	backupManager := storage.NewBackupManager(store, cfg.BackupDir, cfg.BackupInterval, cfg.BackupKeep)
+	
+	// Create speech to text provider (voice messages and .текст)
+	var transcriber utils.Transcriber
+	if cfg.TranscribeURL != "" {
+		transcriber = utils.NewWhisperTranscriber(cfg.TranscribeURL, cfg.TranscribeAPIKey, cfg.TranscribeModel, cfg.TranscribeLanguage)
+	}
 	
 	// Setup bot
 	bot, err := telebot.NewBot(telebot.Settings{
@@ -48,15 +113,46 @@ func main() {
 	}
 	
 	// Setup middleware
-	middleware.SetupMiddleware(bot, metrics)
+	aiModeration := middleware.SetupMiddleware(bot, metrics, settingsManager, aiBudgetManager, privacyManager)
 	
 	// Register handlers
-	handlers.SetupHandlers(bot, metrics, historyManager, messageIDManager, statsManager, reviewManager, cfg.StartTime)
+	handlers.SetupHandlers(bot, metrics, historyManager, messageIDManager, statsManager, reviewManager, privacyManager, warningManager, captchaManager, greetingManager, reminderManager, karmaManager, quoteManager, quizManager, dailyManager, settingsManager, callbackManager, backupManager, transcriber, cfg.StartTime)
+	
+	// Answer inline queries (@bot question)
+	handlers.SetupInlineMode(bot, statsManager, handlers.InlineConfig{
+		MaxTokens:    cfg.InlineMaxTokens,
+		CacheTTL:     time.Duration(cfg.InlineCacheMinutes) * time.Minute,
+		Cooldown:     time.Duration(cfg.InlineCooldownSeconds) * time.Second,
+		AllowedUsers: cfg.InlineAllowedUsers,
+	})
 	
 	// Setup graceful shutdown
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 	
+	// Start retention janitor
+	janitor := models.NewJanitor(store, statsManager, reviewManager, messageIDManager, callbackManager, models.JanitorConfig{
+		Interval:            cfg.JanitorInterval,
+		StatsRetentionDays:  cfg.StatsRetentionDays,
+		ReviewRetentionDays: cfg.ReviewRetentionDays,
+		MessageIDRetention:  time.Duration(cfg.MessageIDRetentionDays) * 24 * time.Hour,
+	})
+	janitor.AddCleaner("warnings", warningManager)
+	janitor.AddCleaner("ai budget", aiBudgetManager)
+	janitor.Start(ctx)
+	
+	// Start periodic backups
+	backupManager.Start(ctx)
+	
+	// Start reminder delivery, catching up on reminders missed while the bot was down
+	reminderScheduler := handlers.NewReminderScheduler(bot, reminderManager)
+	reminderScheduler.Start(ctx)
+	
+	// Start AI moderation workers
+	if aiModeration != nil {
+		aiModeration.Start(ctx)
+	}
+	
 	// Start bot in separate goroutine
 	go func() {
 		log.Printf("[+] Bot starting...")
@@ -93,9 +189,73 @@ func main() {
 	// Stop bot
 	bot.Stop()
 	
+	// Stop background workers before the database is closed
+	cancel()
+	janitor.Wait()
+	backupManager.Wait()
+	reminderScheduler.Wait()
+	if aiModeration != nil {
+		aiModeration.Wait()
+	}
+	
 	// Print final statistics
 	finalStats := metrics.GetStats()
 	log.Printf("[#] Final stats: %+v", finalStats)
 	
 	log.Println("[+] Bot stopped gracefully")
 }
+
+// loadBadgerConfig builds storage options from configuration
+func loadBadgerConfig(cfg *config.Config) (storage.BadgerConfig, error) {
+	key, err := storage.LoadEncryptionKey(cfg.DBEncryptionKey, cfg.DBEncryptionKeyFile)
+	if err != nil {
+		return storage.BadgerConfig{}, err
+	}
+	
+	if key != nil {
+		log.Printf("[+] Database encryption enabled (AES-%d)", len(key)*8)
+	}
+	
+	return storage.BadgerConfig{
+		EncryptionKey:  key,
+		IndexCacheSize: int64(cfg.DBIndexCacheMB) << 20,
+		KeyRotation:    time.Duration(cfg.DBKeyRotationDays) * 24 * time.Hour,
+	}, nil
+}
+
+// runMigrations applies pending schema migrations and reports whether the bot should start
+func runMigrations(cfg *config.Config, store storage.Store) bool {
+	migrator := storage.NewMigrator(store)
+	migrator.Register(models.Migrations()...)
+	
+	backupDir := ""
+	if cfg.BackupBeforeMigrate {
+		backupDir = cfg.BackupDir
+	}
+	
+	result, err := migrator.Run(storage.MigrateOptions{
+		DryRun:    cfg.MigrateDryRun,
+		BackupDir: backupDir,
+	})
+	if err != nil {
+		log.Fatal("Failed to migrate storage:", err)
+	}
+	
+	if result.BackupPath != "" {
+		log.Printf("[+] Backup before migration saved to %s", result.BackupPath)
+	}
+	
+	if cfg.MigrateDryRun {
+		log.Printf("[i] Migration dry run: schema v%d -> v%d, migrations %v, %d writes. Nothing was saved.",
+			result.FromVersion, result.ToVersion, result.Applied, result.Writes)
+		return false
+	}
+	
+	if len(result.Applied) > 0 {
+		log.Printf("[+] Schema migrated from v%d to v%d", result.FromVersion, result.ToVersion)
+	} else {
+		log.Printf("[=] Schema version: v%d", result.ToVersion)
+	}
+	
+	return true
+}
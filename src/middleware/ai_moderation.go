@@ -0,0 +1,421 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/telebot.v3"
+	"gobrev/src/models"
+	"gobrev/src/utils"
+)
+
+const (
+	// aiModBatchSize is how many messages of a chat are classified in one AI call
+	aiModBatchSize = 20
+	// aiModFlushInterval sends incomplete batches of quiet chats
+	aiModFlushInterval = 3 * time.Minute
+	// aiModQueueSize is how many batches may wait for the AI, newer ones are dropped
+	aiModQueueSize = 16
+	// aiModMaxTextLength clips long messages in the prompt
+	aiModMaxTextLength = 500
+	// aiModQuoteLength is the message preview length in reports
+	aiModQuoteLength = 200
+	// aiModReportLength keeps report messages under the Telegram limit of 4096 characters
+	aiModReportLength = 3500
+)
+
+// aiModCategories maps classifier categories to their names in reports
+var aiModCategories = map[string]string{
+	"spam":       "📢 Спам",
+	"scam":       "🎣 Скам-ссылка",
+	"harassment": "🤬 Травля",
+	"nsfw":       "🔞 NSFW",
+}
+
+// aiModPrompt instructs the classifier to answer with strict JSON only
+const aiModPrompt = `You are a content moderation classifier for a Telegram group chat.
+You get a JSON array of messages: [{"id": <number>, "text": <string>}].
+Classify every message into exactly one category:
+- "spam": ads, unsolicited promotion, mass invites, crypto or earning offers
+- "scam": phishing or fraudulent links, fake giveaways, requests for money or credentials
+- "harassment": insults, threats or hate aimed at a person or group (friendly banter is "ok")
+- "nsfw": sexual or graphic content
+- "ok": anything else
+Casual swearing, jokes and heated arguments are "ok". When unsure, use "ok" or a low confidence.
+
+Answer with JSON only, no markdown and no explanations, exactly in this schema:
+{"results": [{"id": <number>, "category": "ok|spam|scam|harassment|nsfw", "confidence": <0.0-1.0>, "reason": "<up to 10 words in Russian>"}]}
+Messages with category "ok" may be omitted.`
+
+// aiModMessage is a message waiting for classification
+type aiModMessage struct {
+	ID       int
+	Chat     *telebot.Chat
+	UserID   int64
+	UserName string
+	Text     string
+}
+
+// aiModVerdict is a single classifier result
+type aiModVerdict struct {
+	ID         int     `json:"id"`
+	Category   string  `json:"category"`
+	Confidence float64 `json:"confidence"`
+	Reason     string  `json:"reason"`
+}
+
+// AIModerationMiddleware collects messages of chats that opted in and classifies them with AI in batches.
+// Classification runs in the background, so messages are never held back.
+type AIModerationMiddleware struct {
+	bot             *telebot.Bot
+	aiClient        *utils.AIClient
+	settingsManager *models.SettingsManager
+	budgetManager   *models.AIBudgetManager
+	privacyManager  *models.PrivacyManager
+
+	mu      sync.Mutex
+	pending map[int64][]aiModMessage
+	queue   chan []aiModMessage
+
+	exhausted map[int64]string // Day the budget ran out per chat, to log it once. Only the worker uses it.
+
+	wg sync.WaitGroup
+}
+
+// NewAIModerationMiddleware creates the AI moderation middleware, its workers run after Start.
+// It returns nil when the AI backend isn't available.
+func NewAIModerationMiddleware(bot *telebot.Bot, settingsManager *models.SettingsManager, budgetManager *models.AIBudgetManager, privacyManager *models.PrivacyManager) *AIModerationMiddleware {
+	aiClient, err := utils.NewAIClient()
+	if err != nil {
+		fmt.Printf("[-] AI moderation disabled: %v\n", err)
+		return nil
+	}
+
+	am := &AIModerationMiddleware{
+		bot:             bot,
+		aiClient:        aiClient,
+		settingsManager: settingsManager,
+		budgetManager:   budgetManager,
+		privacyManager:  privacyManager,
+		pending:         make(map[int64][]aiModMessage),
+		queue:           make(chan []aiModMessage, aiModQueueSize),
+		exhausted:       make(map[int64]string),
+	}
+
+	return am
+}
+
+// Start runs the classifier worker and the batch flusher until ctx is cancelled
+func (am *AIModerationMiddleware) Start(ctx context.Context) {
+	am.wg.Add(2)
+	go am.worker(ctx)
+	go am.flusher(ctx)
+
+	fmt.Printf("[+] AI moderation started, batch: %d, flush interval: %v\n", aiModBatchSize, aiModFlushInterval)
+}
+
+// Wait blocks until the workers have exited, a batch being classified is finished first
+func (am *AIModerationMiddleware) Wait() {
+	am.wg.Wait()
+}
+
+// Middleware returns the telebot middleware function
+func (am *AIModerationMiddleware) Middleware() telebot.MiddlewareFunc {
+	return func(next telebot.HandlerFunc) telebot.HandlerFunc {
+		return func(c telebot.Context) error {
+			msg := c.Message()
+			if msg == nil || c.Callback() != nil || msg.Sender == nil || msg.Sender.IsBot {
+				return next(c)
+			}
+			if msg.Chat.Type != telebot.ChatGroup && msg.Chat.Type != telebot.ChatSuperGroup {
+				return next(c)
+			}
+
+			text := strings.TrimSpace(msg.Text)
+			if text == "" {
+				text = strings.TrimSpace(msg.Caption)
+			}
+			if text == "" || strings.HasPrefix(text, "/") || strings.HasPrefix(text, ".") {
+				return next(c)
+			}
+
+			// Messages of opted-out members aren't sent to the AI
+			settings := am.settingsManager.Get(msg.Chat.ID)
+			if settings.AIModeration != models.AIModerationOff && !settings.SpamAllowed(strconv.FormatInt(msg.Sender.ID, 10)) &&
+				!am.privacyManager.IsOptedOut(msg.Chat.ID, msg.Sender.ID) {
+				am.add(aiModMessage{
+					ID:       msg.ID,
+					Chat:     msg.Chat,
+					UserID:   msg.Sender.ID,
+					UserName: strings.TrimSpace(msg.Sender.FirstName + " " + msg.Sender.LastName),
+					Text:     text,
+				})
+			}
+
+			return next(c)
+		}
+	}
+}
+
+// add buffers a message and queues the chat batch once it's full
+func (am *AIModerationMiddleware) add(message aiModMessage) {
+	am.mu.Lock()
+	defer am.mu.Unlock()
+
+	chatID := message.Chat.ID
+	am.pending[chatID] = append(am.pending[chatID], message)
+	if len(am.pending[chatID]) >= aiModBatchSize {
+		am.enqueue(chatID)
+	}
+}
+
+// enqueue moves the pending batch of a chat to the queue. Must be called with am.mu held.
+func (am *AIModerationMiddleware) enqueue(chatID int64) {
+	batch := am.pending[chatID]
+	delete(am.pending, chatID)
+	if len(batch) == 0 {
+		return
+	}
+
+	select {
+	case am.queue <- batch:
+	default:
+		fmt.Printf("[-] AI moderation queue is full, dropped %d messages of chat %d\n", len(batch), chatID)
+	}
+}
+
+// flusher periodically queues incomplete batches
+func (am *AIModerationMiddleware) flusher(ctx context.Context) {
+	defer am.wg.Done()
+
+	ticker := time.NewTicker(aiModFlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			am.mu.Lock()
+			for chatID := range am.pending {
+				am.enqueue(chatID)
+			}
+			am.mu.Unlock()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// worker classifies queued batches one at a time, AI calls are slow.
+// Batches still queued at shutdown are dropped.
+func (am *AIModerationMiddleware) worker(ctx context.Context) {
+	defer am.wg.Done()
+
+	for {
+		select {
+		case batch := <-am.queue:
+			am.process(batch)
+		case <-ctx.Done():
+			fmt.Printf("[i] AI moderation stopped\n")
+			return
+		}
+	}
+}
+
+// process classifies a batch within the chat's daily budget and acts on confident verdicts
+func (am *AIModerationMiddleware) process(batch []aiModMessage) {
+	chat := batch[0].Chat
+	settings := am.settingsManager.Get(chat.ID)
+	if settings.AIModeration == models.AIModerationOff {
+		return
+	}
+
+	date := time.Now().In(settings.Location()).Format("2006-01-02")
+	allowed, err := am.budgetManager.Spend(chat.ID, date, settings.AIModDaily)
+	if err != nil {
+		fmt.Printf("[-] Failed to check AI moderation budget of chat %d: %v\n", chat.ID, err)
+		return
+	}
+	if !allowed {
+		if am.exhausted[chat.ID] != date {
+			am.exhausted[chat.ID] = date
+			fmt.Printf("[i] AI moderation budget of chat %d is exhausted for %s\n", chat.ID, date)
+		}
+		return
+	}
+
+	verdicts, err := am.classify(batch)
+	if err != nil {
+		fmt.Printf("[-] AI moderation failed in chat %d: %v\n", chat.ID, err)
+		return
+	}
+
+	byID := make(map[int]aiModMessage, len(batch))
+	for _, message := range batch {
+		byID[message.ID] = message
+	}
+
+	var lines []string
+	for _, verdict := range verdicts {
+		message, ok := byID[verdict.ID]
+		if !ok || verdict.Confidence < settings.AIModMinScore {
+			continue
+		}
+		if am.isAdmin(chat, message.UserID) {
+			continue
+		}
+
+		fmt.Printf("[!] AI moderation: %s (%.2f) from user %d in chat %d\n", verdict.Category, verdict.Confidence, message.UserID, chat.ID)
+
+		deleted := false
+		if settings.AIModeration == models.AIModerationDelete {
+			err := am.bot.Delete(&telebot.StoredMessage{MessageID: strconv.Itoa(message.ID), ChatID: chat.ID})
+			if err != nil {
+				fmt.Printf("[-] Failed to delete flagged message: %v\n", err)
+			} else {
+				deleted = true
+			}
+		}
+		lines = append(lines, formatAIModVerdict(message, verdict, deleted))
+	}
+
+	if len(lines) > 0 && settings.AIModLogChat != 0 {
+		am.report(settings.AIModLogChat, chat, lines)
+	}
+}
+
+// report sends flagged messages to the log chat, splitting reports that don't fit into one message
+func (am *AIModerationMiddleware) report(logChatID int64, chat *telebot.Chat, lines []string) {
+	header := fmt.Sprintf("🤖 <b>ИИ-модерация</b> · %s", html.EscapeString(chatTitle(chat)))
+
+	var chunks []string
+	current := header
+	for _, line := range lines {
+		if len(current)+len(line)+2 > aiModReportLength {
+			chunks = append(chunks, current)
+			current = header
+		}
+		current += "\n\n" + line
+	}
+	chunks = append(chunks, current)
+
+	for _, chunk := range chunks {
+		if _, err := am.bot.Send(&telebot.Chat{ID: logChatID}, chunk, &telebot.SendOptions{
+			ParseMode:             telebot.ModeHTML,
+			DisableWebPagePreview: true,
+		}); err != nil {
+			fmt.Printf("[-] Failed to send AI moderation report of chat %d: %v\n", chat.ID, err)
+			return
+		}
+	}
+}
+
+// classify sends a batch to the AI and returns valid verdicts other than "ok"
+func (am *AIModerationMiddleware) classify(batch []aiModMessage) ([]aiModVerdict, error) {
+	type promptMessage struct {
+		ID   int    `json:"id"`
+		Text string `json:"text"`
+	}
+
+	input := make([]promptMessage, 0, len(batch))
+	for _, message := range batch {
+		input = append(input, promptMessage{ID: message.ID, Text: clipText(message.Text, aiModMaxTextLength)})
+	}
+	prompt, err := json.Marshal(input)
+	if err != nil {
+		return nil, err
+	}
+
+	answer, err := am.aiClient.QuickChat(string(prompt),
+		utils.WithSystemMessage(aiModPrompt),
+		utils.WithTemperature(0),
+		utils.WithMaxTokens(1000),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseAIModVerdicts(answer)
+}
+
+// isAdmin checks whether a user is an admin of the chat; admins are never flagged
+func (am *AIModerationMiddleware) isAdmin(chat *telebot.Chat, userID int64) bool {
+	member, err := am.bot.ChatMemberOf(chat, &telebot.User{ID: userID})
+	if err != nil {
+		return false
+	}
+	return member.Role == telebot.Creator || member.Role == telebot.Administrator
+}
+
+// parseAIModVerdicts parses the classifier answer, dropping results outside the schema
+func parseAIModVerdicts(answer string) ([]aiModVerdict, error) {
+	var parsed struct {
+		Results []aiModVerdict `json:"results"`
+	}
+	if err := json.Unmarshal([]byte(utils.ExtractJSON(answer)), &parsed); err != nil {
+		return nil, fmt.Errorf("invalid classifier answer: %w", err)
+	}
+
+	var verdicts []aiModVerdict
+	for _, verdict := range parsed.Results {
+		verdict.Category = strings.ToLower(strings.TrimSpace(verdict.Category))
+		if _, ok := aiModCategories[verdict.Category]; !ok {
+			continue
+		}
+		if verdict.Confidence < 0 || verdict.Confidence > 1 {
+			continue
+		}
+		verdicts = append(verdicts, verdict)
+	}
+	return verdicts, nil
+}
+
+// formatAIModVerdict renders one flagged message of a report
+func formatAIModVerdict(message aiModMessage, verdict aiModVerdict, deleted bool) string {
+	line := fmt.Sprintf("%s · %.0f%% · <a href=\"tg://user?id=%d\">%s</a>",
+		aiModCategories[verdict.Category], verdict.Confidence*100, message.UserID, html.EscapeString(message.UserName))
+	if verdict.Reason != "" {
+		line += "\n💬 " + html.EscapeString(verdict.Reason)
+	}
+	line += "\n<blockquote>" + html.EscapeString(clipText(message.Text, aiModQuoteLength)) + "</blockquote>"
+
+	if deleted {
+		line += "\n🗑 Удалено"
+	} else if link := messageLink(message.Chat, message.ID); link != "" {
+		line += fmt.Sprintf("\n<a href=\"%s\">➡️ К сообщению</a>", link)
+	}
+	return line
+}
+
+// messageLink returns a link to a message; basic groups have none
+func messageLink(chat *telebot.Chat, messageID int) string {
+	if chat.Username != "" {
+		return fmt.Sprintf("https://t.me/%s/%d", chat.Username, messageID)
+	}
+	if id := strconv.FormatInt(chat.ID, 10); strings.HasPrefix(id, "-100") {
+		return fmt.Sprintf("https://t.me/c/%s/%d", strings.TrimPrefix(id, "-100"), messageID)
+	}
+	return ""
+}
+
+// chatTitle returns a readable chat name
+func chatTitle(chat *telebot.Chat) string {
+	if chat.Title != "" {
+		return chat.Title
+	}
+	return strconv.FormatInt(chat.ID, 10)
+}
+
+// clipText shortens text to limit runes
+func clipText(text string, limit int) string {
+	runes := []rune(text)
+	if len(runes) <= limit {
+		return text
+	}
+	return string(runes[:limit]) + "…"
+}
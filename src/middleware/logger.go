@@ -13,9 +13,13 @@ func LoggerMiddleware() telebot.MiddlewareFunc {
 		return func(c telebot.Context) error {
 			start := time.Now()
 			
-			// Log incoming message
+			// Log incoming message (inline queries have no chat)
+			var chatID int64
+			if c.Chat() != nil {
+				chatID = c.Chat().ID
+			}
 			log.Printf("[i] User: %d, Chat: %d, Text: %s", 
-				c.Sender().ID, c.Chat().ID, c.Text())
+				c.Sender().ID, chatID, c.Text())
 			
 			// Execute next handler
 			err := next(c)
@@ -5,8 +5,17 @@ import (
 	"gobrev/src/models"
 )
 
-// SetupMiddleware configures all middleware for the bot
-func SetupMiddleware(bot *telebot.Bot, metrics *models.Metrics) {
+// SetupMiddleware configures all middleware for the bot.
+// It returns the AI moderation middleware to be started, nil when AI isn't available.
+func SetupMiddleware(bot *telebot.Bot, metrics *models.Metrics, settingsManager *models.SettingsManager, budgetManager *models.AIBudgetManager, privacyManager *models.PrivacyManager) *AIModerationMiddleware {
 	bot.Use(LoggerMiddleware())
 	bot.Use(MetricsMiddleware(metrics))
+	bot.Use(NewModerationMiddleware(settingsManager).Middleware())
+
+	// Messages dropped by anti-spam never reach the AI classifier
+	aiModeration := NewAIModerationMiddleware(bot, settingsManager, budgetManager, privacyManager)
+	if aiModeration != nil {
+		bot.Use(aiModeration.Middleware())
+	}
+	return aiModeration
 }
@@ -0,0 +1,276 @@
+package middleware
+
+import (
+	"fmt"
+	"html"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/telebot.v3"
+	"gobrev/src/models"
+	"gobrev/src/utils"
+)
+
+const (
+	// repeatLimit is how many identical messages in a row count as spam
+	repeatLimit = 3
+	// repeatWindow is how long an identical message counts as a repeat
+	repeatWindow = 2 * time.Minute
+	// mentionLimit is how many mentions in one message count as mass mentioning
+	mentionLimit = 5
+	// linkLimit is how many links in one message count as link spam
+	linkLimit = 3
+	// warnCooldown limits warnings to one per user in this period
+	warnCooldown = 30 * time.Second
+	// floodStateTTL drops tracking of users who stopped writing
+	floodStateTTL = 10 * time.Minute
+)
+
+// inviteRx matches Telegram chat invite links
+var inviteRx = regexp.MustCompile(`(?i)(?:t\.me|telegram\.me|telegram\.dog)/(?:joinchat/|\+)[\w-]+`)
+
+// linkRx matches links written without entities (e.g. in captions of forwarded posts)
+var linkRx = regexp.MustCompile(`(?i)\b(?:https?://|www\.|t\.me/)[^\s]+`)
+
+// userActivity tracks recent messages of a user in a chat
+type userActivity struct {
+	times    []time.Time
+	lastText string
+	repeats  int
+	lastSeen time.Time
+}
+
+// ModerationMiddleware detects floods, repeats, mass mentions and link spam
+// and applies the chat's anti-spam action
+type ModerationMiddleware struct {
+	settingsManager *models.SettingsManager
+	adminManager    *utils.AdminManager
+	warnings        *utils.CooldownTracker
+
+	mu        sync.Mutex
+	activity  map[string]*userActivity
+	lastPrune time.Time
+}
+
+// NewModerationMiddleware creates a new moderation middleware
+func NewModerationMiddleware(settingsManager *models.SettingsManager) *ModerationMiddleware {
+	return &ModerationMiddleware{
+		settingsManager: settingsManager,
+		adminManager:    utils.NewAdminManager(),
+		warnings:        utils.NewCooldownTracker(),
+		activity:        make(map[string]*userActivity),
+		lastPrune:       time.Now(),
+	}
+}
+
+// Middleware returns the telebot middleware function
+func (mm *ModerationMiddleware) Middleware() telebot.MiddlewareFunc {
+	return func(next telebot.HandlerFunc) telebot.HandlerFunc {
+		return func(c telebot.Context) error {
+			msg := c.Message()
+			if msg == nil || c.Callback() != nil || msg.Sender == nil || msg.Sender.IsBot {
+				return next(c)
+			}
+			if msg.Chat.Type != telebot.ChatGroup && msg.Chat.Type != telebot.ChatSuperGroup {
+				return next(c)
+			}
+
+			settings := mm.settingsManager.Get(msg.Chat.ID)
+			if settings.SpamAction == models.SpamActionOff {
+				return next(c)
+			}
+
+			reason := mm.detect(msg, settings)
+			if reason == "" {
+				return next(c)
+			}
+
+			// Exemptions are checked only on violations: IsChatAdmin is an API call
+			if settings.SpamAllowed(strconv.FormatInt(msg.Sender.ID, 10)) || mm.adminManager.IsChatAdmin(c) {
+				return next(c)
+			}
+
+			fmt.Printf("[!] Spam from user %d in chat %d: %s\n", msg.Sender.ID, msg.Chat.ID, reason)
+			mm.punish(c, settings, reason)
+
+			// A warned message stays in the chat, so it is handled like any other
+			if settings.SpamAction == models.SpamActionWarn {
+				return next(c)
+			}
+			return nil
+		}
+	}
+}
+
+// detect returns the violation reason or an empty string
+func (mm *ModerationMiddleware) detect(msg *telebot.Message, settings models.ChatSettings) string {
+	text := msg.Text
+	if text == "" {
+		text = msg.Caption
+	}
+
+	if reason := mm.trackActivity(msg.Chat.ID, msg.Sender.ID, text, settings); reason != "" {
+		return reason
+	}
+
+	entities := msg.Entities
+	if len(entities) == 0 {
+		entities = msg.CaptionEntities
+	}
+
+	mentions := 0
+	var links []string
+	for _, entity := range entities {
+		switch entity.Type {
+		case telebot.EntityMention, telebot.EntityTMention:
+			mentions++
+		case telebot.EntityURL:
+			links = append(links, msg.EntityText(entity))
+		case telebot.EntityTextLink:
+			links = append(links, entity.URL)
+		}
+	}
+	if len(links) == 0 {
+		links = linkRx.FindAllString(text, -1)
+	}
+
+	if mentions >= mentionLimit {
+		return fmt.Sprintf("массовые упоминания (%d)", mentions)
+	}
+
+	external := 0
+	for _, link := range links {
+		if linkAllowed(link, settings) {
+			continue
+		}
+		if inviteRx.MatchString(link) {
+			return "ссылка-приглашение"
+		}
+		external++
+	}
+	if external >= linkLimit {
+		return fmt.Sprintf("спам ссылками (%d)", external)
+	}
+
+	return ""
+}
+
+// trackActivity records the message and checks flood and repeat limits
+func (mm *ModerationMiddleware) trackActivity(chatID, userID int64, text string, settings models.ChatSettings) string {
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(mm.lastPrune) > floodStateTTL {
+		for key, activity := range mm.activity {
+			if now.Sub(activity.lastSeen) > floodStateTTL {
+				delete(mm.activity, key)
+			}
+		}
+		mm.lastPrune = now
+	}
+
+	key := fmt.Sprintf("%d_%d", chatID, userID)
+	activity, ok := mm.activity[key]
+	if !ok {
+		activity = &userActivity{}
+		mm.activity[key] = activity
+	}
+
+	// Flood: too many messages within the window
+	window := time.Duration(settings.FloodSeconds) * time.Second
+	recent := activity.times[:0]
+	for _, t := range activity.times {
+		if now.Sub(t) < window {
+			recent = append(recent, t)
+		}
+	}
+	activity.times = append(recent, now)
+
+	// Repeats: the same text several times in a row
+	normalized := strings.ToLower(strings.TrimSpace(text))
+	if normalized != "" && normalized == activity.lastText && now.Sub(activity.lastSeen) < repeatWindow {
+		activity.repeats++
+	} else {
+		activity.repeats = 1
+	}
+	activity.lastText = normalized
+	activity.lastSeen = now
+
+	if len(activity.times) > settings.FloodMessages {
+		return fmt.Sprintf("флуд (%d сообщений за %d сек.)", len(activity.times), settings.FloodSeconds)
+	}
+	if activity.repeats >= repeatLimit {
+		return fmt.Sprintf("повтор одного сообщения (%d раз)", activity.repeats)
+	}
+	return ""
+}
+
+// punish applies the chat's anti-spam action to the message author
+func (mm *ModerationMiddleware) punish(c telebot.Context, settings models.ChatSettings, reason string) {
+	msg := c.Message()
+	user := msg.Sender
+	mention := fmt.Sprintf(`<a href="tg://user?id=%d">%s</a>`, user.ID, html.EscapeString(user.FirstName))
+
+	if settings.SpamAction == models.SpamActionDelete || settings.SpamAction == models.SpamActionMute {
+		if err := c.Bot().Delete(msg); err != nil {
+			fmt.Printf("[-] Failed to delete spam message: %v\n", err)
+		}
+	}
+
+	text := fmt.Sprintf("⚠️ %s, без спама: %s", mention, reason)
+
+	if settings.SpamAction == models.SpamActionMute {
+		duration := time.Duration(settings.MuteMinutes) * time.Minute
+		err := c.Bot().Restrict(msg.Chat, &telebot.ChatMember{
+			User:            user,
+			Rights:          telebot.NoRights(),
+			RestrictedUntil: time.Now().Add(duration).Unix(),
+		})
+		if err != nil {
+			fmt.Printf("[-] Failed to mute user %d in chat %d: %v\n", user.ID, msg.Chat.ID, err)
+		} else {
+			text = fmt.Sprintf("🔇 %s в муте на %d мин.: %s", mention, settings.MuteMinutes, reason)
+		}
+	}
+
+	// One notice per user per period, the flood itself keeps being handled
+	key := fmt.Sprintf("%d_%d", msg.Chat.ID, user.ID)
+	if mm.warnings.Remaining(key, warnCooldown) > 0 {
+		return
+	}
+	mm.warnings.Touch(key)
+
+	options := &telebot.SendOptions{ParseMode: telebot.ModeHTML}
+	if settings.SpamAction == models.SpamActionWarn {
+		options.ReplyTo = msg
+	}
+	if _, err := c.Bot().Send(msg.Chat, text, options); err != nil {
+		fmt.Printf("[-] Failed to send spam warning: %v\n", err)
+	}
+}
+
+// linkAllowed checks a link against the allow-list by domain and by t.me path
+func linkAllowed(link string, settings models.ChatSettings) bool {
+	if !strings.Contains(link, "://") {
+		link = "https://" + link
+	}
+	parsed, err := url.Parse(link)
+	if err != nil || parsed.Host == "" {
+		return false
+	}
+
+	host := strings.TrimPrefix(strings.ToLower(parsed.Host), "www.")
+	if settings.SpamAllowed(host) {
+		return true
+	}
+
+	if segment, _, _ := strings.Cut(strings.Trim(parsed.Path, "/"), "/"); segment != "" {
+		return settings.SpamAllowed(host + "/" + segment)
+	}
+	return false
+}
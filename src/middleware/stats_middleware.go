@@ -56,7 +56,7 @@ func (sm *StatsMiddleware) HandleMessage(c telebot.Context) error {
 	}
 	
 	// Add message to statistics
-	err := sm.statsManager.AddMessage(chatID, userID, username, text)
+	err := sm.statsManager.AddMessage(chatID, userID, username, user.Username, text)
 	if err != nil {
 		fmt.Printf("[-] Failed to add message to stats: %v\n", err)
 		// Don't return error to avoid breaking the bot
@@ -0,0 +1,86 @@
+package models
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"gobrev/src/storage"
+)
+
+// aiBudgetKeepDays is how long daily counters are kept after their day
+const aiBudgetKeepDays = 2
+
+// AIBudgetManager counts background AI calls per chat and day so a chat can't exceed its daily cap
+type AIBudgetManager struct {
+	store storage.Store
+}
+
+// NewAIBudgetManager creates a new AI budget manager
+func NewAIBudgetManager(store storage.Store) *AIBudgetManager {
+	return &AIBudgetManager{
+		store: store,
+	}
+}
+
+// Spend takes one call from the daily budget. It returns false when the limit is already reached.
+func (bm *AIBudgetManager) Spend(chatID int64, date string, limit int) (bool, error) {
+	allowed := false
+
+	err := bm.store.Update(func(tx storage.Tx) error {
+		used, err := readCounter(tx, aiBudgetKey(chatID, date))
+		if err != nil {
+			return err
+		}
+		if used >= limit {
+			return nil
+		}
+
+		allowed = true
+		return tx.Set(aiBudgetKey(chatID, date), []byte(strconv.Itoa(used+1)))
+	})
+
+	return allowed, err
+}
+
+// Used returns how many calls a chat made on a day
+func (bm *AIBudgetManager) Used(chatID int64, date string) int {
+	used, _ := readCounter(bm.store, aiBudgetKey(chatID, date))
+	return used
+}
+
+// CleanupExpired removes counters of past days and returns the number of deleted records
+func (bm *AIBudgetManager) CleanupExpired() (int, error) {
+	cutoff := time.Now().AddDate(0, 0, -aiBudgetKeepDays).Format("2006-01-02")
+	var keysToDelete []string
+
+	err := bm.store.ScanKeys(aiBudgetPrefix, func(key string) error {
+		date := key[strings.LastIndex(key, "_")+1:]
+		if date < cutoff {
+			keysToDelete = append(keysToDelete, key)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if err := storage.DeleteKeys(bm.store, keysToDelete); err != nil {
+		return 0, err
+	}
+
+	return len(keysToDelete), nil
+}
+
+// readCounter reads an integer counter, missing keys count as zero
+func readCounter(reader storage.Reader, key string) (int, error) {
+	val, err := reader.Get(key)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	return strconv.Atoi(string(val))
+}
@@ -0,0 +1,151 @@
+package models
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"gobrev/src/storage"
+)
+
+// ErrCallbackExpired is returned for unknown or expired callback records
+var ErrCallbackExpired = errors.New("callback expired")
+
+// CallbackData is the server-side state behind an inline keyboard.
+// Buttons only carry the random ID, so payloads can't be forged and survive restarts.
+type CallbackData struct {
+	ID        string `json:"id"`
+	Prefix    string `json:"prefix"`            // Handler prefix
+	Payload   string `json:"payload,omitempty"` // Opaque handler state
+	ChatID    int64  `json:"chat_id"`           // Chat where the keyboard was sent
+	UserID    int64  `json:"user_id,omitempty"` // Only this user may press the buttons (0 = anyone)
+	CreatedAt int64  `json:"created_at"`
+	ExpiresAt int64  `json:"expires_at"`
+}
+
+// CallbackManager stores callback records
+type CallbackManager struct {
+	store storage.Store
+}
+
+// NewCallbackManager creates a new callback manager
+func NewCallbackManager(store storage.Store) *CallbackManager {
+	return &CallbackManager{
+		store: store,
+	}
+}
+
+// Create stores a new callback record and returns it
+func (cm *CallbackManager) Create(prefix, payload string, chatID, userID int64, ttl time.Duration) (*CallbackData, error) {
+	id, err := newCallbackID()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	data := &CallbackData{
+		ID:        id,
+		Prefix:    prefix,
+		Payload:   payload,
+		ChatID:    chatID,
+		UserID:    userID,
+		CreatedAt: now.Unix(),
+		ExpiresAt: now.Add(ttl).Unix(),
+	}
+
+	jsonData, err := json.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal callback data: %w", err)
+	}
+
+	if err := cm.store.Set(callbackKey(id), jsonData); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// Get returns a callback record, or ErrCallbackExpired if it's gone or expired
+func (cm *CallbackManager) Get(id string) (*CallbackData, error) {
+	val, err := cm.store.Get(callbackKey(id))
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, ErrCallbackExpired
+		}
+		return nil, err
+	}
+
+	var data CallbackData
+	if err := json.Unmarshal(val, &data); err != nil {
+		return nil, err
+	}
+
+	if time.Now().Unix() > data.ExpiresAt {
+		cm.Delete(id)
+		return nil, ErrCallbackExpired
+	}
+
+	return &data, nil
+}
+
+// UpdatePayload replaces the payload of an existing record
+func (cm *CallbackManager) UpdatePayload(id, payload string) error {
+	return cm.store.Update(func(tx storage.Tx) error {
+		val, err := tx.Get(callbackKey(id))
+		if err != nil {
+			return err
+		}
+
+		var data CallbackData
+		if err := json.Unmarshal(val, &data); err != nil {
+			return err
+		}
+		data.Payload = payload
+
+		jsonData, err := json.Marshal(data)
+		if err != nil {
+			return err
+		}
+		return tx.Set(callbackKey(id), jsonData)
+	})
+}
+
+// Delete removes a callback record, disabling its buttons
+func (cm *CallbackManager) Delete(id string) error {
+	return cm.store.Delete(callbackKey(id))
+}
+
+// CleanupExpired removes expired records and returns the number of deleted records
+func (cm *CallbackManager) CleanupExpired() (int, error) {
+	now := time.Now().Unix()
+	var keysToDelete []string
+
+	err := cm.store.Scan(callbackPrefix, func(key string, val []byte) error {
+		var data CallbackData
+		if err := json.Unmarshal(val, &data); err != nil || data.ExpiresAt < now {
+			keysToDelete = append(keysToDelete, key)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if err := storage.DeleteKeys(cm.store, keysToDelete); err != nil {
+		return 0, err
+	}
+
+	return len(keysToDelete), nil
+}
+
+// newCallbackID returns a random URL-safe ID short enough for 64-byte callback data
+func newCallbackID() (string, error) {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate callback ID: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
@@ -0,0 +1,86 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"gobrev/src/storage"
+)
+
+// Captcha is a pending join challenge of a new chat member
+type Captcha struct {
+	ChatID    int64  `json:"chat_id"`
+	UserID    int64  `json:"user_id"`
+	UserName  string `json:"user_name"`
+	MessageID int    `json:"message_id"` // Challenge message to delete when it's over
+	Answer    string `json:"answer"`
+	Attempts  int    `json:"attempts"`
+	CreatedAt int64  `json:"created_at"`
+	ExpiresAt int64  `json:"expires_at"` // The user is kicked after this time
+}
+
+// CaptchaManager stores pending join challenges so they survive restarts
+type CaptchaManager struct {
+	store storage.Store
+}
+
+// NewCaptchaManager creates a new captcha manager
+func NewCaptchaManager(store storage.Store) *CaptchaManager {
+	return &CaptchaManager{
+		store: store,
+	}
+}
+
+// Save stores a challenge
+func (cm *CaptchaManager) Save(captcha Captcha) error {
+	jsonData, err := json.Marshal(captcha)
+	if err != nil {
+		return fmt.Errorf("failed to marshal captcha: %w", err)
+	}
+	return cm.store.Set(captchaKey(captcha.ChatID, captcha.UserID), jsonData)
+}
+
+// Get returns the pending challenge of a user, nil if there is none
+func (cm *CaptchaManager) Get(chatID, userID int64) (*Captcha, error) {
+	val, err := cm.store.Get(captchaKey(chatID, userID))
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var captcha Captcha
+	if err := json.Unmarshal(val, &captcha); err != nil {
+		return nil, err
+	}
+	return &captcha, nil
+}
+
+// Delete removes a challenge
+func (cm *CaptchaManager) Delete(chatID, userID int64) error {
+	return cm.store.Delete(captchaKey(chatID, userID))
+}
+
+// Pending returns all stored challenges
+func (cm *CaptchaManager) Pending() ([]Captcha, error) {
+	var captchas []Captcha
+
+	err := cm.store.Scan(captchaPrefix, func(key string, val []byte) error {
+		var captcha Captcha
+		if err := json.Unmarshal(val, &captcha); err != nil {
+			fmt.Printf("[-] Skipping broken captcha record %s: %v\n", key, err)
+			return nil
+		}
+		captchas = append(captchas, captcha)
+		return nil
+	})
+
+	return captchas, err
+}
+
+// DeleteChatCaptchas removes all challenges of a chat
+func (cm *CaptchaManager) DeleteChatCaptchas(chatID int64) (int, error) {
+	return storage.DeletePrefix(cm.store, captchaChatPrefix(chatID))
+}
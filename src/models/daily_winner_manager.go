@@ -0,0 +1,150 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"time"
+
+	"gobrev/src/storage"
+)
+
+// DailyWinner is the user of the day of a chat
+type DailyWinner struct {
+	ChatID   int64  `json:"chat_id"`
+	Date     string `json:"date"` // YYYY-MM-DD in the chat timezone
+	UserID   int64  `json:"user_id"`
+	Username string `json:"username"`
+	PickedAt int64  `json:"picked_at"`
+}
+
+// DailyWins is how many times a member was the user of the day
+type DailyWins struct {
+	UserID   int64
+	Username string
+	Wins     int
+}
+
+// DailyWinnerManager picks and stores the user of the day of each chat
+type DailyWinnerManager struct {
+	store storage.Store
+}
+
+// NewDailyWinnerManager creates a new daily winner manager
+func NewDailyWinnerManager(store storage.Store) *DailyWinnerManager {
+	return &DailyWinnerManager{
+		store: store,
+	}
+}
+
+// Pick returns the winner of a day, choosing one of candidates if the day has none yet.
+// The choice depends only on the chat, the date and the candidates, so it is reproducible.
+// created is true when this call made the choice.
+func (dm *DailyWinnerManager) Pick(chatID int64, date string, candidates []UserStats) (winner DailyWinner, created bool, err error) {
+	key := dailyWinnerKey(chatID, date)
+
+	err = dm.store.Update(func(tx storage.Tx) error {
+		val, err := tx.Get(key)
+		if err == nil {
+			return json.Unmarshal(val, &winner)
+		}
+		if !errors.Is(err, storage.ErrNotFound) {
+			return err
+		}
+		if len(candidates) == 0 {
+			return nil
+		}
+
+		hash := fnv.New64a()
+		fmt.Fprintf(hash, "%d_%s", chatID, date)
+		chosen := candidates[hash.Sum64()%uint64(len(candidates))]
+
+		winner = DailyWinner{
+			ChatID:   chatID,
+			Date:     date,
+			UserID:   chosen.UserID,
+			Username: chosen.Username,
+			PickedAt: time.Now().Unix(),
+		}
+		jsonData, err := json.Marshal(winner)
+		if err != nil {
+			return fmt.Errorf("failed to marshal daily winner: %w", err)
+		}
+
+		created = true
+		return tx.Set(key, jsonData)
+	})
+
+	return winner, created, err
+}
+
+// Leaderboard counts wins of each member of a chat, most wins first
+func (dm *DailyWinnerManager) Leaderboard(chatID int64, limit int) ([]DailyWins, error) {
+	wins := make(map[int64]*DailyWins)
+
+	err := dm.store.Scan(dailyWinnerChatPrefix(chatID), func(key string, val []byte) error {
+		var winner DailyWinner
+		if err := json.Unmarshal(val, &winner); err != nil {
+			return nil
+		}
+
+		entry, ok := wins[winner.UserID]
+		if !ok {
+			entry = &DailyWins{UserID: winner.UserID}
+			wins[winner.UserID] = entry
+		}
+		// Records are scanned in date order, so the latest name wins
+		entry.Username = winner.Username
+		entry.Wins++
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var board []DailyWins
+	for _, entry := range wins {
+		board = append(board, *entry)
+	}
+	sort.Slice(board, func(i, j int) bool {
+		if board[i].Wins != board[j].Wins {
+			return board[i].Wins > board[j].Wins
+		}
+		return board[i].Username < board[j].Username
+	})
+
+	if limit > 0 && len(board) > limit {
+		board = board[:limit]
+	}
+
+	return board, nil
+}
+
+// DeleteUserWins removes days a user won in all chats and returns the number of deleted records
+func (dm *DailyWinnerManager) DeleteUserWins(userID int64) (int, error) {
+	var keysToDelete []string
+
+	err := dm.store.Scan(dailyWinnerPrefix, func(key string, val []byte) error {
+		var winner DailyWinner
+		if err := json.Unmarshal(val, &winner); err != nil || winner.UserID == userID {
+			keysToDelete = append(keysToDelete, key)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if err := storage.DeleteKeys(dm.store, keysToDelete); err != nil {
+		return 0, err
+	}
+
+	return len(keysToDelete), nil
+}
+
+// DeleteChatWinners removes the winner history of a chat
+func (dm *DailyWinnerManager) DeleteChatWinners(chatID int64) (int, error) {
+	return storage.DeletePrefix(dm.store, dailyWinnerChatPrefix(chatID))
+}
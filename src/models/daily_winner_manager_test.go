@@ -0,0 +1,86 @@
+package models
+
+import (
+	"testing"
+
+	"gobrev/src/storage"
+)
+
+var dailyCandidates = []UserStats{
+	{UserID: 1, Username: "Аня"},
+	{UserID: 2, Username: "Борис"},
+	{UserID: 3, Username: "Вика"},
+	{UserID: 4, Username: "Гоша"},
+}
+
+func TestDailyWinnerPickIsStable(t *testing.T) {
+	dm := NewDailyWinnerManager(storage.NewMemoryStore())
+
+	winner, created, err := dm.Pick(-100, "2026-10-18", dailyCandidates)
+	if err != nil || !created || winner.UserID == 0 {
+		t.Fatalf("first Pick = %+v, %v, %v", winner, created, err)
+	}
+
+	// Later calls of the day return the stored winner, even with other candidates
+	again, created, err := dm.Pick(-100, "2026-10-18", dailyCandidates[:1])
+	if err != nil || created || again.UserID != winner.UserID {
+		t.Errorf("second Pick = %+v, created %v, %v, want winner %d", again, created, err, winner.UserID)
+	}
+
+	// The same chat, date and candidates give the same winner on another store
+	fresh, _, _ := NewDailyWinnerManager(storage.NewMemoryStore()).Pick(-100, "2026-10-18", dailyCandidates)
+	if fresh.UserID != winner.UserID {
+		t.Errorf("Pick on a fresh store chose %d, want %d", fresh.UserID, winner.UserID)
+	}
+}
+
+func TestDailyWinnerPickWithoutCandidates(t *testing.T) {
+	dm := NewDailyWinnerManager(storage.NewMemoryStore())
+
+	winner, created, err := dm.Pick(-100, "2026-10-18", nil)
+	if err != nil || created || winner.UserID != 0 {
+		t.Fatalf("Pick without candidates = %+v, %v, %v, want nothing", winner, created, err)
+	}
+	if winner, created, _ := dm.Pick(-100, "2026-10-18", dailyCandidates); !created || winner.UserID == 0 {
+		t.Errorf("Pick after an empty day chose nobody")
+	}
+}
+
+func TestDailyWinnerLeaderboard(t *testing.T) {
+	store := storage.NewMemoryStore()
+	dm := NewDailyWinnerManager(store)
+	days := []struct {
+		date   string
+		winner UserStats
+	}{
+		{"2026-10-01", dailyCandidates[1]},
+		{"2026-10-02", dailyCandidates[0]},
+		{"2026-10-03", dailyCandidates[1]},
+		{"2026-10-04", UserStats{UserID: 2, Username: "Борис Б."}},
+	}
+	for _, day := range days {
+		if _, _, err := dm.Pick(-100, day.date, []UserStats{day.winner}); err != nil {
+			t.Fatalf("Pick: %v", err)
+		}
+	}
+	dm.Pick(-200, "2026-10-01", dailyCandidates[2:3])
+
+	board, err := dm.Leaderboard(-100, 0)
+	if err != nil || len(board) != 2 {
+		t.Fatalf("Leaderboard = %+v, %v, want 2 members", board, err)
+	}
+	if board[0].UserID != 2 || board[0].Wins != 3 || board[0].Username != "Борис Б." {
+		t.Errorf("leader = %+v, want user 2 with 3 wins under the latest name", board[0])
+	}
+	if limited, _ := dm.Leaderboard(-100, 1); len(limited) != 1 {
+		t.Errorf("Leaderboard limit 1 returned %d members", len(limited))
+	}
+
+	deleted, err := dm.DeleteUserWins(2)
+	if err != nil || deleted != 3 {
+		t.Fatalf("DeleteUserWins = %d, %v, want 3", deleted, err)
+	}
+	if board, _ := dm.Leaderboard(-100, 0); len(board) != 1 || board[0].UserID != 1 {
+		t.Errorf("Leaderboard after delete = %+v, want user 1 only", board)
+	}
+}
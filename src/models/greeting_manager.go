@@ -0,0 +1,107 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"gobrev/src/storage"
+)
+
+// Greeting limits
+const (
+	MaxGreetingLength  = 1000
+	MaxGreetingButtons = 6
+	MaxGreetingDelete  = 24 * 60
+)
+
+// GreetingButton is a link button under the welcome message
+type GreetingButton struct {
+	Text string `json:"text"`
+	URL  string `json:"url"`
+}
+
+// Greeting holds welcome and goodbye templates of a chat.
+// Templates may use {name}, {mention}, {chat} and {count} placeholders.
+type Greeting struct {
+	ChatID        int64            `json:"chat_id"`
+	Welcome       string           `json:"welcome"`        // Welcome template, empty to stay silent
+	Goodbye       string           `json:"goodbye"`        // Goodbye template, empty to stay silent
+	Buttons       []GreetingButton `json:"buttons"`        // Link buttons under the welcome message
+	DeleteMinutes int              `json:"delete_minutes"` // Delete greetings after this time, 0 keeps them
+	AI            bool             `json:"ai"`             // Generate personalised welcomes with AI
+	UpdatedAt     int64            `json:"updated_at"`
+}
+
+// Enabled reports whether newcomers get any welcome
+func (g Greeting) Enabled() bool {
+	return g.Welcome != "" || g.AI
+}
+
+// Validate checks that all values are within limits
+func (g Greeting) Validate() error {
+	if len([]rune(g.Welcome)) > MaxGreetingLength || len([]rune(g.Goodbye)) > MaxGreetingLength {
+		return fmt.Errorf("шаблон не длиннее %d символов", MaxGreetingLength)
+	}
+	if len(g.Buttons) > MaxGreetingButtons {
+		return fmt.Errorf("не больше %d кнопок", MaxGreetingButtons)
+	}
+	if g.DeleteMinutes < 0 || g.DeleteMinutes > MaxGreetingDelete {
+		return fmt.Errorf("автоудаление от 1 минуты до %d часов", MaxGreetingDelete/60)
+	}
+	return nil
+}
+
+// GreetingManager stores welcome and goodbye templates per chat
+type GreetingManager struct {
+	store storage.Store
+}
+
+// NewGreetingManager creates a new greeting manager
+func NewGreetingManager(store storage.Store) *GreetingManager {
+	return &GreetingManager{
+		store: store,
+	}
+}
+
+// Get returns the chat greeting, an empty one if nothing is configured
+func (gm *GreetingManager) Get(chatID int64) Greeting {
+	greeting := Greeting{ChatID: chatID}
+
+	val, err := gm.store.Get(greetingKey(chatID))
+	if err != nil {
+		if !errors.Is(err, storage.ErrNotFound) {
+			fmt.Printf("[-] Failed to load greeting for chat %d: %v\n", chatID, err)
+		}
+		return greeting
+	}
+
+	if err := json.Unmarshal(val, &greeting); err != nil {
+		fmt.Printf("[-] Failed to decode greeting for chat %d: %v\n", chatID, err)
+		return Greeting{ChatID: chatID}
+	}
+	greeting.ChatID = chatID
+
+	return greeting
+}
+
+// Save validates and stores the chat greeting
+func (gm *GreetingManager) Save(greeting Greeting) error {
+	if err := greeting.Validate(); err != nil {
+		return err
+	}
+
+	greeting.UpdatedAt = time.Now().Unix()
+	jsonData, err := json.Marshal(greeting)
+	if err != nil {
+		return fmt.Errorf("failed to marshal greeting: %w", err)
+	}
+
+	return gm.store.Set(greetingKey(greeting.ChatID), jsonData)
+}
+
+// Reset removes the chat greeting
+func (gm *GreetingManager) Reset(chatID int64) error {
+	return gm.store.Delete(greetingKey(chatID))
+}
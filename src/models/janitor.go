@@ -0,0 +1,162 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gobrev/src/storage"
+)
+
+// JanitorConfig holds schedule and retention settings for background cleanup
+type JanitorConfig struct {
+	Interval            time.Duration // How often cleanup runs
+	StatsRetentionDays  int           // Daily stats older than this are removed (0 disables)
+	ReviewRetentionDays int           // Review messages older than this are removed (0 disables)
+	MessageIDRetention  time.Duration // AI message IDs older than this are removed (0 disables)
+	GCDiscardRatio      float64       // Discard ratio passed to the store garbage collector
+}
+
+// ExpiredCleaner is a manager whose records expire
+type ExpiredCleaner interface {
+	CleanupExpired() (int, error)
+}
+
+// namedCleaner is an ExpiredCleaner with a name for logs
+type namedCleaner struct {
+	name    string
+	cleaner ExpiredCleaner
+}
+
+// Janitor periodically removes expired data from all stores
+type Janitor struct {
+	store            storage.Store
+	statsManager     *StatsManager
+	reviewManager    *ReviewManager
+	messageIDManager *MessageIDManager
+	callbackManager  *CallbackManager
+	cleaners         []namedCleaner
+	config           JanitorConfig
+	done             chan struct{}
+}
+
+// NewJanitor creates a new janitor
+func NewJanitor(store storage.Store, statsManager *StatsManager, reviewManager *ReviewManager, messageIDManager *MessageIDManager, callbackManager *CallbackManager, config JanitorConfig) *Janitor {
+	if config.Interval <= 0 {
+		config.Interval = time.Hour
+	}
+	if config.GCDiscardRatio <= 0 || config.GCDiscardRatio >= 1 {
+		config.GCDiscardRatio = 0.5
+	}
+
+	return &Janitor{
+		store:            store,
+		statsManager:     statsManager,
+		reviewManager:    reviewManager,
+		messageIDManager: messageIDManager,
+		callbackManager:  callbackManager,
+		config:           config,
+		done:             make(chan struct{}),
+	}
+}
+
+// AddCleaner registers another store with expiring records, call it before Start
+func (j *Janitor) AddCleaner(name string, cleaner ExpiredCleaner) {
+	j.cleaners = append(j.cleaners, namedCleaner{name: name, cleaner: cleaner})
+}
+
+// Start runs cleanup immediately and then on every interval until ctx is cancelled
+func (j *Janitor) Start(ctx context.Context) {
+	go func() {
+		defer close(j.done)
+
+		ticker := time.NewTicker(j.config.Interval)
+		defer ticker.Stop()
+
+		j.RunOnce()
+
+		for {
+			select {
+			case <-ticker.C:
+				j.RunOnce()
+			case <-ctx.Done():
+				fmt.Printf("[i] Janitor stopped\n")
+				return
+			}
+		}
+	}()
+
+	fmt.Printf("[+] Janitor started, interval: %v\n", j.config.Interval)
+}
+
+// Wait blocks until the janitor goroutine has exited
+func (j *Janitor) Wait() {
+	<-j.done
+}
+
+// RunOnce runs every enabled cleanup followed by value log garbage collection
+func (j *Janitor) RunOnce() {
+	start := time.Now()
+	total := 0
+
+	if j.config.StatsRetentionDays > 0 {
+		removed, err := j.statsManager.CleanupOldStats(j.config.StatsRetentionDays)
+		if err != nil {
+			fmt.Printf("[-] Janitor failed to cleanup stats: %v\n", err)
+		} else {
+			fmt.Printf("[#] Janitor removed %d stats keys older than %d days\n", removed, j.config.StatsRetentionDays)
+			total += removed
+		}
+	}
+
+	if j.config.ReviewRetentionDays > 0 {
+		removed, err := j.reviewManager.CleanupOldMessages(j.config.ReviewRetentionDays)
+		if err != nil {
+			fmt.Printf("[-] Janitor failed to cleanup review messages: %v\n", err)
+		} else {
+			fmt.Printf("[#] Janitor removed %d review messages older than %d days\n", removed, j.config.ReviewRetentionDays)
+			total += removed
+		}
+	}
+
+	if j.config.MessageIDRetention > 0 {
+		removed, err := j.messageIDManager.CleanupOldMessages(j.config.MessageIDRetention)
+		if err != nil {
+			fmt.Printf("[-] Janitor failed to cleanup message IDs: %v\n", err)
+		} else {
+			fmt.Printf("[#] Janitor removed %d message IDs older than %v\n", removed, j.config.MessageIDRetention)
+			total += removed
+		}
+	}
+
+	// Expired inline keyboards are always removed
+	removed, err := j.callbackManager.CleanupExpired()
+	if err != nil {
+		fmt.Printf("[-] Janitor failed to cleanup callbacks: %v\n", err)
+	} else {
+		fmt.Printf("[#] Janitor removed %d expired callbacks\n", removed)
+		total += removed
+	}
+
+	for _, named := range j.cleaners {
+		removed, err := named.cleaner.CleanupExpired()
+		if err != nil {
+			fmt.Printf("[-] Janitor failed to cleanup %s: %v\n", named.name, err)
+			continue
+		}
+		fmt.Printf("[#] Janitor removed %d expired %s\n", removed, named.name)
+		total += removed
+	}
+
+	// Reclaim space if the backend needs it
+	rewrites := 0
+	if gc, ok := j.store.(storage.GarbageCollector); ok {
+		var err error
+		rewrites, err = gc.CollectGarbage(j.config.GCDiscardRatio)
+		if err != nil {
+			fmt.Printf("[-] Janitor garbage collection failed: %v\n", err)
+		}
+	}
+
+	fmt.Printf("[+] Janitor finished in %v: %d keys removed, %d value log files rewritten\n", time.Since(start), total, rewrites)
+}
@@ -0,0 +1,175 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"gobrev/src/storage"
+)
+
+const (
+	// karmaPerWeight is how much karma a voter needs for each extra point of vote weight
+	karmaPerWeight = 50
+	// karmaMaxWeight caps the weight of a single vote
+	karmaMaxWeight = 3
+)
+
+// KarmaStats is the karma of a chat member
+type KarmaStats struct {
+	UserID    int64  `json:"user_id"`
+	Username  string `json:"username"`
+	Karma     int    `json:"karma"`
+	Plus      int    `json:"plus"`
+	Minus     int    `json:"minus"`
+	UpdatedAt int64  `json:"updated_at"`
+}
+
+// KarmaManager stores karma per chat and user
+type KarmaManager struct {
+	store storage.Store
+}
+
+// NewKarmaManager creates a new karma manager
+func NewKarmaManager(store storage.Store) *KarmaManager {
+	return &KarmaManager{
+		store: store,
+	}
+}
+
+// KarmaWeight returns how many points a vote of a member with the given karma is worth
+func KarmaWeight(karma int) int {
+	weight := 1 + karma/karmaPerWeight
+	if weight < 1 {
+		return 1
+	}
+	if weight > karmaMaxWeight {
+		return karmaMaxWeight
+	}
+	return weight
+}
+
+// Get returns the karma of a user, zero stats if the user has no karma yet
+func (km *KarmaManager) Get(chatID, userID int64) KarmaStats {
+	stats, err := readKarma(km.store, chatID, userID)
+	if err != nil {
+		fmt.Printf("[-] Failed to load karma of user %d in chat %d: %v\n", userID, chatID, err)
+	}
+	return stats
+}
+
+// Vote changes the karma of target by the weight of the voter and returns the new stats and the weight
+func (km *KarmaManager) Vote(chatID, voterID, targetID int64, targetName string, up bool) (KarmaStats, int, error) {
+	var stats KarmaStats
+	weight := 0
+
+	err := km.store.Update(func(tx storage.Tx) error {
+		voter, err := readKarma(tx, chatID, voterID)
+		if err != nil {
+			return err
+		}
+		stats, err = readKarma(tx, chatID, targetID)
+		if err != nil {
+			return err
+		}
+
+		weight = KarmaWeight(voter.Karma)
+		if up {
+			stats.Karma += weight
+			stats.Plus++
+		} else {
+			stats.Karma -= weight
+			stats.Minus++
+		}
+		stats.UserID = targetID
+		stats.Username = targetName
+		stats.UpdatedAt = time.Now().Unix()
+
+		jsonData, err := json.Marshal(stats)
+		if err != nil {
+			return fmt.Errorf("failed to marshal karma: %w", err)
+		}
+		return tx.Set(karmaKey(chatID, targetID), jsonData)
+	})
+
+	return stats, weight, err
+}
+
+// Top returns members of a chat with the highest karma
+func (km *KarmaManager) Top(chatID int64, limit int) ([]KarmaStats, error) {
+	var users []KarmaStats
+
+	err := km.store.Scan(karmaChatPrefix(chatID), func(key string, val []byte) error {
+		var stats KarmaStats
+		if err := json.Unmarshal(val, &stats); err != nil {
+			return nil
+		}
+		if stats.Karma != 0 {
+			users = append(users, stats)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(users, func(i, j int) bool {
+		if users[i].Karma != users[j].Karma {
+			return users[i].Karma > users[j].Karma
+		}
+		return users[i].UpdatedAt < users[j].UpdatedAt
+	})
+
+	if limit > 0 && len(users) > limit {
+		users = users[:limit]
+	}
+
+	return users, nil
+}
+
+// DeleteUserKarma removes karma of a user in all chats and returns the number of deleted records
+func (km *KarmaManager) DeleteUserKarma(userID int64) (int, error) {
+	suffix := fmt.Sprintf("_%d", userID)
+	var keysToDelete []string
+
+	err := km.store.ScanKeys(karmaPrefix, func(key string) error {
+		if strings.HasSuffix(key, suffix) {
+			keysToDelete = append(keysToDelete, key)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if err := storage.DeleteKeys(km.store, keysToDelete); err != nil {
+		return 0, err
+	}
+
+	return len(keysToDelete), nil
+}
+
+// DeleteChatKarma removes karma of all members of a chat
+func (km *KarmaManager) DeleteChatKarma(chatID int64) (int, error) {
+	return storage.DeletePrefix(km.store, karmaChatPrefix(chatID))
+}
+
+// readKarma reads karma stats, missing records count as zero karma
+func readKarma(reader storage.Reader, chatID, userID int64) (KarmaStats, error) {
+	val, err := reader.Get(karmaKey(chatID, userID))
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return KarmaStats{UserID: userID}, nil
+		}
+		return KarmaStats{}, err
+	}
+
+	var stats KarmaStats
+	if err := json.Unmarshal(val, &stats); err != nil {
+		return KarmaStats{}, fmt.Errorf("failed to unmarshal karma: %w", err)
+	}
+	return stats, nil
+}
@@ -0,0 +1,78 @@
+package models
+
+import (
+	"testing"
+
+	"gobrev/src/storage"
+)
+
+func TestKarmaWeight(t *testing.T) {
+	tests := []struct {
+		karma int
+		want  int
+	}{
+		{-100, 1},
+		{0, 1},
+		{49, 1},
+		{50, 2},
+		{99, 2},
+		{100, 3},
+		{1000, 3},
+	}
+	for _, tt := range tests {
+		if got := KarmaWeight(tt.karma); got != tt.want {
+			t.Errorf("KarmaWeight(%d) = %d, want %d", tt.karma, got, tt.want)
+		}
+	}
+}
+
+func TestKarmaVote(t *testing.T) {
+	store := storage.NewMemoryStore()
+	km := NewKarmaManager(store)
+
+	// A voter with 120 karma gives votes of the maximum weight
+	store.Set(karmaKey(-100, 1), []byte(`{"user_id": 1, "karma": 120}`))
+
+	tests := []struct {
+		voter     int64
+		up        bool
+		wantKarma int
+		weight    int
+	}{
+		{1, true, 3, 3},
+		{3, true, 4, 1},
+		{3, false, 3, 1},
+		{1, false, 0, 3},
+	}
+	for _, tt := range tests {
+		stats, weight, err := km.Vote(-100, tt.voter, 2, "Борис", tt.up)
+		if err != nil {
+			t.Fatalf("Vote: %v", err)
+		}
+		if stats.Karma != tt.wantKarma || weight != tt.weight {
+			t.Errorf("Vote(voter %d, up %v) = karma %d weight %d, want %d and %d", tt.voter, tt.up, stats.Karma, weight, tt.wantKarma, tt.weight)
+		}
+	}
+
+	if stats := km.Get(-100, 2); stats.Plus != 2 || stats.Minus != 2 || stats.Username != "Борис" {
+		t.Errorf("Get = %+v, want 2 pluses and 2 minuses", stats)
+	}
+	if top, _ := km.Top(-100, 0); len(top) != 1 || top[0].UserID != 1 {
+		t.Errorf("Top = %+v, want the voter only: zero karma is not listed", top)
+	}
+}
+
+func TestKarmaDeleteUserKarma(t *testing.T) {
+	km := NewKarmaManager(storage.NewMemoryStore())
+	km.Vote(-100, 1, 2, "Борис", true)
+	km.Vote(-200, 1, 2, "Борис", true)
+	km.Vote(-100, 2, 12, "Вика", true)
+
+	deleted, err := km.DeleteUserKarma(2)
+	if err != nil || deleted != 2 {
+		t.Fatalf("DeleteUserKarma = %d, %v, want 2", deleted, err)
+	}
+	if stats := km.Get(-100, 12); stats.Karma != 1 {
+		t.Errorf("karma of user 12 = %d, want 1: suffix _2 must not match _12", stats.Karma)
+	}
+}
@@ -0,0 +1,278 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Key layouts used by the managers. All keys live in one shared store, so prefixes must stay unique.
+const (
+	statsUserPrefix     = "stats_user_"     // stats_user_<chat>_<user> -> UserStats
+	statsMsgPrefix      = "stats_msg_"      // stats_msg_<chat>_<date> -> MessageStats
+	statsWordPrefix     = "stats_word_"     // stats_word_<chat>_<date>_<word> -> int
+	reviewMsgPrefix     = "review_msg_"     // review_msg_<chat>_<user>_<unixnano> -> ReviewMessage
+	reviewTgPrefix      = "review_tg_"      // review_tg_<chat>_<telegram message id> -> review message ID
+	lastReviewPrefix    = "last_review_"    // last_review_<chat> -> unix timestamp
+	messageIDPrefix     = "msg_"            // msg_<message id> -> MessageIDData
+	privacyChatPrefix   = "privacy_chat_"   // privacy_chat_<chat>_<user> -> flag
+	privacyGlobalPrefix = "privacy_global_" // privacy_global_<user> -> flag
+	chatSettingsPrefix  = "settings_chat_"  // settings_chat_<chat> -> ChatSettings
+	callbackPrefix      = "callback_"       // callback_<id> -> CallbackData
+	warningPrefix       = "warn_"           // warn_<chat>_<user>_<unixnano> -> Warning
+	captchaPrefix       = "captcha_"        // captcha_<chat>_<user> -> Captcha
+	aiBudgetPrefix      = "ai_budget_"      // ai_budget_<chat>_<date> -> int
+	greetingPrefix      = "greeting_"       // greeting_<chat> -> Greeting
+	reminderPrefix      = "reminder_"       // reminder_<chat>_<id> -> Reminder
+	karmaPrefix         = "karma_"          // karma_<chat>_<user> -> KarmaStats
+	quotePrefix         = "quote_"          // quote_<chat>_<message id> -> Quote
+	quizPrefix          = "quiz_"           // quiz_<chat>_<user> -> QuizStats
+	dailyWinnerPrefix   = "daily_winner_"   // daily_winner_<chat>_<date> -> DailyWinner
+)
+
+// KeyPrefixes lists the key prefixes of all managers
+var KeyPrefixes = []string{
+	statsUserPrefix,
+	statsMsgPrefix,
+	statsWordPrefix,
+	reviewMsgPrefix,
+	reviewTgPrefix,
+	lastReviewPrefix,
+	messageIDPrefix,
+	privacyChatPrefix,
+	privacyGlobalPrefix,
+	chatSettingsPrefix,
+	callbackPrefix,
+	warningPrefix,
+	captchaPrefix,
+	aiBudgetPrefix,
+	greetingPrefix,
+	reminderPrefix,
+	karmaPrefix,
+	quotePrefix,
+	quizPrefix,
+	dailyWinnerPrefix,
+}
+
+// KeyBelongsToChat reports whether a stored record belongs to a chat.
+// Most keys carry the chat ID right after the prefix, AI message and callback records keep it in the value.
+func KeyBelongsToChat(key string, value []byte, chatID int64) bool {
+	if strings.HasPrefix(key, messageIDPrefix) {
+		var data MessageIDData
+		if err := json.Unmarshal(value, &data); err != nil {
+			return false
+		}
+		return data.ChatID == chatID
+	}
+
+	if strings.HasPrefix(key, callbackPrefix) {
+		var data CallbackData
+		if err := json.Unmarshal(value, &data); err != nil {
+			return false
+		}
+		return data.ChatID == chatID
+	}
+
+	if key == lastReviewKey(chatID) || key == chatSettingsKey(chatID) || key == greetingKey(chatID) {
+		return true
+	}
+
+	for _, prefix := range []string{statsUserPrefix, statsMsgPrefix, statsWordPrefix, reviewMsgPrefix, reviewTgPrefix, privacyChatPrefix, warningPrefix, captchaPrefix, aiBudgetPrefix, reminderPrefix, karmaPrefix, quotePrefix, quizPrefix, dailyWinnerPrefix} {
+		if strings.HasPrefix(key, fmt.Sprintf("%s%d_", prefix, chatID)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// statsUserKey returns the key of user stats in a chat
+func statsUserKey(chatID, userID int64) string {
+	return fmt.Sprintf("%s%d_%d", statsUserPrefix, chatID, userID)
+}
+
+// statsUserChatPrefix returns the prefix of all user stats in a chat
+func statsUserChatPrefix(chatID int64) string {
+	return fmt.Sprintf("%s%d_", statsUserPrefix, chatID)
+}
+
+// statsMsgKey returns the key of daily message stats
+func statsMsgKey(chatID int64, date string) string {
+	return fmt.Sprintf("%s%d_%s", statsMsgPrefix, chatID, date)
+}
+
+// statsMsgChatPrefix returns the prefix of all daily message stats of a chat
+func statsMsgChatPrefix(chatID int64) string {
+	return fmt.Sprintf("%s%d_", statsMsgPrefix, chatID)
+}
+
+// statsWordKey returns the key of a daily word counter
+func statsWordKey(chatID int64, date, word string) string {
+	return fmt.Sprintf("%s%d_%s_%s", statsWordPrefix, chatID, date, word)
+}
+
+// statsWordChatPrefix returns the prefix of all word counters of a chat
+func statsWordChatPrefix(chatID int64) string {
+	return fmt.Sprintf("%s%d_", statsWordPrefix, chatID)
+}
+
+// statsWordDayPrefix returns the prefix of all word counters of a chat for a day
+func statsWordDayPrefix(chatID int64, date string) string {
+	return fmt.Sprintf("%s%d_%s_", statsWordPrefix, chatID, date)
+}
+
+// statsDayKeyDate returns the date of a daily message stats or word counter key
+func statsDayKeyDate(key string) (string, bool) {
+	var rest string
+	switch {
+	case strings.HasPrefix(key, statsMsgPrefix):
+		rest = strings.TrimPrefix(key, statsMsgPrefix)
+	case strings.HasPrefix(key, statsWordPrefix):
+		rest = strings.TrimPrefix(key, statsWordPrefix)
+	default:
+		return "", false
+	}
+
+	// <chat>_<date> or <chat>_<date>_<word>
+	_, rest, ok := strings.Cut(rest, "_")
+	if !ok {
+		return "", false
+	}
+	date, _, _ := strings.Cut(rest, "_")
+	return date, date != ""
+}
+
+// reviewMsgKey returns the key of a review message
+func reviewMsgKey(messageID string) string {
+	return reviewMsgPrefix + messageID
+}
+
+// reviewMsgChatPrefix returns the prefix of all review messages of a chat
+func reviewMsgChatPrefix(chatID int64) string {
+	return fmt.Sprintf("%s%d_", reviewMsgPrefix, chatID)
+}
+
+// reviewTgKey returns the key of the index entry of a review message by its Telegram message ID
+func reviewTgKey(chatID int64, telegramID int) string {
+	return fmt.Sprintf("%s%d_%d", reviewTgPrefix, chatID, telegramID)
+}
+
+// reviewTgChatPrefix returns the prefix of the review message index of a chat
+func reviewTgChatPrefix(chatID int64) string {
+	return fmt.Sprintf("%s%d_", reviewTgPrefix, chatID)
+}
+
+// lastReviewKey returns the key of the last review timestamp for a chat
+func lastReviewKey(chatID int64) string {
+	return fmt.Sprintf("%s%d", lastReviewPrefix, chatID)
+}
+
+// messageIDKey returns the key of an AI message record
+func messageIDKey(messageID int) string {
+	return fmt.Sprintf("%s%d", messageIDPrefix, messageID)
+}
+
+// privacyChatKey returns the key of a chat-level opt-out flag
+func privacyChatKey(chatID, userID int64) string {
+	return fmt.Sprintf("%s%d_%d", privacyChatPrefix, chatID, userID)
+}
+
+// privacyGlobalKey returns the key of a global opt-out flag
+func privacyGlobalKey(userID int64) string {
+	return fmt.Sprintf("%s%d", privacyGlobalPrefix, userID)
+}
+
+// chatSettingsKey returns the key of chat settings
+func chatSettingsKey(chatID int64) string {
+	return fmt.Sprintf("%s%d", chatSettingsPrefix, chatID)
+}
+
+// callbackKey returns the key of a callback record
+func callbackKey(id string) string {
+	return callbackPrefix + id
+}
+
+// warningKey returns the key of a warning
+func warningKey(chatID, userID, unixNano int64) string {
+	return fmt.Sprintf("%s%d_%d_%d", warningPrefix, chatID, userID, unixNano)
+}
+
+// warningUserPrefix returns the prefix of all warnings of a user in a chat
+func warningUserPrefix(chatID, userID int64) string {
+	return fmt.Sprintf("%s%d_%d_", warningPrefix, chatID, userID)
+}
+
+// warningChatPrefix returns the prefix of all warnings in a chat
+func warningChatPrefix(chatID int64) string {
+	return fmt.Sprintf("%s%d_", warningPrefix, chatID)
+}
+
+// captchaKey returns the key of a pending join challenge
+func captchaKey(chatID, userID int64) string {
+	return fmt.Sprintf("%s%d_%d", captchaPrefix, chatID, userID)
+}
+
+// captchaChatPrefix returns the prefix of all join challenges in a chat
+func captchaChatPrefix(chatID int64) string {
+	return fmt.Sprintf("%s%d_", captchaPrefix, chatID)
+}
+
+// aiBudgetKey returns the key of the daily AI call counter of a chat
+func aiBudgetKey(chatID int64, date string) string {
+	return fmt.Sprintf("%s%d_%s", aiBudgetPrefix, chatID, date)
+}
+
+// greetingKey returns the key of chat welcome and goodbye templates
+func greetingKey(chatID int64) string {
+	return fmt.Sprintf("%s%d", greetingPrefix, chatID)
+}
+
+// reminderKey returns the key of a reminder
+func reminderKey(chatID int64, id string) string {
+	return fmt.Sprintf("%s%d_%s", reminderPrefix, chatID, id)
+}
+
+// reminderChatPrefix returns the prefix of all reminders in a chat
+func reminderChatPrefix(chatID int64) string {
+	return fmt.Sprintf("%s%d_", reminderPrefix, chatID)
+}
+
+// karmaKey returns the key of the karma of a chat member
+func karmaKey(chatID, userID int64) string {
+	return fmt.Sprintf("%s%d_%d", karmaPrefix, chatID, userID)
+}
+
+// karmaChatPrefix returns the prefix of all karma records in a chat
+func karmaChatPrefix(chatID int64) string {
+	return fmt.Sprintf("%s%d_", karmaPrefix, chatID)
+}
+
+// quoteKey returns the key of a saved quote
+func quoteKey(chatID int64, messageID int) string {
+	return fmt.Sprintf("%s%d_%d", quotePrefix, chatID, messageID)
+}
+
+// quoteChatPrefix returns the prefix of the quote book of a chat
+func quoteChatPrefix(chatID int64) string {
+	return fmt.Sprintf("%s%d_", quotePrefix, chatID)
+}
+
+// quizKey returns the key of the quiz record of a chat member
+func quizKey(chatID, userID int64) string {
+	return fmt.Sprintf("%s%d_%d", quizPrefix, chatID, userID)
+}
+
+// quizChatPrefix returns the prefix of the quiz leaderboard of a chat
+func quizChatPrefix(chatID int64) string {
+	return fmt.Sprintf("%s%d_", quizPrefix, chatID)
+}
+
+// dailyWinnerKey returns the key of the user of the day of a chat
+func dailyWinnerKey(chatID int64, date string) string {
+	return fmt.Sprintf("%s%d_%s", dailyWinnerPrefix, chatID, date)
+}
+
+// dailyWinnerChatPrefix returns the prefix of the winner history of a chat
+func dailyWinnerChatPrefix(chatID int64) string {
+	return fmt.Sprintf("%s%d_", dailyWinnerPrefix, chatID)
+}
@@ -0,0 +1,24 @@
+package models
+
+import "testing"
+
+func TestStatsDayKeyDate(t *testing.T) {
+	tests := []struct {
+		key  string
+		want string
+		ok   bool
+	}{
+		{statsMsgKey(-100, "2026-10-18"), "2026-10-18", true},
+		{statsWordKey(-100, "2026-10-18", "cats"), "2026-10-18", true},
+		{statsWordKey(42, "2026-01-02", "with_underscore"), "2026-01-02", true},
+		{statsUserKey(-100, 1), "", false},
+		{reviewMsgKey("-100_1_1"), "", false},
+		{statsMsgPrefix + "-100", "", false},
+	}
+	for _, tt := range tests {
+		date, ok := statsDayKeyDate(tt.key)
+		if date != tt.want || ok != tt.ok {
+			t.Errorf("statsDayKeyDate(%q) = %q, %v, want %q, %v", tt.key, date, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -5,12 +5,12 @@ import (
 	"fmt"
 	"time"
 
-	"github.com/dgraph-io/badger/v4"
+	"gobrev/src/storage"
 )
 
 // MessageIDManager manages message IDs for AI responses
 type MessageIDManager struct {
-	db *badger.DB
+	store storage.Store
 }
 
 // MessageIDData represents data stored for a message ID
@@ -20,40 +20,29 @@ type MessageIDData struct {
 	ChatID      int64  `json:"chat_id"`      // Chat where message was sent
 	Timestamp   int64  `json:"timestamp"`   // When message was sent
 	Content    string `json:"content"`      // Message content (for debugging)
+	Prompt      string `json:"prompt,omitempty"` // User message the AI answered, used to regenerate
+	PhotoID     string `json:"photo_id,omitempty"` // Telegram file ID of the photo sent with the prompt
+	PartIDs     []int  `json:"part_ids,omitempty"` // All messages of an answer split into parts, in order
 }
 
 // NewMessageIDManager creates a new message ID manager
-func NewMessageIDManager(dbPath string) (*MessageIDManager, error) {
-	// Open BadgerDB
-	opts := badger.DefaultOptions(dbPath)
-	opts.Logger = nil // Disable logging
-	
-	db, err := badger.Open(opts)
-	if err != nil {
-		return nil, fmt.Errorf("failed to open BadgerDB: %w", err)
-	}
-	
+func NewMessageIDManager(store storage.Store) *MessageIDManager {
 	return &MessageIDManager{
-		db: db,
-	}, nil
-}
-
-// Close closes the database connection
-func (mim *MessageIDManager) Close() error {
-	if mim.db != nil {
-		return mim.db.Close()
+		store: store,
 	}
-	return nil
 }
 
 // StoreMessageID stores a message ID for an AI response
-func (mim *MessageIDManager) StoreMessageID(messageID int, userID, chatID int64, content string) error {
+func (mim *MessageIDManager) StoreMessageID(messageID int, userID, chatID int64, prompt, photoID, content string, partIDs []int) error {
 	data := MessageIDData{
 		MessageID:  messageID,
 		UserID:     userID,
 		ChatID:     chatID,
 		Timestamp:  time.Now().Unix(),
 		Content:    content,
+		Prompt:     prompt,
+		PhotoID:    photoID,
+		PartIDs:    partIDs,
 	}
 	
 	jsonData, err := json.Marshal(data)
@@ -61,30 +50,18 @@ func (mim *MessageIDManager) StoreMessageID(messageID int, userID, chatID int64,
 		return fmt.Errorf("failed to marshal message ID data: %w", err)
 	}
 	
-	key := fmt.Sprintf("msg_%d", messageID)
-	
-	return mim.db.Update(func(txn *badger.Txn) error {
-		return txn.Set([]byte(key), jsonData)
-	})
+	return mim.store.Set(messageIDKey(messageID), jsonData)
 }
 
 // GetMessageIDData retrieves message ID data
 func (mim *MessageIDManager) GetMessageIDData(messageID int) (*MessageIDData, error) {
-	key := fmt.Sprintf("msg_%d", messageID)
+	val, err := mim.store.Get(messageIDKey(messageID))
+	if err != nil {
+		return nil, err
+	}
 	
 	var data MessageIDData
-	err := mim.db.View(func(txn *badger.Txn) error {
-		item, err := txn.Get([]byte(key))
-		if err != nil {
-			return err
-		}
-		
-		return item.Value(func(val []byte) error {
-			return json.Unmarshal(val, &data)
-		})
-	})
-	
-	if err != nil {
+	if err := json.Unmarshal(val, &data); err != nil {
 		return nil, err
 	}
 	
@@ -99,79 +76,67 @@ func (mim *MessageIDManager) IsAIMessage(messageID int) bool {
 
 // DeleteMessageID removes a message ID from storage
 func (mim *MessageIDManager) DeleteMessageID(messageID int) error {
-	key := fmt.Sprintf("msg_%d", messageID)
+	return mim.store.Delete(messageIDKey(messageID))
+}
+
+// CleanupOldMessages removes message IDs older than specified duration and returns the number of deleted records
+func (mim *MessageIDManager) CleanupOldMessages(maxAge time.Duration) (int, error) {
+	cutoff := time.Now().Add(-maxAge).Unix()
 	
-	return mim.db.Update(func(txn *badger.Txn) error {
-		return txn.Delete([]byte(key))
+	return mim.deleteWhere(func(data MessageIDData) bool {
+		return data.Timestamp < cutoff
 	})
 }
 
-// CleanupOldMessages removes message IDs older than specified duration
-func (mim *MessageIDManager) CleanupOldMessages(maxAge time.Duration) error {
-	cutoff := time.Now().Add(-maxAge).Unix()
+// DeleteUserMessages removes all AI message records addressed to a user and returns the number of deleted records
+func (mim *MessageIDManager) DeleteUserMessages(userID int64) (int, error) {
+	return mim.deleteWhere(func(data MessageIDData) bool {
+		return data.UserID == userID
+	})
+}
+
+// DeleteChatMessages removes all AI message records of a chat and returns the number of deleted records
+func (mim *MessageIDManager) DeleteChatMessages(chatID int64) (int, error) {
+	return mim.deleteWhere(func(data MessageIDData) bool {
+		return data.ChatID == chatID
+	})
+}
+
+// deleteWhere removes all records matching the predicate
+func (mim *MessageIDManager) deleteWhere(match func(data MessageIDData) bool) (int, error) {
+	var keysToDelete []string
 	
-	return mim.db.Update(func(txn *badger.Txn) error {
-		opts := badger.DefaultIteratorOptions
-		opts.PrefetchValues = false
-		
-		it := txn.NewIterator(opts)
-		defer it.Close()
+	err := mim.store.Scan(messageIDPrefix, func(key string, val []byte) error {
+		var data MessageIDData
+		if err := json.Unmarshal(val, &data); err != nil {
+			return err
+		}
 		
-		for it.Rewind(); it.Valid(); it.Next() {
-			item := it.Item()
-			key := item.Key()
-			
-			// Check if this is a message ID key
-			if len(key) > 4 && string(key[:4]) == "msg_" {
-				err := item.Value(func(val []byte) error {
-					var data MessageIDData
-					if err := json.Unmarshal(val, &data); err != nil {
-						return err
-					}
-					
-					// Delete if older than cutoff
-					if data.Timestamp < cutoff {
-						return txn.Delete(key)
-					}
-					
-					return nil
-				})
-				
-				if err != nil {
-					return err
-				}
-			}
+		if match(data) {
+			keysToDelete = append(keysToDelete, key)
 		}
 		
 		return nil
 	})
+	if err != nil {
+		return 0, err
+	}
+	
+	if err := storage.DeleteKeys(mim.store, keysToDelete); err != nil {
+		return 0, err
+	}
+	
+	return len(keysToDelete), nil
 }
 
 // GetMessageCount returns the number of stored message IDs
 func (mim *MessageIDManager) GetMessageCount() (int, error) {
 	count := 0
 	
-	err := mim.db.View(func(txn *badger.Txn) error {
-		opts := badger.DefaultIteratorOptions
-		opts.PrefetchValues = false
-		
-		it := txn.NewIterator(opts)
-		defer it.Close()
-		
-		for it.Rewind(); it.Valid(); it.Next() {
-			key := it.Item().Key()
-			if len(key) > 4 && string(key[:4]) == "msg_" {
-				count++
-			}
-		}
-		
+	err := mim.store.ScanKeys(messageIDPrefix, func(key string) error {
+		count++
 		return nil
 	})
 	
 	return count, err
 }
-
-// GetDB returns the underlying BadgerDB instance
-func (mim *MessageIDManager) GetDB() *badger.DB {
-	return mim.db
-}
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"fmt"
+
+	"gobrev/src/storage"
+)
+
+// Migrations returns all data layout migrations in order.
+// Append new migrations at the end and never change released ones.
+func Migrations() []storage.Migration {
+	return []storage.Migration{
+		{
+			Version:     1,
+			Description: "baseline key layout (stats_*, review_msg_*, last_review_*, msg_*, privacy_*)",
+			Up: func(store storage.Store) error {
+				// Existing databases already use this layout, only the version is recorded
+				return nil
+			},
+		},
+		{
+			Version:     2,
+			Description: "index review messages by Telegram message ID (review_tg_*) for quotes",
+			Up: func(store storage.Store) error {
+				count, err := IndexReviewMessages(store)
+				if err != nil {
+					return err
+				}
+				fmt.Printf("[+] Indexed %d review messages\n", count)
+				return nil
+			},
+		},
+	}
+}
@@ -0,0 +1,54 @@
+package models
+
+import (
+	"gobrev/src/storage"
+)
+
+// PrivacyManager stores per-user opt-out preferences
+type PrivacyManager struct {
+	store storage.Store
+}
+
+// NewPrivacyManager creates a new privacy manager
+func NewPrivacyManager(store storage.Store) *PrivacyManager {
+	return &PrivacyManager{
+		store: store,
+	}
+}
+
+// SetChatOptOut enables or disables message storage opt-out for a user in a single chat
+func (pm *PrivacyManager) SetChatOptOut(chatID, userID int64, optOut bool) error {
+	return pm.setFlag(privacyChatKey(chatID, userID), optOut)
+}
+
+// SetGlobalOptOut enables or disables message storage opt-out for a user in all chats
+func (pm *PrivacyManager) SetGlobalOptOut(userID int64, optOut bool) error {
+	return pm.setFlag(privacyGlobalKey(userID), optOut)
+}
+
+// IsOptedOut checks if a user opted out in the given chat or globally
+func (pm *PrivacyManager) IsOptedOut(chatID, userID int64) bool {
+	chatOptOut, globalOptOut := pm.GetStatus(chatID, userID)
+	return chatOptOut || globalOptOut
+}
+
+// GetStatus returns the chat-level and global opt-out flags for a user
+func (pm *PrivacyManager) GetStatus(chatID, userID int64) (chatOptOut bool, globalOptOut bool) {
+	chatOptOut = pm.hasFlag(privacyChatKey(chatID, userID))
+	globalOptOut = pm.hasFlag(privacyGlobalKey(userID))
+	return chatOptOut, globalOptOut
+}
+
+// setFlag stores or removes a boolean flag key
+func (pm *PrivacyManager) setFlag(key string, enabled bool) error {
+	if !enabled {
+		return pm.store.Delete(key)
+	}
+	return pm.store.Set(key, []byte("1"))
+}
+
+// hasFlag checks if a flag key exists
+func (pm *PrivacyManager) hasFlag(key string) bool {
+	_, err := pm.store.Get(key)
+	return err == nil
+}
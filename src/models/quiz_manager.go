@@ -0,0 +1,136 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"gobrev/src/storage"
+)
+
+// QuizStats is the quiz record of a chat member
+type QuizStats struct {
+	UserID    int64  `json:"user_id"`
+	Username  string `json:"username"`
+	Correct   int    `json:"correct"`
+	Answered  int    `json:"answered"`
+	Wins      int    `json:"wins"`
+	UpdatedAt int64  `json:"updated_at"`
+}
+
+// QuizManager stores the quiz leaderboard of each chat
+type QuizManager struct {
+	store storage.Store
+}
+
+// NewQuizManager creates a new quiz manager
+func NewQuizManager(store storage.Store) *QuizManager {
+	return &QuizManager{
+		store: store,
+	}
+}
+
+// RecordAnswer counts an answer of a member
+func (qm *QuizManager) RecordAnswer(chatID, userID int64, username string, correct bool) error {
+	return qm.update(chatID, userID, username, func(stats *QuizStats) {
+		stats.Answered++
+		if correct {
+			stats.Correct++
+		}
+	})
+}
+
+// AddWin counts a won game of a member
+func (qm *QuizManager) AddWin(chatID, userID int64, username string) error {
+	return qm.update(chatID, userID, username, func(stats *QuizStats) {
+		stats.Wins++
+	})
+}
+
+// Top returns members of a chat with the most correct answers
+func (qm *QuizManager) Top(chatID int64, limit int) ([]QuizStats, error) {
+	var users []QuizStats
+
+	err := qm.store.Scan(quizChatPrefix(chatID), func(key string, val []byte) error {
+		var stats QuizStats
+		if err := json.Unmarshal(val, &stats); err != nil {
+			return nil
+		}
+		users = append(users, stats)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(users, func(i, j int) bool {
+		if users[i].Correct != users[j].Correct {
+			return users[i].Correct > users[j].Correct
+		}
+		return users[i].Wins > users[j].Wins
+	})
+
+	if limit > 0 && len(users) > limit {
+		users = users[:limit]
+	}
+
+	return users, nil
+}
+
+// DeleteUserStats removes quiz records of a user in all chats and returns the number of deleted records
+func (qm *QuizManager) DeleteUserStats(userID int64) (int, error) {
+	suffix := fmt.Sprintf("_%d", userID)
+	var keysToDelete []string
+
+	err := qm.store.ScanKeys(quizPrefix, func(key string) error {
+		if strings.HasSuffix(key, suffix) {
+			keysToDelete = append(keysToDelete, key)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if err := storage.DeleteKeys(qm.store, keysToDelete); err != nil {
+		return 0, err
+	}
+
+	return len(keysToDelete), nil
+}
+
+// DeleteChatQuiz removes the quiz leaderboard of a chat
+func (qm *QuizManager) DeleteChatQuiz(chatID int64) (int, error) {
+	return storage.DeletePrefix(qm.store, quizChatPrefix(chatID))
+}
+
+// update changes the record of a member in a transaction
+func (qm *QuizManager) update(chatID, userID int64, username string, change func(stats *QuizStats)) error {
+	key := quizKey(chatID, userID)
+
+	return qm.store.Update(func(tx storage.Tx) error {
+		stats := QuizStats{UserID: userID}
+		val, err := tx.Get(key)
+		switch {
+		case err == nil:
+			if err := json.Unmarshal(val, &stats); err != nil {
+				return fmt.Errorf("failed to unmarshal quiz stats: %w", err)
+			}
+		case !errors.Is(err, storage.ErrNotFound):
+			return err
+		}
+
+		change(&stats)
+		stats.Username = username
+		stats.UpdatedAt = time.Now().Unix()
+
+		jsonData, err := json.Marshal(stats)
+		if err != nil {
+			return fmt.Errorf("failed to marshal quiz stats: %w", err)
+		}
+		return tx.Set(key, jsonData)
+	})
+}
@@ -0,0 +1,121 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+
+	"gobrev/src/storage"
+)
+
+// QuoteLine is one quoted message
+type QuoteLine struct {
+	UserID    int64  `json:"user_id"`
+	Username  string `json:"username"`
+	Text      string `json:"text"`
+	Timestamp int64  `json:"timestamp"`
+}
+
+// Quote is a saved quote of one or several consecutive messages
+type Quote struct {
+	ChatID    int64       `json:"chat_id"`
+	MessageID int         `json:"message_id"` // Telegram ID of the first quoted message
+	Lines     []QuoteLine `json:"lines"`
+	SavedBy   int64       `json:"saved_by"`
+	CreatedAt int64       `json:"created_at"`
+}
+
+// HasUser reports whether the quote contains messages of a user
+func (q Quote) HasUser(userID int64) bool {
+	for _, line := range q.Lines {
+		if line.UserID == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// QuoteManager stores the quote book of each chat
+type QuoteManager struct {
+	store storage.Store
+}
+
+// NewQuoteManager creates a new quote manager
+func NewQuoteManager(store storage.Store) *QuoteManager {
+	return &QuoteManager{
+		store: store,
+	}
+}
+
+// Save stores a quote. Quoting the same first message again replaces the old quote.
+func (qm *QuoteManager) Save(quote Quote) error {
+	jsonData, err := json.Marshal(quote)
+	if err != nil {
+		return fmt.Errorf("failed to marshal quote: %w", err)
+	}
+
+	return qm.store.Set(quoteKey(quote.ChatID, quote.MessageID), jsonData)
+}
+
+// Random returns a random quote of a chat and the size of the quote book
+func (qm *QuoteManager) Random(chatID int64) (Quote, int, error) {
+	var keys []string
+	err := qm.store.ScanKeys(quoteChatPrefix(chatID), func(key string) error {
+		keys = append(keys, key)
+		return nil
+	})
+	if err != nil || len(keys) == 0 {
+		return Quote{}, 0, err
+	}
+
+	val, err := qm.store.Get(keys[rand.Intn(len(keys))])
+	if err != nil {
+		return Quote{}, len(keys), err
+	}
+
+	var quote Quote
+	if err := json.Unmarshal(val, &quote); err != nil {
+		return Quote{}, len(keys), fmt.Errorf("failed to unmarshal quote: %w", err)
+	}
+	return quote, len(keys), nil
+}
+
+// Count returns the number of quotes in the quote book of a chat
+func (qm *QuoteManager) Count(chatID int64) int {
+	count := 0
+	err := qm.store.ScanKeys(quoteChatPrefix(chatID), func(key string) error {
+		count++
+		return nil
+	})
+	if err != nil {
+		fmt.Printf("[-] Failed to count quotes in chat %d: %v\n", chatID, err)
+	}
+	return count
+}
+
+// DeleteUserQuotes removes quotes containing messages of a user in all chats and returns the number of deleted quotes
+func (qm *QuoteManager) DeleteUserQuotes(userID int64) (int, error) {
+	var keysToDelete []string
+
+	err := qm.store.Scan(quotePrefix, func(key string, val []byte) error {
+		var quote Quote
+		if err := json.Unmarshal(val, &quote); err != nil || quote.HasUser(userID) {
+			keysToDelete = append(keysToDelete, key)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if err := storage.DeleteKeys(qm.store, keysToDelete); err != nil {
+		return 0, err
+	}
+
+	return len(keysToDelete), nil
+}
+
+// DeleteChatQuotes removes the quote book of a chat
+func (qm *QuoteManager) DeleteChatQuotes(chatID int64) (int, error) {
+	return storage.DeletePrefix(qm.store, quoteChatPrefix(chatID))
+}
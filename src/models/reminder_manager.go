@@ -0,0 +1,164 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"gobrev/src/storage"
+)
+
+// Reminder repeat modes
+const (
+	RepeatNone     = ""
+	RepeatDaily    = "daily"
+	RepeatWeekdays = "weekdays"
+	RepeatWeekly   = "weekly"
+)
+
+// MaxRemindersPerUser limits active reminders of a user in a chat
+const MaxRemindersPerUser = 20
+
+// Reminder is a scheduled message for a chat member
+type Reminder struct {
+	ID        string `json:"id"`
+	ChatID    int64  `json:"chat_id"`
+	UserID    int64  `json:"user_id"`
+	UserName  string `json:"user_name"`
+	Text      string `json:"text"`
+	MessageID int    `json:"message_id"` // Command message the reminder replies to
+	At        int64  `json:"at"`         // Next delivery time
+	Repeat    string `json:"repeat"`     // Repeat mode, empty for one-off reminders
+	Weekday   int    `json:"weekday"`    // Day of weekly reminders
+	Hour      int    `json:"hour"`       // Time of day of recurring reminders
+	Minute    int    `json:"minute"`
+	Timezone  string `json:"timezone"` // Chat timezone when the reminder was set
+	CreatedAt int64  `json:"created_at"`
+}
+
+// Location returns the reminder timezone
+func (r Reminder) Location() *time.Location {
+	if location, err := time.LoadLocation(r.Timezone); err == nil {
+		return location
+	}
+	return time.UTC
+}
+
+// Next returns the first delivery of a recurring reminder after the given time, zero for one-off reminders
+func (r Reminder) Next(after time.Time) time.Time {
+	if r.Repeat == RepeatNone {
+		return time.Time{}
+	}
+
+	local := after.In(r.Location())
+	for day := 0; day <= 7; day++ {
+		candidate := time.Date(local.Year(), local.Month(), local.Day()+day, r.Hour, r.Minute, 0, 0, local.Location())
+		if !candidate.After(local) {
+			continue
+		}
+
+		weekday := candidate.Weekday()
+		switch {
+		case r.Repeat == RepeatDaily,
+			r.Repeat == RepeatWeekdays && weekday != time.Saturday && weekday != time.Sunday,
+			r.Repeat == RepeatWeekly && int(weekday) == r.Weekday:
+			return candidate
+		}
+	}
+
+	return time.Time{}
+}
+
+// ReminderManager stores reminders per chat
+type ReminderManager struct {
+	store storage.Store
+}
+
+// NewReminderManager creates a new reminder manager
+func NewReminderManager(store storage.Store) *ReminderManager {
+	return &ReminderManager{
+		store: store,
+	}
+}
+
+// Add stores a new reminder, assigning its ID
+func (rm *ReminderManager) Add(reminder *Reminder) error {
+	if len(rm.List(reminder.ChatID, reminder.UserID)) >= MaxRemindersPerUser {
+		return fmt.Errorf("не больше %d напоминаний на человека", MaxRemindersPerUser)
+	}
+
+	now := time.Now()
+	reminder.ID = strconv.FormatInt(now.UnixNano(), 36)
+	reminder.CreatedAt = now.Unix()
+	return rm.Save(*reminder)
+}
+
+// Save stores a reminder
+func (rm *ReminderManager) Save(reminder Reminder) error {
+	jsonData, err := json.Marshal(reminder)
+	if err != nil {
+		return fmt.Errorf("failed to marshal reminder: %w", err)
+	}
+	return rm.store.Set(reminderKey(reminder.ChatID, reminder.ID), jsonData)
+}
+
+// Delete removes a reminder
+func (rm *ReminderManager) Delete(chatID int64, id string) error {
+	return rm.store.Delete(reminderKey(chatID, id))
+}
+
+// List returns reminders of a user in a chat, soonest first
+func (rm *ReminderManager) List(chatID, userID int64) []Reminder {
+	var reminders []Reminder
+
+	err := rm.store.Scan(reminderChatPrefix(chatID), func(key string, val []byte) error {
+		var reminder Reminder
+		if err := json.Unmarshal(val, &reminder); err != nil {
+			return nil
+		}
+		if reminder.UserID == userID {
+			reminders = append(reminders, reminder)
+		}
+		return nil
+	})
+	if err != nil {
+		fmt.Printf("[-] Failed to load reminders of user %d in chat %d: %v\n", userID, chatID, err)
+	}
+
+	sortReminders(reminders)
+	return reminders
+}
+
+// Due returns reminders of all chats whose time has come, oldest first
+func (rm *ReminderManager) Due(now time.Time) ([]Reminder, error) {
+	var reminders []Reminder
+
+	err := rm.store.Scan(reminderPrefix, func(key string, val []byte) error {
+		var reminder Reminder
+		if err := json.Unmarshal(val, &reminder); err != nil {
+			fmt.Printf("[-] Skipping broken reminder record %s: %v\n", key, err)
+			return nil
+		}
+		if reminder.At <= now.Unix() {
+			reminders = append(reminders, reminder)
+		}
+		return nil
+	})
+
+	sortReminders(reminders)
+	return reminders, err
+}
+
+// DeleteChatReminders removes all reminders of a chat
+func (rm *ReminderManager) DeleteChatReminders(chatID int64) (int, error) {
+	return storage.DeletePrefix(rm.store, reminderChatPrefix(chatID))
+}
+
+// sortReminders orders reminders by delivery time
+func sortReminders(reminders []Reminder) {
+	sort.Slice(reminders, func(i, j int) bool {
+		return reminders[i].At < reminders[j].At
+	})
+}
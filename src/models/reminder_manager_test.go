@@ -0,0 +1,51 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestReminderNext(t *testing.T) {
+	// Wednesday, 12:00 UTC
+	now := time.Date(2026, 10, 14, 12, 0, 0, 0, time.UTC)
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2026, 10, day, hour, minute, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name     string
+		reminder Reminder
+		after    time.Time
+		want     time.Time
+	}{
+		{"one-off", Reminder{Hour: 18}, now, time.Time{}},
+		{"daily later today", Reminder{Repeat: RepeatDaily, Hour: 18, Minute: 30}, now, at(14, 18, 30)},
+		{"daily passed today", Reminder{Repeat: RepeatDaily, Hour: 9}, now, at(15, 9, 0)},
+		{"daily at this minute", Reminder{Repeat: RepeatDaily, Hour: 12}, now, at(15, 12, 0)},
+		{"weekdays midweek", Reminder{Repeat: RepeatWeekdays, Hour: 8}, now, at(15, 8, 0)},
+		{"weekdays skip weekend", Reminder{Repeat: RepeatWeekdays, Hour: 8}, at(16, 9, 0), at(19, 8, 0)},
+		{"weekly later this week", Reminder{Repeat: RepeatWeekly, Weekday: int(time.Friday), Hour: 18}, now, at(16, 18, 0)},
+		{"weekly same day passed", Reminder{Repeat: RepeatWeekly, Weekday: int(time.Wednesday), Hour: 9}, now, at(21, 9, 0)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.reminder.Next(tt.after); !got.Equal(tt.want) {
+				t.Errorf("Next(%s) = %s, want %s", tt.after, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReminderNextInTimezone(t *testing.T) {
+	moscow, err := time.LoadLocation("Europe/Moscow")
+	if err != nil {
+		t.Skip("no timezone database")
+	}
+	reminder := Reminder{Repeat: RepeatDaily, Hour: 9, Timezone: "Europe/Moscow"}
+
+	// 07:00 UTC is already 10:00 in Moscow
+	got := reminder.Next(time.Date(2026, 10, 14, 7, 0, 0, 0, time.UTC))
+	if want := time.Date(2026, 10, 15, 9, 0, 0, 0, moscow); !got.Equal(want) {
+		t.Errorf("Next = %s, want %s", got, want)
+	}
+}
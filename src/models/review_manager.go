@@ -2,21 +2,23 @@ package models
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strconv"
 	"time"
 
-	"github.com/dgraph-io/badger/v4"
+	"gobrev/src/storage"
 )
 
 // ReviewManager manages messages for daily review generation
 type ReviewManager struct {
-	db *badger.DB
+	store storage.Store
 }
 
 // ReviewMessage represents a message stored for review
 type ReviewMessage struct {
 	MessageID     string `json:"message_id"`     // Unique message identifier
+	TelegramID    int    `json:"telegram_id,omitempty"` // Telegram message ID in the chat
 	ChatID        int64  `json:"chat_id"`        // Chat where message was sent
 	UserID        int64  `json:"user_id"`        // User who sent the message
 	Username      string `json:"username"`       // Username of the sender
@@ -28,22 +30,24 @@ type ReviewMessage struct {
 	ReplyToMessageID string `json:"reply_to_message_id,omitempty"` // ID of message being replied to
 	ReplyToUsername  string `json:"reply_to_username,omitempty"`   // Username of original message author
 	ReplyToContent   string `json:"reply_to_content,omitempty"`    // Content of original message
+	ReplyToUserID    int64  `json:"reply_to_user_id,omitempty"`    // User ID of original message author
 }
 
 // NewReviewManager creates a new review manager
-func NewReviewManager(db *badger.DB) *ReviewManager {
+func NewReviewManager(store storage.Store) *ReviewManager {
 	return &ReviewManager{
-		db: db,
+		store: store,
 	}
 }
 
 // AddMessage adds a message to the review database
-func (rm *ReviewManager) AddMessage(chatID, userID int64, username, content string, replyToMessageID, replyToUsername, replyToContent string) error {
+func (rm *ReviewManager) AddMessage(chatID, userID int64, telegramID int, username, content string, replyToMessageID, replyToUsername, replyToContent string, replyToUserID int64) error {
 	now := time.Now()
 	messageID := fmt.Sprintf("%d_%d_%d", chatID, userID, now.UnixNano())
 	
 	message := ReviewMessage{
 		MessageID:        messageID,
+		TelegramID:       telegramID,
 		ChatID:           chatID,
 		UserID:           userID,
 		Username:         username,
@@ -53,6 +57,7 @@ func (rm *ReviewManager) AddMessage(chatID, userID int64, username, content stri
 		ReplyToMessageID: replyToMessageID,
 		ReplyToUsername:  replyToUsername,
 		ReplyToContent:   replyToContent,
+		ReplyToUserID:    replyToUserID,
 	}
 	
 	jsonData, err := json.Marshal(message)
@@ -60,10 +65,16 @@ func (rm *ReviewManager) AddMessage(chatID, userID int64, username, content stri
 		return fmt.Errorf("failed to marshal review message: %w", err)
 	}
 	
-	key := fmt.Sprintf("review_msg_%s", messageID)
-	
-	return rm.db.Update(func(txn *badger.Txn) error {
-		return txn.Set([]byte(key), jsonData)
+	return rm.store.Update(func(tx storage.Tx) error {
+		if err := tx.Set(reviewMsgKey(messageID), jsonData); err != nil {
+			return err
+		}
+		
+		// Index by Telegram message ID to find the messages that follow one
+		if telegramID == 0 {
+			return nil
+		}
+		return tx.Set(reviewTgKey(chatID, telegramID), []byte(messageID))
 	})
 }
 
@@ -71,33 +82,15 @@ func (rm *ReviewManager) AddMessage(chatID, userID int64, username, content stri
 func (rm *ReviewManager) GetUnusedMessages(chatID int64, limit int) ([]ReviewMessage, error) {
 	var messages []ReviewMessage
 	
-	err := rm.db.View(func(txn *badger.Txn) error {
-		opts := badger.DefaultIteratorOptions
-		opts.Prefix = []byte("review_msg_")
-		
-		it := txn.NewIterator(opts)
-		defer it.Close()
+	err := rm.store.Scan(reviewMsgChatPrefix(chatID), func(key string, val []byte) error {
+		var message ReviewMessage
+		if err := json.Unmarshal(val, &message); err != nil {
+			return err
+		}
 		
-		for it.Rewind(); it.Valid(); it.Next() {
-			item := it.Item()
-			
-			err := item.Value(func(val []byte) error {
-				var message ReviewMessage
-				if err := json.Unmarshal(val, &message); err != nil {
-					return err
-				}
-				
-				// Filter by chat ID and unused status
-				if message.ChatID == chatID && !message.UsedForReview {
-					messages = append(messages, message)
-				}
-				
-				return nil
-			})
-			
-			if err != nil {
-				return err
-			}
+		// Filter by chat ID and unused status
+		if message.ChatID == chatID && !message.UsedForReview {
+			messages = append(messages, message)
 		}
 		
 		return nil
@@ -137,43 +130,21 @@ func (rm *ReviewManager) GetMessagesAfterLastReview(chatID int64, limit int) ([]
 	
 	var messages []ReviewMessage
 	
-	err = rm.db.View(func(txn *badger.Txn) error {
-		opts := badger.DefaultIteratorOptions
-		opts.Prefix = []byte("review_msg_")
-		
-		it := txn.NewIterator(opts)
-		defer it.Close()
+	err = rm.store.Scan(reviewMsgChatPrefix(chatID), func(key string, val []byte) error {
+		var message ReviewMessage
+		if err := json.Unmarshal(val, &message); err != nil {
+			return err
+		}
 		
-		for it.Rewind(); it.Valid(); it.Next() {
-			item := it.Item()
-			
-			err := item.Value(func(val []byte) error {
-				var message ReviewMessage
-				if err := json.Unmarshal(val, &message); err != nil {
-					return err
-				}
-				
-				// Filter by chat ID and timestamp after last review
-				if message.ChatID == chatID {
-					if message.Timestamp > lastReviewTime {
-						fmt.Printf("[+] Including message from %s at %s: %s\n", 
-							message.Username, 
-							time.Unix(message.Timestamp, 0).Format("15:04:05"),
-							message.Content[:min(50, len(message.Content))])
-						fmt.Printf("[+] Including message from %s at %s: %.50s\n", message.Username, time.Unix(message.Timestamp, 0).Format("15:04:05"), message.Content)
-						messages = append(messages, message)
-					} else {
-						fmt.Printf("[-] Skipping old message from %s at %s\n", 
-							message.Username, 
-							time.Unix(message.Timestamp, 0).Format("15:04:05"))
-					}
-				}
-				
-				return nil
-			})
-			
-			if err != nil {
-				return err
+		// Filter by chat ID and timestamp after last review
+		if message.ChatID == chatID {
+			if message.Timestamp > lastReviewTime {
+				fmt.Printf("[+] Including message from %s at %s: %.50s\n", message.Username, time.Unix(message.Timestamp, 0).Format("15:04:05"), message.Content)
+				messages = append(messages, message)
+			} else {
+				fmt.Printf("[-] Skipping old message from %s at %s\n", 
+					message.Username, 
+					time.Unix(message.Timestamp, 0).Format("15:04:05"))
 			}
 		}
 		
@@ -202,54 +173,75 @@ func (rm *ReviewManager) GetMessagesAfterLastReview(chatID int64, limit int) ([]
 	return messages, nil
 }
 
+// GetMessagesFrom returns up to limit consecutive messages of a chat starting at a Telegram message ID.
+// It stops at the first message that isn't stored, like a command, media or a message of an opted-out member.
+func (rm *ReviewManager) GetMessagesFrom(chatID int64, telegramID, limit int) ([]ReviewMessage, error) {
+	var messages []ReviewMessage
+	
+	err := rm.store.View(func(tx storage.Reader) error {
+		for id := telegramID; len(messages) < limit; id++ {
+			messageID, err := tx.Get(reviewTgKey(chatID, id))
+			if errors.Is(err, storage.ErrNotFound) {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			
+			val, err := tx.Get(reviewMsgKey(string(messageID)))
+			if errors.Is(err, storage.ErrNotFound) {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			
+			var message ReviewMessage
+			if err := json.Unmarshal(val, &message); err != nil {
+				return nil
+			}
+			messages = append(messages, message)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	
+	return messages, nil
+}
+
 // SetLastReviewTime sets the timestamp of the last review for a chat
 func (rm *ReviewManager) SetLastReviewTime(chatID int64, timestamp int64) error {
-	key := fmt.Sprintf("last_review_%d", chatID)
 	value := fmt.Sprintf("%d", timestamp)
 	
-	return rm.db.Update(func(txn *badger.Txn) error {
-		return txn.Set([]byte(key), []byte(value))
-	})
+	return rm.store.Set(lastReviewKey(chatID), []byte(value))
 }
 
 // GetLastReviewTime gets the timestamp of the last review for a chat
 func (rm *ReviewManager) GetLastReviewTime(chatID int64) (int64, error) {
-	key := fmt.Sprintf("last_review_%d", chatID)
-	var timestamp int64
-	
-	err := rm.db.View(func(txn *badger.Txn) error {
-		item, err := txn.Get([]byte(key))
-		if err != nil {
-			return err
-		}
-		
-		return item.Value(func(val []byte) error {
-			var parseErr error
-			timestamp, parseErr = strconv.ParseInt(string(val), 10, 64)
-			return parseErr
-		})
-	})
+	val, err := rm.store.Get(lastReviewKey(chatID))
+	if err != nil {
+		return 0, err
+	}
 	
-	return timestamp, err
+	return strconv.ParseInt(string(val), 10, 64)
 }
 
 // MarkMessagesAsUsed marks messages as used for review
 func (rm *ReviewManager) MarkMessagesAsUsed(messageIDs []string) error {
-	return rm.db.Update(func(txn *badger.Txn) error {
+	return rm.store.Update(func(tx storage.Tx) error {
 		for _, messageID := range messageIDs {
-			key := fmt.Sprintf("review_msg_%s", messageID)
+			key := reviewMsgKey(messageID)
 			
 			// Get existing message
-			item, err := txn.Get([]byte(key))
+			val, err := tx.Get(key)
 			if err != nil {
 				continue // Skip if message not found
 			}
 			
 			var message ReviewMessage
-			err = item.Value(func(val []byte) error {
-				return json.Unmarshal(val, &message)
-			})
-			if err != nil {
+			if err := json.Unmarshal(val, &message); err != nil {
 				continue
 			}
 			
@@ -262,7 +254,7 @@ func (rm *ReviewManager) MarkMessagesAsUsed(messageIDs []string) error {
 				continue
 			}
 			
-			if err := txn.Set([]byte(key), jsonData); err != nil {
+			if err := tx.Set(key, jsonData); err != nil {
 				return err
 			}
 		}
@@ -271,87 +263,167 @@ func (rm *ReviewManager) MarkMessagesAsUsed(messageIDs []string) error {
 	})
 }
 
-// CleanupOldMessages removes messages older than specified days
-func (rm *ReviewManager) CleanupOldMessages(maxDays int) error {
+// CleanupOldMessages removes messages older than specified days and returns the number of deleted messages
+func (rm *ReviewManager) CleanupOldMessages(maxDays int) (int, error) {
 	cutoff := time.Now().AddDate(0, 0, -maxDays).Unix()
+	var keysToDelete, indexToDelete []string
 	
-	return rm.db.Update(func(txn *badger.Txn) error {
-		opts := badger.DefaultIteratorOptions
-		opts.Prefix = []byte("review_msg_")
+	err := rm.store.Scan(reviewMsgPrefix, func(key string, val []byte) error {
+		var message ReviewMessage
+		if err := json.Unmarshal(val, &message); err != nil {
+			return err
+		}
 		
-		it := txn.NewIterator(opts)
-		defer it.Close()
+		// Delete if older than cutoff
+		if message.Timestamp < cutoff {
+			keysToDelete = append(keysToDelete, key)
+			indexToDelete = append(indexToDelete, reviewIndexKeys(message)...)
+		}
+		
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	
+	// Delete old messages
+	if err := storage.DeleteKeys(rm.store, append(keysToDelete, indexToDelete...)); err != nil {
+		return 0, err
+	}
+	
+	return len(keysToDelete), nil
+}
+
+// DeleteUserMessages removes all messages sent by a user and scrubs quotes of their messages in replies.
+// Returns the number of deleted messages.
+func (rm *ReviewManager) DeleteUserMessages(userID int64) (int, error) {
+	var keysToDelete, indexToDelete []string
+	scrubbed := make(map[string][]byte)
+	
+	err := rm.store.Scan(reviewMsgPrefix, func(key string, val []byte) error {
+		var message ReviewMessage
+		if err := json.Unmarshal(val, &message); err != nil {
+			return err
+		}
 		
-		var keysToDelete [][]byte
+		if message.UserID == userID {
+			keysToDelete = append(keysToDelete, key)
+			indexToDelete = append(indexToDelete, reviewIndexKeys(message)...)
+			return nil
+		}
 		
-		for it.Rewind(); it.Valid(); it.Next() {
-			item := it.Item()
-			key := item.Key()
-			
-			err := item.Value(func(val []byte) error {
-				var message ReviewMessage
-				if err := json.Unmarshal(val, &message); err != nil {
-					return err
-				}
-				
-				// Delete if older than cutoff
-				if message.Timestamp < cutoff {
-					keysToDelete = append(keysToDelete, append([]byte(nil), key...))
-				}
-				
-				return nil
-			})
-			
+		// Other users' replies keep the reply but lose the quoted content
+		if message.ReplyToUserID == userID {
+			message.ReplyToUsername = ""
+			message.ReplyToContent = ""
+			jsonData, err := json.Marshal(message)
 			if err != nil {
 				return err
 			}
+			scrubbed[key] = jsonData
 		}
 		
-		// Delete old messages
-		for _, key := range keysToDelete {
-			if err := txn.Delete(key); err != nil {
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	
+	if err := storage.DeleteKeys(rm.store, append(keysToDelete, indexToDelete...)); err != nil {
+		return 0, err
+	}
+	
+	err = rm.store.Batch(func(w storage.Writer) error {
+		for key, value := range scrubbed {
+			if err := w.Set(key, value); err != nil {
 				return err
 			}
 		}
-		
 		return nil
 	})
+	if err != nil {
+		return 0, err
+	}
+	
+	return len(keysToDelete), nil
+}
+
+// DeleteChatMessages removes all messages and the last review time of a chat and returns the number of deleted messages
+func (rm *ReviewManager) DeleteChatMessages(chatID int64) (int, error) {
+	count, err := storage.DeletePrefix(rm.store, reviewMsgChatPrefix(chatID))
+	if err != nil {
+		return count, err
+	}
+	
+	if _, err := storage.DeletePrefix(rm.store, reviewTgChatPrefix(chatID)); err != nil {
+		return count, err
+	}
+	
+	if err := rm.store.Delete(lastReviewKey(chatID)); err != nil {
+		return count, err
+	}
+	
+	return count, nil
 }
 
 // GetMessageCount returns the number of unused messages for a chat
 func (rm *ReviewManager) GetMessageCount(chatID int64) (int, error) {
 	count := 0
 	
-	err := rm.db.View(func(txn *badger.Txn) error {
-		opts := badger.DefaultIteratorOptions
-		opts.Prefix = []byte("review_msg_")
+	err := rm.store.Scan(reviewMsgChatPrefix(chatID), func(key string, val []byte) error {
+		var message ReviewMessage
+		if err := json.Unmarshal(val, &message); err != nil {
+			return err
+		}
 		
-		it := txn.NewIterator(opts)
-		defer it.Close()
+		if message.ChatID == chatID && !message.UsedForReview {
+			count++
+		}
 		
-		for it.Rewind(); it.Valid(); it.Next() {
-			item := it.Item()
-			
-			err := item.Value(func(val []byte) error {
-				var message ReviewMessage
-				if err := json.Unmarshal(val, &message); err != nil {
-					return err
-				}
-				
-				if message.ChatID == chatID && !message.UsedForReview {
-					count++
-				}
-				
-				return nil
-			})
-			
-			if err != nil {
+		return nil
+	})
+	
+	return count, err
+}
+
+// reviewIndexKeys returns the index keys of a stored message
+func reviewIndexKeys(message ReviewMessage) []string {
+	if message.TelegramID == 0 {
+		return nil
+	}
+	return []string{reviewTgKey(message.ChatID, message.TelegramID)}
+}
+
+// IndexReviewMessages indexes stored review messages by Telegram message ID and returns the number of indexed messages.
+// Used by the migration that introduced the index.
+func IndexReviewMessages(store storage.Store) (int, error) {
+	index := make(map[string][]byte)
+	
+	err := store.Scan(reviewMsgPrefix, func(key string, val []byte) error {
+		var message ReviewMessage
+		if err := json.Unmarshal(val, &message); err != nil {
+			return nil
+		}
+		for _, indexKey := range reviewIndexKeys(message) {
+			index[indexKey] = []byte(message.MessageID)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	
+	err = store.Batch(func(w storage.Writer) error {
+		for key, value := range index {
+			if err := w.Set(key, value); err != nil {
 				return err
 			}
 		}
-		
 		return nil
 	})
+	if err != nil {
+		return 0, err
+	}
 	
-	return count, err
+	return len(index), nil
 }
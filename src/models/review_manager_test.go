@@ -0,0 +1,196 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"gobrev/src/storage"
+)
+
+// addReview stores a message and fails the test on error
+func addReview(t *testing.T, rm *ReviewManager, chatID, userID int64, telegramID int, username, content string) {
+	t.Helper()
+	if err := rm.AddMessage(chatID, userID, telegramID, username, content, "", "", "", 0); err != nil {
+		t.Fatalf("AddMessage: %v", err)
+	}
+}
+
+func TestReviewManagerUnusedMessages(t *testing.T) {
+	rm := NewReviewManager(storage.NewMemoryStore())
+	addReview(t, rm, -100, 1, 10, "Аня", "первое")
+	addReview(t, rm, -100, 1, 11, "Аня", "второе")
+	addReview(t, rm, -100, 1, 12, "Аня", "третье")
+	addReview(t, rm, -200, 2, 10, "Борис", "другой чат")
+
+	messages, err := rm.GetUnusedMessages(-100, 0)
+	if err != nil || len(messages) != 3 {
+		t.Fatalf("GetUnusedMessages = %d messages, %v, want 3", len(messages), err)
+	}
+	if messages[0].Content != "первое" || messages[2].Content != "третье" {
+		t.Errorf("GetUnusedMessages order = %q..%q, want oldest first", messages[0].Content, messages[2].Content)
+	}
+
+	if err := rm.MarkMessagesAsUsed([]string{messages[0].MessageID, "missing"}); err != nil {
+		t.Fatalf("MarkMessagesAsUsed: %v", err)
+	}
+
+	tests := []struct {
+		chatID int64
+		limit  int
+		want   int
+	}{
+		{-100, 0, 2},
+		{-100, 1, 1},
+		{-200, 0, 1},
+		{-300, 0, 0},
+	}
+	for _, tt := range tests {
+		unused, err := rm.GetUnusedMessages(tt.chatID, tt.limit)
+		if err != nil || len(unused) != tt.want {
+			t.Errorf("GetUnusedMessages(%d, %d) = %d, %v, want %d", tt.chatID, tt.limit, len(unused), err, tt.want)
+		}
+	}
+	if count, _ := rm.GetMessageCount(-100); count != 2 {
+		t.Errorf("GetMessageCount = %d, want 2", count)
+	}
+}
+
+func TestReviewManagerAfterLastReview(t *testing.T) {
+	rm := NewReviewManager(storage.NewMemoryStore())
+	addReview(t, rm, -100, 1, 10, "Аня", "до ревью")
+
+	// Without a review every unused message is returned
+	if messages, _ := rm.GetMessagesAfterLastReview(-100, 0); len(messages) != 1 {
+		t.Fatalf("first review got %d messages, want 1", len(messages))
+	}
+
+	if err := rm.SetLastReviewTime(-100, time.Now().Unix()); err != nil {
+		t.Fatalf("SetLastReviewTime: %v", err)
+	}
+	if last, err := rm.GetLastReviewTime(-100); err != nil || last == 0 {
+		t.Fatalf("GetLastReviewTime = %d, %v", last, err)
+	}
+	if messages, _ := rm.GetMessagesAfterLastReview(-100, 0); len(messages) != 0 {
+		t.Errorf("got %d messages older than the last review, want 0", len(messages))
+	}
+
+	rm.SetLastReviewTime(-100, time.Now().Add(-time.Hour).Unix())
+	if messages, _ := rm.GetMessagesAfterLastReview(-100, 0); len(messages) != 1 {
+		t.Errorf("got %d messages after an hour old review, want 1", len(messages))
+	}
+}
+
+func TestReviewManagerMessagesFrom(t *testing.T) {
+	rm := NewReviewManager(storage.NewMemoryStore())
+	addReview(t, rm, -100, 2, 12, "Борис", "c")
+	addReview(t, rm, -100, 1, 10, "Аня", "a")
+	addReview(t, rm, -100, 1, 11, "Аня", "b")
+	addReview(t, rm, -100, 1, 13, "Аня", "d")
+	addReview(t, rm, -100, 1, 15, "Аня", "f")
+	addReview(t, rm, -200, 1, 14, "Аня", "другой чат")
+
+	tests := []struct {
+		from  int
+		limit int
+		want  string
+	}{
+		{11, 2, "bc"},
+		{10, 10, "abcd"},
+		{13, 5, "d"},
+		{14, 5, ""},
+		{15, 5, "f"},
+		{10, 0, ""},
+	}
+	for _, tt := range tests {
+		messages, err := rm.GetMessagesFrom(-100, tt.from, tt.limit)
+		if err != nil {
+			t.Fatalf("GetMessagesFrom: %v", err)
+		}
+		got := ""
+		for _, message := range messages {
+			got += message.Content
+		}
+		if got != tt.want {
+			t.Errorf("GetMessagesFrom(%d, %d) = %q, want %q", tt.from, tt.limit, got, tt.want)
+		}
+	}
+}
+
+func TestReviewManagerDeleteUserMessages(t *testing.T) {
+	rm := NewReviewManager(storage.NewMemoryStore())
+	addReview(t, rm, -100, 1, 10, "Аня", "секрет")
+	addReview(t, rm, -200, 1, 10, "Аня", "ещё секрет")
+	if err := rm.AddMessage(-100, 2, 11, "Борис", "ответ", "x", "Аня", "секрет", 1); err != nil {
+		t.Fatalf("AddMessage: %v", err)
+	}
+
+	deleted, err := rm.DeleteUserMessages(1)
+	if err != nil || deleted != 2 {
+		t.Fatalf("DeleteUserMessages = %d, %v, want 2", deleted, err)
+	}
+
+	messages, _ := rm.GetUnusedMessages(-100, 0)
+	if len(messages) != 1 {
+		t.Fatalf("%d messages left in chat, want the reply only", len(messages))
+	}
+	if reply := messages[0]; reply.ReplyToContent != "" || reply.ReplyToUsername != "" || reply.Content != "ответ" {
+		t.Errorf("reply kept the quote of a deleted user: %+v", reply)
+	}
+}
+
+func TestReviewManagerCleanupAndDeleteChat(t *testing.T) {
+	store := storage.NewMemoryStore()
+	rm := NewReviewManager(store)
+	addReview(t, rm, -100, 1, 10, "Аня", "свежее")
+	addReview(t, rm, -200, 1, 10, "Аня", "другой чат")
+	store.Set(reviewMsgKey("-100_1_1"), []byte(`{"chat_id": -100, "user_id": 1, "timestamp": 1}`))
+	rm.SetLastReviewTime(-100, time.Now().Unix())
+
+	removed, err := rm.CleanupOldMessages(7)
+	if err != nil || removed != 1 {
+		t.Fatalf("CleanupOldMessages = %d, %v, want 1", removed, err)
+	}
+
+	deleted, err := rm.DeleteChatMessages(-100)
+	if err != nil || deleted != 1 {
+		t.Fatalf("DeleteChatMessages = %d, %v, want 1", deleted, err)
+	}
+	if _, err := rm.GetLastReviewTime(-100); err == nil {
+		t.Errorf("last review time survived DeleteChatMessages")
+	}
+	if count, _ := rm.GetMessageCount(-200); count != 1 {
+		t.Errorf("DeleteChatMessages(-100) touched chat -200")
+	}
+}
+
+func TestReviewManagerIndex(t *testing.T) {
+	store := storage.NewMemoryStore()
+	rm := NewReviewManager(store)
+
+	// Messages stored before the index get it from the migration
+	store.Set(reviewMsgKey("-100_1_1"), []byte(`{"message_id": "-100_1_1", "telegram_id": 10, "chat_id": -100, "user_id": 1, "content": "a"}`))
+	store.Set(reviewMsgKey("-100_2_2"), []byte(`{"message_id": "-100_2_2", "chat_id": -100, "user_id": 2, "content": "без id"}`))
+	addReview(t, rm, -100, 2, 11, "Борис", "b")
+
+	if messages, _ := rm.GetMessagesFrom(-100, 10, 5); len(messages) != 0 {
+		t.Fatalf("found %d messages before indexing, want 0", len(messages))
+	}
+	if indexed, err := IndexReviewMessages(store); err != nil || indexed != 2 {
+		t.Fatalf("IndexReviewMessages = %d, %v, want 2", indexed, err)
+	}
+	if messages, _ := rm.GetMessagesFrom(-100, 10, 5); len(messages) != 2 {
+		t.Fatalf("found %d messages after indexing, want 2", len(messages))
+	}
+
+	// Deleted messages leave no index entries behind
+	rm.DeleteUserMessages(1)
+	rm.DeleteChatMessages(-100)
+	keys := 0
+	store.ScanKeys(reviewTgPrefix, func(key string) error {
+		keys++
+		return nil
+	})
+	if keys != 0 {
+		t.Errorf("%d index keys left after deleting all messages", keys)
+	}
+}
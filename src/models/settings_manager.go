@@ -0,0 +1,291 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gobrev/src/storage"
+)
+
+// ChatSettings holds per-chat bot configuration
+type ChatSettings struct {
+	ChatID        int64    `json:"chat_id"`
+	TriggerWords  []string `json:"trigger_words"`   // Words that make the bot answer with AI
+	AITemperature float64  `json:"ai_temperature"`  // Sampling temperature for AI answers
+	AIMaxTokens   int      `json:"ai_max_tokens"`   // Answer length limit for AI answers
+	StatsTopUsers int      `json:"stats_top_users"` // Number of users listed in .стат
+	ReviewPrompt  string   `json:"review_prompt"`   // Extra instructions for .рев
+	Timezone      string   `json:"timezone"`        // IANA timezone name
+	SpamAction    string   `json:"spam_action"`     // Anti-spam action, empty when anti-spam is off
+	FloodMessages int      `json:"flood_messages"`  // Messages a user may send within FloodSeconds
+	FloodSeconds  int      `json:"flood_seconds"`   // Flood detection window
+	MuteMinutes   int      `json:"mute_minutes"`    // Mute length of the mute action
+	SpamAllowList []string `json:"spam_allow_list"` // User IDs and link domains anti-spam ignores
+	WarnLimit     int      `json:"warn_limit"`      // Active warnings that trigger WarnAction
+	WarnAction    string   `json:"warn_action"`     // Escalation on reaching WarnLimit: mute or ban
+	WarnDays      int      `json:"warn_days"`       // How long a warning stays active
+	Captcha       bool     `json:"captcha"`         // Newcomers must solve a challenge
+	CaptchaSecs   int      `json:"captcha_secs"`    // Time to solve it before being kicked
+	AIModeration  string   `json:"ai_moderation"`   // AI moderation mode, empty when it's off
+	AIModLogChat  int64    `json:"ai_mod_log_chat"` // Chat that receives AI moderation reports
+	AIModMinScore float64  `json:"ai_mod_score"`    // Confidence needed to act on a verdict
+	AIModDaily    int      `json:"ai_mod_daily"`    // AI moderation calls per day
+	UpdatedAt     int64    `json:"updated_at"`
+}
+
+// Anti-spam actions
+const (
+	SpamActionOff    = ""
+	SpamActionWarn   = "warn"
+	SpamActionDelete = "delete"
+	SpamActionMute   = "mute"
+)
+
+// Warning escalations
+const (
+	WarnActionMute = "mute"
+	WarnActionBan  = "ban"
+)
+
+// AI moderation modes
+const (
+	AIModerationOff    = ""
+	AIModerationReport = "report"
+	AIModerationDelete = "delete"
+)
+
+// SpamActions lists anti-spam actions in menu order
+var SpamActions = []string{SpamActionOff, SpamActionWarn, SpamActionDelete, SpamActionMute}
+
+// Setting limits
+const (
+	MinAITemperature = 0.0
+	MaxAITemperature = 1.5
+	MinAIMaxTokens   = 200
+	MaxAIMaxTokens   = 8000
+	MinStatsTopUsers = 3
+	MaxStatsTopUsers = 50
+	MaxTriggerWords  = 20
+	MaxReviewPrompt  = 500
+	MinFloodMessages = 2
+	MaxFloodMessages = 50
+	MinFloodSeconds  = 1
+	MaxFloodSeconds  = 300
+	MinMuteMinutes   = 1
+	MaxMuteMinutes   = 7 * 24 * 60
+	MaxSpamAllowList = 50
+	MinWarnLimit     = 1
+	MaxWarnLimit     = 10
+	MinWarnDays      = 1
+	MaxWarnDays      = 365
+	MinCaptchaSecs   = 30
+	MaxCaptchaSecs   = 600
+	MinAIModMinScore = 0.5
+	MaxAIModMinScore = 1.0
+	MinAIModDaily    = 1
+	MaxAIModDaily    = 500
+)
+
+// DefaultChatSettings returns settings used when a chat hasn't changed anything
+func DefaultChatSettings(chatID int64) ChatSettings {
+	return ChatSettings{
+		ChatID:        chatID,
+		TriggerWords:  []string{"брев"},
+		AITemperature: 1,
+		AIMaxTokens:   900,
+		StatsTopUsers: 20,
+		ReviewPrompt:  "",
+		Timezone:      "Europe/Moscow",
+		SpamAction:    SpamActionOff,
+		FloodMessages: 5,
+		FloodSeconds:  10,
+		MuteMinutes:   30,
+		WarnLimit:     3,
+		WarnAction:    WarnActionMute,
+		WarnDays:      30,
+		Captcha:       false,
+		CaptchaSecs:   120,
+		AIModeration:  AIModerationOff,
+		AIModMinScore: 0.8,
+		AIModDaily:    50,
+	}
+}
+
+// Validate checks that all values are within limits
+func (s ChatSettings) Validate() error {
+	if len(s.TriggerWords) == 0 {
+		return errors.New("нужно хотя бы одно слово-триггер")
+	}
+	if len(s.TriggerWords) > MaxTriggerWords {
+		return fmt.Errorf("слишком много триггеров, максимум %d", MaxTriggerWords)
+	}
+	if s.AITemperature < MinAITemperature || s.AITemperature > MaxAITemperature {
+		return fmt.Errorf("температура должна быть от %.1f до %.1f", MinAITemperature, MaxAITemperature)
+	}
+	if s.AIMaxTokens < MinAIMaxTokens || s.AIMaxTokens > MaxAIMaxTokens {
+		return fmt.Errorf("лимит токенов должен быть от %d до %d", MinAIMaxTokens, MaxAIMaxTokens)
+	}
+	if s.StatsTopUsers < MinStatsTopUsers || s.StatsTopUsers > MaxStatsTopUsers {
+		return fmt.Errorf("число юзеров в статистике должно быть от %d до %d", MinStatsTopUsers, MaxStatsTopUsers)
+	}
+	if len([]rune(s.ReviewPrompt)) > MaxReviewPrompt {
+		return fmt.Errorf("пожелания к ревью не длиннее %d символов", MaxReviewPrompt)
+	}
+	if _, err := time.LoadLocation(s.Timezone); err != nil {
+		return fmt.Errorf("неизвестный часовой пояс %q, пример: Europe/Moscow", s.Timezone)
+	}
+	if !containsString(SpamActions, s.SpamAction) {
+		return fmt.Errorf("неизвестное действие антиспама %q", s.SpamAction)
+	}
+	if s.FloodMessages < MinFloodMessages || s.FloodMessages > MaxFloodMessages {
+		return fmt.Errorf("лимит флуда должен быть от %d до %d сообщений", MinFloodMessages, MaxFloodMessages)
+	}
+	if s.FloodSeconds < MinFloodSeconds || s.FloodSeconds > MaxFloodSeconds {
+		return fmt.Errorf("окно флуда должно быть от %d до %d секунд", MinFloodSeconds, MaxFloodSeconds)
+	}
+	if s.MuteMinutes < MinMuteMinutes || s.MuteMinutes > MaxMuteMinutes {
+		return fmt.Errorf("мут должен быть от %d минуты до %d дней", MinMuteMinutes, MaxMuteMinutes/(24*60))
+	}
+	if len(s.SpamAllowList) > MaxSpamAllowList {
+		return fmt.Errorf("слишком большой белый список, максимум %d", MaxSpamAllowList)
+	}
+	if s.WarnLimit < MinWarnLimit || s.WarnLimit > MaxWarnLimit {
+		return fmt.Errorf("лимит варнов должен быть от %d до %d", MinWarnLimit, MaxWarnLimit)
+	}
+	if s.WarnAction != WarnActionMute && s.WarnAction != WarnActionBan {
+		return fmt.Errorf("неизвестное наказание за варны %q", s.WarnAction)
+	}
+	if s.WarnDays < MinWarnDays || s.WarnDays > MaxWarnDays {
+		return fmt.Errorf("срок варна должен быть от %d до %d дней", MinWarnDays, MaxWarnDays)
+	}
+	if s.CaptchaSecs < MinCaptchaSecs || s.CaptchaSecs > MaxCaptchaSecs {
+		return fmt.Errorf("время на капчу должно быть от %d до %d секунд", MinCaptchaSecs, MaxCaptchaSecs)
+	}
+	if s.AIModeration != AIModerationOff && s.AIModeration != AIModerationReport && s.AIModeration != AIModerationDelete {
+		return fmt.Errorf("неизвестный режим ИИ-модерации %q", s.AIModeration)
+	}
+	if s.AIModeration == AIModerationReport && s.AIModLogChat == 0 {
+		return errors.New("для отчётов ИИ-модерации нужен лог-чат")
+	}
+	if s.AIModMinScore < MinAIModMinScore || s.AIModMinScore > MaxAIModMinScore {
+		return fmt.Errorf("порог ИИ-модерации должен быть от %.0f%% до %.0f%%", MinAIModMinScore*100, MaxAIModMinScore*100)
+	}
+	if s.AIModDaily < MinAIModDaily || s.AIModDaily > MaxAIModDaily {
+		return fmt.Errorf("лимит ИИ-модерации должен быть от %d до %d проверок в день", MinAIModDaily, MaxAIModDaily)
+	}
+	return nil
+}
+
+// Location returns the chat timezone, falling back to the default one
+func (s ChatSettings) Location() *time.Location {
+	if location, err := time.LoadLocation(s.Timezone); err == nil {
+		return location
+	}
+	location, err := time.LoadLocation(DefaultChatSettings(s.ChatID).Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return location
+}
+
+// ContainsTrigger checks if text contains any trigger word (case insensitive)
+func (s ChatSettings) ContainsTrigger(text string) bool {
+	text = strings.ToLower(text)
+	for _, word := range s.TriggerWords {
+		if word != "" && strings.Contains(text, strings.ToLower(word)) {
+			return true
+		}
+	}
+	return false
+}
+
+// SpamAllowed checks if a user ID or link domain is in the anti-spam allow-list
+func (s ChatSettings) SpamAllowed(entry string) bool {
+	entry = strings.ToLower(entry)
+	for _, allowed := range s.SpamAllowList {
+		if entry == allowed || strings.HasSuffix(entry, "."+allowed) {
+			return true
+		}
+	}
+	return false
+}
+
+// containsString checks if list contains value
+func containsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+// SettingsManager stores per-chat settings
+type SettingsManager struct {
+	store storage.Store
+}
+
+// NewSettingsManager creates a new settings manager
+func NewSettingsManager(store storage.Store) *SettingsManager {
+	return &SettingsManager{
+		store: store,
+	}
+}
+
+// Get returns chat settings, with defaults for anything not stored.
+// Errors are logged and defaults returned so commands never fail because of settings.
+func (sm *SettingsManager) Get(chatID int64) ChatSettings {
+	settings := DefaultChatSettings(chatID)
+
+	val, err := sm.store.Get(chatSettingsKey(chatID))
+	if err != nil {
+		if !errors.Is(err, storage.ErrNotFound) {
+			fmt.Printf("[-] Failed to load settings for chat %d: %v\n", chatID, err)
+		}
+		return settings
+	}
+
+	// Unmarshal over defaults so fields added later keep their default values
+	if err := json.Unmarshal(val, &settings); err != nil {
+		fmt.Printf("[-] Failed to decode settings for chat %d: %v\n", chatID, err)
+		return DefaultChatSettings(chatID)
+	}
+	settings.ChatID = chatID
+
+	return settings
+}
+
+// Save validates and stores chat settings
+func (sm *SettingsManager) Save(settings ChatSettings) error {
+	if err := settings.Validate(); err != nil {
+		return err
+	}
+
+	settings.UpdatedAt = time.Now().Unix()
+	jsonData, err := json.Marshal(settings)
+	if err != nil {
+		return fmt.Errorf("failed to marshal settings: %w", err)
+	}
+
+	return sm.store.Set(chatSettingsKey(settings.ChatID), jsonData)
+}
+
+// Update applies fn to the chat settings and saves them if they are valid
+func (sm *SettingsManager) Update(chatID int64, fn func(settings *ChatSettings)) (ChatSettings, error) {
+	settings := sm.Get(chatID)
+	fn(&settings)
+
+	if err := sm.Save(settings); err != nil {
+		return sm.Get(chatID), err
+	}
+
+	return settings, nil
+}
+
+// Reset restores default settings for a chat
+func (sm *SettingsManager) Reset(chatID int64) error {
+	return sm.store.Delete(chatSettingsKey(chatID))
+}
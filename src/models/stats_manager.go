@@ -3,21 +3,23 @@ package models
 import (
 	"encoding/json"
 	"fmt"
+	"sort"
 	"strings"
 	"time"
 
-	"github.com/dgraph-io/badger/v4"
+	"gobrev/src/storage"
 )
 
-// StatsManager manages chat statistics using BadgerDB
+// StatsManager manages chat statistics
 type StatsManager struct {
-	db *badger.DB
+	store storage.Store
 }
 
 // UserStats represents user statistics
 type UserStats struct {
 	UserID       int64  `json:"user_id"`
 	Username     string `json:"username"`
+	Handle       string `json:"handle,omitempty"` // Telegram @username without "@"
 	MessageCount int    `json:"message_count"`
 	LastSeen     int64  `json:"last_seen"`
 }
@@ -37,14 +39,14 @@ type WordStats struct {
 }
 
 // NewStatsManager creates a new stats manager
-func NewStatsManager(db *badger.DB) *StatsManager {
+func NewStatsManager(store storage.Store) *StatsManager {
 	return &StatsManager{
-		db: db,
+		store: store,
 	}
 }
 
 // AddMessage adds a message to statistics
-func (sm *StatsManager) AddMessage(chatID, userID int64, username, text string) error {
+func (sm *StatsManager) AddMessage(chatID, userID int64, username, handle, text string) error {
 	now := time.Now()
 	date := now.Format("2006-01-02")
 	
@@ -62,28 +64,27 @@ func (sm *StatsManager) AddMessage(chatID, userID int64, username, text string)
 	// Extract words (3+ characters, letters only)
 	words := extractWords(cleanText)
 	
-	return sm.db.Update(func(txn *badger.Txn) error {
+	return sm.store.Update(func(tx storage.Tx) error {
 		// Update user stats
-		userKey := fmt.Sprintf("stats_user_%d_%d", chatID, userID)
+		userKey := statsUserKey(chatID, userID)
 		var userStats UserStats
 		
-		item, err := txn.Get([]byte(userKey))
+		val, err := tx.Get(userKey)
 		if err == nil {
 			// User exists, update stats
-			err = item.Value(func(val []byte) error {
-				return json.Unmarshal(val, &userStats)
-			})
-			if err != nil {
+			if err := json.Unmarshal(val, &userStats); err != nil {
 				return err
 			}
 			userStats.MessageCount++
 			userStats.Username = cleanUsername
+			userStats.Handle = handle
 			userStats.LastSeen = now.Unix()
 		} else {
 			// New user
 			userStats = UserStats{
 				UserID:       userID,
 				Username:     cleanUsername,
+				Handle:       handle,
 				MessageCount: 1,
 				LastSeen:     now.Unix(),
 			}
@@ -94,20 +95,17 @@ func (sm *StatsManager) AddMessage(chatID, userID int64, username, text string)
 			return err
 		}
 		
-		if err := txn.Set([]byte(userKey), userData); err != nil {
+		if err := tx.Set(userKey, userData); err != nil {
 			return err
 		}
 		
 		// Update daily message count
-		msgKey := fmt.Sprintf("stats_msg_%d_%s", chatID, date)
+		msgKey := statsMsgKey(chatID, date)
 		var msgStats MessageStats
 		
-		item, err = txn.Get([]byte(msgKey))
+		val, err = tx.Get(msgKey)
 		if err == nil {
-			err = item.Value(func(val []byte) error {
-				return json.Unmarshal(val, &msgStats)
-			})
-			if err != nil {
+			if err := json.Unmarshal(val, &msgStats); err != nil {
 				return err
 			}
 		}
@@ -121,21 +119,18 @@ func (sm *StatsManager) AddMessage(chatID, userID int64, username, text string)
 			return err
 		}
 		
-		if err := txn.Set([]byte(msgKey), msgData); err != nil {
+		if err := tx.Set(msgKey, msgData); err != nil {
 			return err
 		}
 		
 		// Update word statistics
 		for _, word := range words {
-			wordKey := fmt.Sprintf("stats_word_%d_%s_%s", chatID, date, word)
+			wordKey := statsWordKey(chatID, date, word)
 			
 			var count int
-			item, err := txn.Get([]byte(wordKey))
+			val, err := tx.Get(wordKey)
 			if err == nil {
-				err = item.Value(func(val []byte) error {
-					return json.Unmarshal(val, &count)
-				})
-				if err != nil {
+				if err := json.Unmarshal(val, &count); err != nil {
 					return err
 				}
 			}
@@ -146,7 +141,7 @@ func (sm *StatsManager) AddMessage(chatID, userID int64, username, text string)
 				return err
 			}
 			
-			if err := txn.Set([]byte(wordKey), countData); err != nil {
+			if err := tx.Set(wordKey, countData); err != nil {
 				return err
 			}
 		}
@@ -159,42 +154,22 @@ func (sm *StatsManager) AddMessage(chatID, userID int64, username, text string)
 func (sm *StatsManager) GetTopUsers(chatID int64, limit int, allTime bool) ([]UserStats, error) {
 	var users []UserStats
 	
-	err := sm.db.View(func(txn *badger.Txn) error {
-		prefix := fmt.Sprintf("stats_user_%d_", chatID)
-		
-		opts := badger.DefaultIteratorOptions
-		opts.Prefix = []byte(prefix)
-		
-		it := txn.NewIterator(opts)
-		defer it.Close()
+	err := sm.store.Scan(statsUserChatPrefix(chatID), func(key string, val []byte) error {
+		var userStats UserStats
+		if err := json.Unmarshal(val, &userStats); err != nil {
+			return err
+		}
 		
-		for it.Rewind(); it.Valid(); it.Next() {
-			item := it.Item()
-			
-			err := item.Value(func(val []byte) error {
-				var userStats UserStats
-				if err := json.Unmarshal(val, &userStats); err != nil {
-					return err
-				}
-				
-				// Filter by time if not all time
-				if !allTime {
-					now := time.Now()
-					startOfDay := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
-					if time.Unix(userStats.LastSeen, 0).Before(startOfDay) {
-						return nil // Skip old messages
-					}
-				}
-				
-				users = append(users, userStats)
-				return nil
-			})
-			
-			if err != nil {
-				return err
+		// Filter by time if not all time
+		if !allTime {
+			now := time.Now()
+			startOfDay := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+			if time.Unix(userStats.LastSeen, 0).Before(startOfDay) {
+				return nil // Skip old messages
 			}
 		}
 		
+		users = append(users, userStats)
 		return nil
 	})
 	
@@ -219,65 +194,86 @@ func (sm *StatsManager) GetTopUsers(chatID int64, limit int, allTime bool) ([]Us
 	return users, nil
 }
 
+// GetActiveUsers returns users of a chat seen since a moment, ordered by user ID
+func (sm *StatsManager) GetActiveUsers(chatID int64, since time.Time) ([]UserStats, error) {
+	var users []UserStats
+	
+	err := sm.store.Scan(statsUserChatPrefix(chatID), func(key string, val []byte) error {
+		var userStats UserStats
+		if err := json.Unmarshal(val, &userStats); err != nil {
+			return nil
+		}
+		if userStats.LastSeen >= since.Unix() {
+			users = append(users, userStats)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	
+	sort.Slice(users, func(i, j int) bool {
+		return users[i].UserID < users[j].UserID
+	})
+	
+	return users, nil
+}
+
+// FindUserByHandle looks up a chat member by Telegram @username among users with stats
+func (sm *StatsManager) FindUserByHandle(chatID int64, handle string) (UserStats, bool) {
+	handle = strings.ToLower(strings.TrimPrefix(handle, "@"))
+	var found UserStats
+	
+	err := sm.store.Scan(statsUserChatPrefix(chatID), func(key string, val []byte) error {
+		var userStats UserStats
+		if err := json.Unmarshal(val, &userStats); err != nil {
+			return nil
+		}
+		if userStats.Handle != "" && strings.ToLower(userStats.Handle) == handle {
+			found = userStats
+			return storage.ErrStopScan
+		}
+		return nil
+	})
+	if err != nil {
+		fmt.Printf("[-] Failed to find user @%s in chat %d: %v\n", handle, chatID, err)
+	}
+	
+	return found, found.UserID != 0
+}
+
 // GetTotalMessages returns total message count for a chat
 func (sm *StatsManager) GetTotalMessages(chatID int64, allTime bool) (int, error) {
 	var total int
 	
 	if allTime {
 		// Count all users' messages
-		err := sm.db.View(func(txn *badger.Txn) error {
-			prefix := fmt.Sprintf("stats_user_%d_", chatID)
-			
-			opts := badger.DefaultIteratorOptions
-			opts.Prefix = []byte(prefix)
-			
-			it := txn.NewIterator(opts)
-			defer it.Close()
-			
-			for it.Rewind(); it.Valid(); it.Next() {
-				item := it.Item()
-				
-				err := item.Value(func(val []byte) error {
-					var userStats UserStats
-					if err := json.Unmarshal(val, &userStats); err != nil {
-						return err
-					}
-					total += userStats.MessageCount
-					return nil
-				})
-				
-				if err != nil {
-					return err
-				}
+		err := sm.store.Scan(statsUserChatPrefix(chatID), func(key string, val []byte) error {
+			var userStats UserStats
+			if err := json.Unmarshal(val, &userStats); err != nil {
+				return err
 			}
-			
+			total += userStats.MessageCount
 			return nil
 		})
 		return total, err
 	} else {
 		// Count today's messages
 		date := time.Now().Format("2006-01-02")
-		msgKey := fmt.Sprintf("stats_msg_%d_%s", chatID, date)
 		
-		err := sm.db.View(func(txn *badger.Txn) error {
-			item, err := txn.Get([]byte(msgKey))
-			if err != nil {
-				if err == badger.ErrKeyNotFound {
-					return nil // No messages today
-				}
-				return err
+		val, err := sm.store.Get(statsMsgKey(chatID, date))
+		if err != nil {
+			if err == storage.ErrNotFound {
+				return 0, nil // No messages today
 			}
-			
-			return item.Value(func(val []byte) error {
-				var msgStats MessageStats
-				if err := json.Unmarshal(val, &msgStats); err != nil {
-					return err
-				}
-				total = msgStats.TotalMessages
-				return nil
-			})
-		})
-		return total, err
+			return 0, err
+		}
+		
+		var msgStats MessageStats
+		if err := json.Unmarshal(val, &msgStats); err != nil {
+			return 0, err
+		}
+		return msgStats.TotalMessages, nil
 	}
 }
 
@@ -285,41 +281,21 @@ func (sm *StatsManager) GetTotalMessages(chatID int64, allTime bool) (int, error
 func (sm *StatsManager) GetPopularWords(chatID int64, limit int) ([]WordStats, error) {
 	var words []WordStats
 	date := time.Now().Format("2006-01-02")
+	prefix := statsWordDayPrefix(chatID, date)
 	
-	err := sm.db.View(func(txn *badger.Txn) error {
-		prefix := fmt.Sprintf("stats_word_%d_%s_", chatID, date)
-		
-		opts := badger.DefaultIteratorOptions
-		opts.Prefix = []byte(prefix)
+	err := sm.store.Scan(prefix, func(key string, val []byte) error {
+		var count int
+		if err := json.Unmarshal(val, &count); err != nil {
+			return err
+		}
 		
-		it := txn.NewIterator(opts)
-		defer it.Close()
+		// Extract word from key
+		word := strings.TrimPrefix(key, prefix)
 		
-		for it.Rewind(); it.Valid(); it.Next() {
-			item := it.Item()
-			
-			err := item.Value(func(val []byte) error {
-				var count int
-				if err := json.Unmarshal(val, &count); err != nil {
-					return err
-				}
-				
-				// Extract word from key
-				key := string(item.Key())
-				word := strings.TrimPrefix(key, prefix)
-				
-				words = append(words, WordStats{
-					Word:  word,
-					Count: count,
-				})
-				
-				return nil
-			})
-			
-			if err != nil {
-				return err
-			}
-		}
+		words = append(words, WordStats{
+			Word:  word,
+			Count: count,
+		})
 		
 		return nil
 	})
@@ -345,40 +321,93 @@ func (sm *StatsManager) GetPopularWords(chatID int64, limit int) ([]WordStats, e
 	return words, nil
 }
 
-// CleanupOldStats removes statistics older than specified days
-func (sm *StatsManager) CleanupOldStats(maxDays int) error {
+// CleanupOldStats removes statistics older than specified days and returns the number of deleted keys
+func (sm *StatsManager) CleanupOldStats(maxDays int) (int, error) {
 	cutoff := time.Now().AddDate(0, 0, -maxDays)
+	var keysToDelete []string
 	
-	return sm.db.Update(func(txn *badger.Txn) error {
-		opts := badger.DefaultIteratorOptions
-		opts.Prefix = []byte("stats_")
-		
-		it := txn.NewIterator(opts)
-		defer it.Close()
-		
-		for it.Rewind(); it.Valid(); it.Next() {
-			item := it.Item()
-			key := string(item.Key())
+	// Only daily message stats and word counters carry a date
+	for _, prefix := range []string{statsMsgPrefix, statsWordPrefix} {
+		err := sm.store.ScanKeys(prefix, func(key string) error {
+			day, ok := statsDayKeyDate(key)
+			if !ok {
+				return nil
+			}
 			
-			// Check if this is a date-based key
-			if strings.Contains(key, "_") {
-				parts := strings.Split(key, "_")
-				if len(parts) >= 3 {
-					// Try to parse date from key
-					dateStr := parts[len(parts)-1]
-					if date, err := time.Parse("2006-01-02", dateStr); err == nil {
-						if date.Before(cutoff) {
-							if err := txn.Delete([]byte(key)); err != nil {
-								return err
-							}
-						}
-					}
-				}
+			date, err := time.Parse("2006-01-02", day)
+			if err != nil {
+				return nil
 			}
+			
+			if date.Before(cutoff) {
+				keysToDelete = append(keysToDelete, key)
+			}
+			
+			return nil
+		})
+		if err != nil {
+			return 0, err
+		}
+	}
+	
+	if err := storage.DeleteKeys(sm.store, keysToDelete); err != nil {
+		return 0, err
+	}
+	
+	return len(keysToDelete), nil
+}
+
+// IsKnownUser reports whether a user has statistics in any chat, i.e. is a member of a chat with the bot
+func (sm *StatsManager) IsKnownUser(userID int64) (bool, error) {
+	suffix := fmt.Sprintf("_%d", userID)
+	known := false
+	
+	err := sm.store.ScanKeys(statsUserPrefix, func(key string) error {
+		if strings.HasSuffix(key, suffix) {
+			known = true
+			return storage.ErrStopScan
+		}
+		return nil
+	})
+	
+	return known, err
+}
+
+// DeleteUserStats removes per-user statistics for a user in all chats and returns the number of deleted keys
+func (sm *StatsManager) DeleteUserStats(userID int64) (int, error) {
+	suffix := fmt.Sprintf("_%d", userID)
+	var keysToDelete []string
+	
+	err := sm.store.ScanKeys(statsUserPrefix, func(key string) error {
+		if strings.HasSuffix(key, suffix) {
+			keysToDelete = append(keysToDelete, key)
 		}
-		
 		return nil
 	})
+	if err != nil {
+		return 0, err
+	}
+	
+	if err := storage.DeleteKeys(sm.store, keysToDelete); err != nil {
+		return 0, err
+	}
+	
+	return len(keysToDelete), nil
+}
+
+// DeleteChatStats removes all statistics of a chat and returns the number of deleted keys
+func (sm *StatsManager) DeleteChatStats(chatID int64) (int, error) {
+	total := 0
+	
+	for _, prefix := range []string{statsUserChatPrefix(chatID), statsMsgChatPrefix(chatID), statsWordChatPrefix(chatID)} {
+		count, err := storage.DeletePrefix(sm.store, prefix)
+		total += count
+		if err != nil {
+			return total, err
+		}
+	}
+	
+	return total, nil
 }
 
 // extractWords extracts meaningful words from text
@@ -0,0 +1,156 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"gobrev/src/storage"
+)
+
+func TestStatsManagerTopUsersAndTotals(t *testing.T) {
+	sm := NewStatsManager(storage.NewMemoryStore())
+
+	messages := []struct {
+		chatID   int64
+		userID   int64
+		username string
+		text     string
+	}{
+		{-100, 1, "Аня", "привет всем котики"},
+		{-100, 2, "Борис", "привет"},
+		{-100, 1, "Аня", "котики лучше собак"},
+		{-100, 1, "Аня", "ok"},
+		{-200, 2, "Борис", "другой чат"},
+	}
+	for _, m := range messages {
+		if err := sm.AddMessage(m.chatID, m.userID, m.username, "", m.text); err != nil {
+			t.Fatalf("AddMessage: %v", err)
+		}
+	}
+
+	top, err := sm.GetTopUsers(-100, 0, true)
+	if err != nil {
+		t.Fatalf("GetTopUsers: %v", err)
+	}
+	if len(top) != 2 || top[0].UserID != 1 || top[0].MessageCount != 3 || top[1].MessageCount != 1 {
+		t.Errorf("GetTopUsers = %+v, want Аня with 3 then Борис with 1", top)
+	}
+
+	if limited, _ := sm.GetTopUsers(-100, 1, false); len(limited) != 1 {
+		t.Errorf("GetTopUsers with limit 1 returned %d users", len(limited))
+	}
+
+	tests := []struct {
+		chatID  int64
+		allTime bool
+		want    int
+	}{
+		{-100, true, 4},
+		{-100, false, 4},
+		{-200, true, 1},
+		{-300, false, 0},
+	}
+	for _, tt := range tests {
+		total, err := sm.GetTotalMessages(tt.chatID, tt.allTime)
+		if err != nil || total != tt.want {
+			t.Errorf("GetTotalMessages(%d, %v) = %d, %v, want %d", tt.chatID, tt.allTime, total, err, tt.want)
+		}
+	}
+}
+
+func TestStatsManagerPopularWords(t *testing.T) {
+	sm := NewStatsManager(storage.NewMemoryStore())
+	sm.AddMessage(-100, 1, "Аня", "", "Cats cats is better than dogs")
+	sm.AddMessage(-100, 2, "Борис", "", "cats")
+
+	words, err := sm.GetPopularWords(-100, 10)
+	if err != nil {
+		t.Fatalf("GetPopularWords: %v", err)
+	}
+	if len(words) == 0 || words[0].Word != "cats" || words[0].Count != 3 {
+		t.Fatalf("GetPopularWords = %+v, want cats with 3 first", words)
+	}
+	for _, word := range words {
+		if word.Word == "is" {
+			t.Errorf("short word %q was counted", word.Word)
+		}
+	}
+}
+
+func TestStatsManagerUserLookup(t *testing.T) {
+	sm := NewStatsManager(storage.NewMemoryStore())
+	sm.AddMessage(-100, 1, "Аня", "anya_k", "привет")
+	sm.AddMessage(-200, 2, "Борис", "", "привет")
+
+	if user, ok := sm.FindUserByHandle(-100, "@Anya_K"); !ok || user.UserID != 1 {
+		t.Errorf("FindUserByHandle(@Anya_K) = %+v, %v, want user 1", user, ok)
+	}
+	if _, ok := sm.FindUserByHandle(-200, "anya_k"); ok {
+		t.Errorf("FindUserByHandle found a user of another chat")
+	}
+
+	active, err := sm.GetActiveUsers(-100, time.Now().Add(-time.Hour))
+	if err != nil || len(active) != 1 || active[0].UserID != 1 {
+		t.Errorf("GetActiveUsers = %+v, %v, want user 1", active, err)
+	}
+	if active, _ := sm.GetActiveUsers(-100, time.Now().Add(time.Hour)); len(active) != 0 {
+		t.Errorf("GetActiveUsers in the future = %+v, want none", active)
+	}
+
+	for _, tt := range []struct {
+		userID int64
+		want   bool
+	}{{1, true}, {2, true}, {3, false}} {
+		if known, err := sm.IsKnownUser(tt.userID); err != nil || known != tt.want {
+			t.Errorf("IsKnownUser(%d) = %v, %v, want %v", tt.userID, known, err, tt.want)
+		}
+	}
+}
+
+func TestStatsManagerDelete(t *testing.T) {
+	store := storage.NewMemoryStore()
+	sm := NewStatsManager(store)
+	sm.AddMessage(-100, 1, "Аня", "", "привет котики")
+	sm.AddMessage(-200, 1, "Аня", "", "привет")
+	sm.AddMessage(-100, 21, "Борис", "", "привет")
+
+	deleted, err := sm.DeleteUserStats(1)
+	if err != nil || deleted != 2 {
+		t.Fatalf("DeleteUserStats(1) = %d, %v, want 2", deleted, err)
+	}
+	if top, _ := sm.GetTopUsers(-100, 0, true); len(top) != 1 || top[0].UserID != 21 {
+		t.Errorf("after DeleteUserStats(1) top = %+v, want only user 21", top)
+	}
+
+	if _, err := sm.DeleteChatStats(-100); err != nil {
+		t.Fatalf("DeleteChatStats: %v", err)
+	}
+	err = store.ScanKeys(statsUserChatPrefix(-100), func(key string) error {
+		t.Errorf("key %s left after DeleteChatStats", key)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total, _ := sm.GetTotalMessages(-200, false); total != 1 {
+		t.Errorf("DeleteChatStats(-100) touched chat -200, today's total = %d", total)
+	}
+}
+
+func TestStatsManagerCleanupOldStats(t *testing.T) {
+	store := storage.NewMemoryStore()
+	sm := NewStatsManager(store)
+	sm.AddMessage(-100, 1, "Аня", "", "котики")
+
+	old := time.Now().AddDate(0, 0, -40).Format("2006-01-02")
+	store.Set(statsMsgKey(-100, old), []byte(`{"total_messages": 5}`))
+	store.Set(statsWordKey(-100, old, "старое"), []byte("5"))
+
+	removed, err := sm.CleanupOldStats(30)
+	if err != nil || removed != 2 {
+		t.Fatalf("CleanupOldStats(30) = %d, %v, want 2", removed, err)
+	}
+	if total, _ := sm.GetTotalMessages(-100, true); total != 1 {
+		t.Errorf("CleanupOldStats removed user stats, all-time total = %d", total)
+	}
+}
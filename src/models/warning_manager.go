@@ -0,0 +1,115 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"gobrev/src/storage"
+)
+
+// Warning is a moderator warning given to a chat member
+type Warning struct {
+	ChatID    int64  `json:"chat_id"`
+	UserID    int64  `json:"user_id"`
+	AdminID   int64  `json:"admin_id"`
+	Reason    string `json:"reason,omitempty"`
+	CreatedAt int64  `json:"created_at"`
+	ExpiresAt int64  `json:"expires_at"`
+}
+
+// WarningManager stores warnings per chat and user
+type WarningManager struct {
+	store storage.Store
+}
+
+// NewWarningManager creates a new warning manager
+func NewWarningManager(store storage.Store) *WarningManager {
+	return &WarningManager{
+		store: store,
+	}
+}
+
+// Add stores a warning and returns the number of active warnings of the user
+func (wm *WarningManager) Add(chatID, userID, adminID int64, reason string, ttl time.Duration) (int, error) {
+	now := time.Now()
+	warning := Warning{
+		ChatID:    chatID,
+		UserID:    userID,
+		AdminID:   adminID,
+		Reason:    reason,
+		CreatedAt: now.Unix(),
+		ExpiresAt: now.Add(ttl).Unix(),
+	}
+
+	jsonData, err := json.Marshal(warning)
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal warning: %w", err)
+	}
+
+	if err := wm.store.Set(warningKey(chatID, userID, now.UnixNano()), jsonData); err != nil {
+		return 0, err
+	}
+
+	return len(wm.Active(chatID, userID)), nil
+}
+
+// Active returns non-expired warnings of a user, oldest first
+func (wm *WarningManager) Active(chatID, userID int64) []Warning {
+	now := time.Now().Unix()
+	var warnings []Warning
+
+	err := wm.store.Scan(warningUserPrefix(chatID, userID), func(key string, val []byte) error {
+		var warning Warning
+		if err := json.Unmarshal(val, &warning); err != nil {
+			return nil
+		}
+		if warning.ExpiresAt >= now {
+			warnings = append(warnings, warning)
+		}
+		return nil
+	})
+	if err != nil {
+		fmt.Printf("[-] Failed to load warnings of user %d in chat %d: %v\n", userID, chatID, err)
+	}
+
+	sort.Slice(warnings, func(i, j int) bool {
+		return warnings[i].CreatedAt < warnings[j].CreatedAt
+	})
+
+	return warnings
+}
+
+// Clear removes all warnings of a user and returns how many were removed
+func (wm *WarningManager) Clear(chatID, userID int64) (int, error) {
+	return storage.DeletePrefix(wm.store, warningUserPrefix(chatID, userID))
+}
+
+// DeleteChatWarnings removes all warnings of a chat
+func (wm *WarningManager) DeleteChatWarnings(chatID int64) (int, error) {
+	return storage.DeletePrefix(wm.store, warningChatPrefix(chatID))
+}
+
+// CleanupExpired removes expired warnings and returns the number of deleted records
+func (wm *WarningManager) CleanupExpired() (int, error) {
+	now := time.Now().Unix()
+	var keysToDelete []string
+
+	err := wm.store.Scan(warningPrefix, func(key string, val []byte) error {
+		var warning Warning
+		if err := json.Unmarshal(val, &warning); err != nil || warning.ExpiresAt < now {
+			keysToDelete = append(keysToDelete, key)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if err := storage.DeleteKeys(wm.store, keysToDelete); err != nil {
+		return 0, err
+	}
+
+	return len(keysToDelete), nil
+}
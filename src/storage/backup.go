@@ -0,0 +1,217 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const backupFilePrefix = "gobrev-"
+
+// BackupManager writes periodic online backups and rotates old ones
+type BackupManager struct {
+	store    Backuper
+	dir      string
+	interval time.Duration
+	keep     int
+	mu       sync.Mutex
+	done     chan struct{}
+}
+
+// NewBackupManager creates a new backup manager.
+// interval <= 0 disables periodic backups, keep <= 0 disables rotation.
+func NewBackupManager(store Backuper, dir string, interval time.Duration, keep int) *BackupManager {
+	return &BackupManager{
+		store:    store,
+		dir:      dir,
+		interval: interval,
+		keep:     keep,
+		done:     make(chan struct{}),
+	}
+}
+
+// Start creates backups on every interval until ctx is cancelled
+func (bm *BackupManager) Start(ctx context.Context) {
+	if bm.interval <= 0 {
+		close(bm.done)
+		fmt.Printf("[i] Periodic backups disabled\n")
+		return
+	}
+
+	go func() {
+		defer close(bm.done)
+
+		ticker := time.NewTicker(bm.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if _, err := bm.CreateBackup(); err != nil {
+					fmt.Printf("[-] Periodic backup failed: %v\n", err)
+				}
+			case <-ctx.Done():
+				fmt.Printf("[i] Backup manager stopped\n")
+				return
+			}
+		}
+	}()
+
+	fmt.Printf("[+] Backup manager started, interval: %v, keep: %d, dir: %s\n", bm.interval, bm.keep, bm.dir)
+}
+
+// Wait blocks until the backup goroutine has exited
+func (bm *BackupManager) Wait() {
+	<-bm.done
+}
+
+// CreateBackup writes a new backup file and removes the oldest ones beyond the keep limit
+func (bm *BackupManager) CreateBackup() (string, error) {
+	bm.mu.Lock()
+	defer bm.mu.Unlock()
+
+	if err := os.MkdirAll(bm.dir, 0700); err != nil {
+		return "", fmt.Errorf("failed to create backup dir: %w", err)
+	}
+
+	start := time.Now()
+	name := fmt.Sprintf("%s%s.bak", backupFilePrefix, start.Format("20060102-150405"))
+	path := filepath.Join(bm.dir, name)
+
+	// Write to a temporary file first so a crash never leaves a truncated "latest" backup
+	tmpPath := path + ".tmp"
+	file, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return "", fmt.Errorf("failed to create backup file: %w", err)
+	}
+
+	if err := bm.store.Backup(file); err != nil {
+		file.Close()
+		os.Remove(tmpPath)
+		return "", fmt.Errorf("failed to write backup: %w", err)
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		os.Remove(tmpPath)
+		return "", err
+	}
+	if err := file.Close(); err != nil {
+		os.Remove(tmpPath)
+		return "", err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return "", err
+	}
+
+	fmt.Printf("[+] Backup saved to %s in %v\n", path, time.Since(start))
+
+	bm.rotate()
+	return path, nil
+}
+
+// LatestBackup returns the path of the newest backup file
+func (bm *BackupManager) LatestBackup() (string, error) {
+	backups, err := bm.listBackups()
+	if err != nil {
+		return "", err
+	}
+	if len(backups) == 0 {
+		return "", os.ErrNotExist
+	}
+	return backups[len(backups)-1], nil
+}
+
+// rotate removes the oldest backups beyond the keep limit
+func (bm *BackupManager) rotate() {
+	if bm.keep <= 0 {
+		return
+	}
+
+	backups, err := bm.listBackups()
+	if err != nil {
+		fmt.Printf("[-] Failed to list backups for rotation: %v\n", err)
+		return
+	}
+
+	for len(backups) > bm.keep {
+		if err := os.Remove(backups[0]); err != nil {
+			fmt.Printf("[-] Failed to remove old backup %s: %v\n", backups[0], err)
+		} else {
+			fmt.Printf("[#] Removed old backup %s\n", backups[0])
+		}
+		backups = backups[1:]
+	}
+}
+
+// listBackups returns backup files sorted from oldest to newest
+func (bm *BackupManager) listBackups() ([]string, error) {
+	entries, err := os.ReadDir(bm.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var backups []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, backupFilePrefix) || !strings.HasSuffix(name, ".bak") {
+			continue
+		}
+		backups = append(backups, filepath.Join(bm.dir, name))
+	}
+
+	// Timestamped names sort chronologically
+	sort.Strings(backups)
+	return backups, nil
+}
+
+// RestoreBadger loads a backup file into the Badger database at dbPath.
+// The bot must not be running. Unless force is set, the target database must be empty.
+func RestoreBadger(dbPath string, config BadgerConfig, backupPath string, force bool) (int, error) {
+	file, err := os.Open(backupPath)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open backup: %w", err)
+	}
+	defer file.Close()
+
+	store, err := OpenBadger(dbPath, config)
+	if err != nil {
+		return 0, err
+	}
+	defer store.Close()
+
+	if !force {
+		empty, err := isEmpty(store)
+		if err != nil {
+			return 0, err
+		}
+		if !empty {
+			return 0, fmt.Errorf("database at %s is not empty, move it away or use --force to merge", dbPath)
+		}
+	}
+
+	reader, err := newBackupReader(file, config.EncryptionKey)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := store.db.Load(reader, 256); err != nil {
+		return 0, fmt.Errorf("failed to load backup: %w", err)
+	}
+
+	count := 0
+	err = store.ScanKeys("", func(key string) error {
+		count++
+		return nil
+	})
+
+	return count, err
+}
@@ -0,0 +1,272 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+// BadgerStore implements Store on top of BadgerDB
+type BadgerStore struct {
+	db            *badger.DB
+	encryptionKey []byte
+}
+
+// BadgerConfig holds optional BadgerDB settings
+type BadgerConfig struct {
+	EncryptionKey  []byte        // AES key for encryption at rest (empty disables)
+	IndexCacheSize int64         // Block index cache in bytes, required with encryption
+	KeyRotation    time.Duration // How often Badger rotates data keys (0 uses Badger default)
+}
+
+// defaultIndexCacheSize is used for encrypted databases when no size is configured
+const defaultIndexCacheSize = 64 << 20
+
+// OpenBadger opens a BadgerDB at the given path
+func OpenBadger(path string, config BadgerConfig) (*BadgerStore, error) {
+	opts := badger.DefaultOptions(path)
+	opts.Logger = nil // Disable logging
+
+	if len(config.EncryptionKey) > 0 {
+		opts = opts.WithEncryptionKey(config.EncryptionKey)
+		if config.KeyRotation > 0 {
+			opts = opts.WithEncryptionKeyRotationDuration(config.KeyRotation)
+		}
+		// Encrypted tables must be decrypted on every read without an index cache
+		if config.IndexCacheSize <= 0 {
+			config.IndexCacheSize = defaultIndexCacheSize
+		}
+	}
+	if config.IndexCacheSize > 0 {
+		opts = opts.WithIndexCacheSize(config.IndexCacheSize)
+	}
+
+	db, err := badger.Open(opts)
+	if err != nil {
+		if errors.Is(err, badger.ErrEncryptionKeyMismatch) {
+			if len(config.EncryptionKey) > 0 {
+				return nil, fmt.Errorf("failed to open BadgerDB: database isn't encrypted with this key (run \"gobrev encrypt\" to migrate a plaintext database): %w", err)
+			}
+			return nil, fmt.Errorf("failed to open BadgerDB: database is encrypted, set DB_ENCRYPTION_KEY or DB_ENCRYPTION_KEY_FILE: %w", err)
+		}
+		return nil, fmt.Errorf("failed to open BadgerDB: %w", err)
+	}
+
+	store := NewBadgerStore(db)
+	store.encryptionKey = config.EncryptionKey
+	return store, nil
+}
+
+// NewBadgerStore wraps an already opened BadgerDB
+func NewBadgerStore(db *badger.DB) *BadgerStore {
+	return &BadgerStore{
+		db: db,
+	}
+}
+
+// DB returns the underlying BadgerDB instance
+func (s *BadgerStore) DB() *badger.DB {
+	return s.db
+}
+
+// Close closes the database
+func (s *BadgerStore) Close() error {
+	if s.db != nil {
+		return s.db.Close()
+	}
+	return nil
+}
+
+// Get returns the value stored under key
+func (s *BadgerStore) Get(key string) ([]byte, error) {
+	var value []byte
+	err := s.View(func(tx Reader) error {
+		var err error
+		value, err = tx.Get(key)
+		return err
+	})
+	return value, err
+}
+
+// Set stores a value under key
+func (s *BadgerStore) Set(key string, value []byte) error {
+	return s.Update(func(tx Tx) error {
+		return tx.Set(key, value)
+	})
+}
+
+// Delete removes a key
+func (s *BadgerStore) Delete(key string) error {
+	return s.Update(func(tx Tx) error {
+		return tx.Delete(key)
+	})
+}
+
+// Scan calls fn for every key with the given prefix
+func (s *BadgerStore) Scan(prefix string, fn func(key string, value []byte) error) error {
+	return s.View(func(tx Reader) error {
+		return tx.Scan(prefix, fn)
+	})
+}
+
+// ScanKeys calls fn for every key with the given prefix
+func (s *BadgerStore) ScanKeys(prefix string, fn func(key string) error) error {
+	return s.View(func(tx Reader) error {
+		return tx.ScanKeys(prefix, fn)
+	})
+}
+
+// Update runs fn in a read-write transaction
+func (s *BadgerStore) Update(fn func(tx Tx) error) error {
+	return s.db.Update(func(txn *badger.Txn) error {
+		return fn(&badgerTx{txn: txn})
+	})
+}
+
+// View runs fn in a read-only transaction
+func (s *BadgerStore) View(fn func(tx Reader) error) error {
+	return s.db.View(func(txn *badger.Txn) error {
+		return fn(&badgerTx{txn: txn})
+	})
+}
+
+// Batch applies writes through a Badger write batch
+func (s *BadgerStore) Batch(fn func(w Writer) error) error {
+	wb := s.db.NewWriteBatch()
+	defer wb.Cancel()
+
+	if err := fn(&badgerBatch{wb: wb}); err != nil {
+		return err
+	}
+
+	return wb.Flush()
+}
+
+// CollectGarbage runs value log GC until Badger has nothing left to rewrite
+func (s *BadgerStore) CollectGarbage(discardRatio float64) (int, error) {
+	rewrites := 0
+	for {
+		err := s.db.RunValueLogGC(discardRatio)
+		if err == nil {
+			rewrites++
+			continue
+		}
+		if errors.Is(err, badger.ErrNoRewrite) || errors.Is(err, badger.ErrRejected) {
+			return rewrites, nil
+		}
+		return rewrites, err
+	}
+}
+
+// Size returns the on-disk size of the LSM tree and the value log in bytes
+func (s *BadgerStore) Size() (lsm, vlog int64) {
+	return s.db.Size()
+}
+
+// Compact merges all LSM levels into one and reclaims value log space.
+// It must not run while other writers use the database.
+func (s *BadgerStore) Compact(discardRatio float64) (int, error) {
+	if err := s.db.Flatten(2); err != nil {
+		return 0, fmt.Errorf("failed to flatten LSM tree: %w", err)
+	}
+	return s.CollectGarbage(discardRatio)
+}
+
+// Backup writes a full backup of the database in Badger's backup format.
+// Backups of encrypted databases are encrypted with the same key.
+func (s *BadgerStore) Backup(w io.Writer) error {
+	if len(s.encryptionKey) > 0 {
+		encrypted, err := newEncryptingWriter(w, s.encryptionKey)
+		if err != nil {
+			return err
+		}
+		w = encrypted
+	}
+
+	_, err := s.db.Backup(w, 0)
+	return err
+}
+
+// badgerTx adapts badger.Txn to Tx
+type badgerTx struct {
+	txn *badger.Txn
+}
+
+func (t *badgerTx) Get(key string) ([]byte, error) {
+	item, err := t.txn.Get([]byte(key))
+	if err != nil {
+		if errors.Is(err, badger.ErrKeyNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return item.ValueCopy(nil)
+}
+
+func (t *badgerTx) Set(key string, value []byte) error {
+	return t.txn.Set([]byte(key), value)
+}
+
+func (t *badgerTx) Delete(key string) error {
+	return t.txn.Delete([]byte(key))
+}
+
+func (t *badgerTx) Scan(prefix string, fn func(key string, value []byte) error) error {
+	opts := badger.DefaultIteratorOptions
+	opts.Prefix = []byte(prefix)
+
+	it := t.txn.NewIterator(opts)
+	defer it.Close()
+
+	for it.Rewind(); it.Valid(); it.Next() {
+		item := it.Item()
+		value, err := item.ValueCopy(nil)
+		if err != nil {
+			return err
+		}
+		if err := fn(string(item.Key()), value); err != nil {
+			if errors.Is(err, ErrStopScan) {
+				return nil
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (t *badgerTx) ScanKeys(prefix string, fn func(key string) error) error {
+	opts := badger.DefaultIteratorOptions
+	opts.Prefix = []byte(prefix)
+	opts.PrefetchValues = false
+
+	it := t.txn.NewIterator(opts)
+	defer it.Close()
+
+	for it.Rewind(); it.Valid(); it.Next() {
+		if err := fn(string(it.Item().Key())); err != nil {
+			if errors.Is(err, ErrStopScan) {
+				return nil
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
+// badgerBatch adapts badger.WriteBatch to Writer
+type badgerBatch struct {
+	wb *badger.WriteBatch
+}
+
+func (b *badgerBatch) Set(key string, value []byte) error {
+	return b.wb.Set([]byte(key), value)
+}
+
+func (b *badgerBatch) Delete(key string) error {
+	return b.wb.Delete([]byte(key))
+}
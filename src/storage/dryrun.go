@@ -0,0 +1,103 @@
+package storage
+
+import (
+	"errors"
+	"sort"
+	"strings"
+)
+
+// dryRunStore reads through to a base store but keeps all writes in memory
+type dryRunStore struct {
+	base    Store
+	overlay map[string][]byte // nil values mark deletions
+	writes  int
+}
+
+// newDryRunStore wraps a store so that nothing is persisted
+func newDryRunStore(base Store) *dryRunStore {
+	return &dryRunStore{
+		base:    base,
+		overlay: make(map[string][]byte),
+	}
+}
+
+func (s *dryRunStore) Get(key string) ([]byte, error) {
+	if value, ok := s.overlay[key]; ok {
+		if value == nil {
+			return nil, ErrNotFound
+		}
+		return append([]byte(nil), value...), nil
+	}
+	return s.base.Get(key)
+}
+
+func (s *dryRunStore) Set(key string, value []byte) error {
+	s.overlay[key] = append([]byte{}, value...)
+	s.writes++
+	return nil
+}
+
+func (s *dryRunStore) Delete(key string) error {
+	s.overlay[key] = nil
+	s.writes++
+	return nil
+}
+
+func (s *dryRunStore) Scan(prefix string, fn func(key string, value []byte) error) error {
+	return s.ScanKeys(prefix, func(key string) error {
+		value, err := s.Get(key)
+		if err != nil {
+			return err
+		}
+		return fn(key, value)
+	})
+}
+
+func (s *dryRunStore) ScanKeys(prefix string, fn func(key string) error) error {
+	seen := make(map[string]bool)
+	var keys []string
+
+	err := s.base.ScanKeys(prefix, func(key string) error {
+		seen[key] = true
+		if value, ok := s.overlay[key]; !ok || value != nil {
+			keys = append(keys, key)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for key, value := range s.overlay {
+		if value != nil && !seen[key] && strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if err := fn(key); err != nil {
+			if errors.Is(err, ErrStopScan) {
+				return nil
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *dryRunStore) Update(fn func(tx Tx) error) error {
+	return fn(s)
+}
+
+func (s *dryRunStore) View(fn func(tx Reader) error) error {
+	return fn(s)
+}
+
+func (s *dryRunStore) Batch(fn func(w Writer) error) error {
+	return fn(s)
+}
+
+func (s *dryRunStore) Close() error {
+	return nil
+}
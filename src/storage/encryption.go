@@ -0,0 +1,215 @@
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+// LoadEncryptionKey reads the database key from a value or a key file.
+// Keys are 16, 24 or 32 bytes (AES-128/192/256), given raw or hex-encoded.
+// Returns nil if neither is set.
+func LoadEncryptionKey(value, file string) ([]byte, error) {
+	if value != "" && file != "" {
+		return nil, fmt.Errorf("set either encryption key or key file, not both")
+	}
+
+	raw := []byte(value)
+	if file != "" {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read key file: %w", err)
+		}
+		raw = data
+	}
+
+	if len(raw) == 0 {
+		return nil, nil
+	}
+
+	return parseEncryptionKey(raw)
+}
+
+// parseEncryptionKey accepts raw or hex-encoded AES keys
+func parseEncryptionKey(raw []byte) ([]byte, error) {
+	trimmed := strings.TrimSpace(string(raw))
+
+	// Hex-encoded key: 32, 48 or 64 hex characters
+	if decoded, err := hex.DecodeString(trimmed); err == nil && validKeyLength(len(decoded)) {
+		return decoded, nil
+	}
+
+	if validKeyLength(len(trimmed)) {
+		return []byte(trimmed), nil
+	}
+	if validKeyLength(len(raw)) {
+		return raw, nil
+	}
+
+	return nil, fmt.Errorf("encryption key must be 16, 24 or 32 bytes (or 32, 48, 64 hex characters)")
+}
+
+// validKeyLength checks for AES key sizes
+func validKeyLength(n int) bool {
+	return n == 16 || n == 24 || n == 32
+}
+
+// EncryptBadger migrates an unencrypted database at path to an encrypted copy.
+// The database is copied through Badger's backup stream into a new directory opened with
+// the key, then the directories are swapped. The old plaintext directory is kept and its
+// path returned so it can be wiped after verification. The bot must not be running.
+func EncryptBadger(path string, config BadgerConfig) (string, error) {
+	if len(config.EncryptionKey) == 0 {
+		return "", fmt.Errorf("no encryption key configured")
+	}
+
+	src, err := OpenBadger(path, BadgerConfig{})
+	if err != nil {
+		return "", fmt.Errorf("failed to open plaintext database (already encrypted?): %w", err)
+	}
+
+	tmpPath := path + ".encrypting"
+	if err := os.RemoveAll(tmpPath); err != nil {
+		src.Close()
+		return "", err
+	}
+
+	dst, err := OpenBadger(tmpPath, config)
+	if err != nil {
+		src.Close()
+		return "", err
+	}
+
+	copyErr := copyBadger(src, dst)
+
+	if err := src.Close(); err != nil && copyErr == nil {
+		copyErr = err
+	}
+	if err := dst.Close(); err != nil && copyErr == nil {
+		copyErr = err
+	}
+	if copyErr != nil {
+		os.RemoveAll(tmpPath)
+		return "", fmt.Errorf("failed to copy database: %w", copyErr)
+	}
+
+	plainPath := fmt.Sprintf("%s.plain-%s", path, time.Now().Format("20060102-150405"))
+	if err := os.Rename(path, plainPath); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		// Put the original back so the bot can still start
+		os.Rename(plainPath, path)
+		return "", err
+	}
+
+	return plainPath, nil
+}
+
+// copyBadger streams all data from src into dst
+func copyBadger(src, dst *BadgerStore) error {
+	reader, writer := io.Pipe()
+
+	go func() {
+		_, err := src.db.Backup(writer, 0)
+		writer.CloseWithError(err)
+	}()
+
+	err := dst.db.Load(reader, 256)
+	reader.CloseWithError(err)
+	return err
+}
+
+// RotateBadgerKey re-encrypts the key registry of a closed database with a new master key.
+// Data keys stay the same, so existing files don't need to be rewritten.
+func RotateBadgerKey(path string, oldKey, newKey []byte) error {
+	if len(oldKey) == 0 || len(newKey) == 0 {
+		return fmt.Errorf("both old and new keys are required")
+	}
+
+	opts := badger.KeyRegistryOptions{
+		Dir:                           path,
+		ReadOnly:                      true,
+		EncryptionKey:                 oldKey,
+		EncryptionKeyRotationDuration: 10 * 24 * time.Hour,
+	}
+
+	registry, err := badger.OpenKeyRegistry(opts)
+	if err != nil {
+		return fmt.Errorf("failed to open key registry with current key: %w", err)
+	}
+	defer registry.Close()
+
+	opts.EncryptionKey = newKey
+	if err := badger.WriteKeyRegistry(registry, opts); err != nil {
+		return fmt.Errorf("failed to write key registry: %w", err)
+	}
+
+	return nil
+}
+
+// encryptedBackupMagic marks backup files encrypted with the database key
+var encryptedBackupMagic = []byte("GOBREV-ENC1")
+
+// newEncryptingWriter writes the backup header and returns a writer that encrypts with AES-CTR
+func newEncryptingWriter(w io.Writer, key []byte) (io.Writer, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	iv := make([]byte, aes.BlockSize)
+	if _, err := rand.Read(iv); err != nil {
+		return nil, err
+	}
+
+	if _, err := w.Write(encryptedBackupMagic); err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(iv); err != nil {
+		return nil, err
+	}
+
+	return &cipher.StreamWriter{S: cipher.NewCTR(block, iv), W: w}, nil
+}
+
+// newBackupReader returns a reader of plain backup data, decrypting it if the file is encrypted
+func newBackupReader(r io.Reader, key []byte) (io.Reader, error) {
+	buffered := bufio.NewReader(r)
+
+	header, err := buffered.Peek(len(encryptedBackupMagic))
+	if err != nil || !bytes.Equal(header, encryptedBackupMagic) {
+		// Plain Badger backup
+		return buffered, nil
+	}
+
+	if len(key) == 0 {
+		return nil, fmt.Errorf("backup is encrypted, set DB_ENCRYPTION_KEY or DB_ENCRYPTION_KEY_FILE")
+	}
+
+	if _, err := buffered.Discard(len(encryptedBackupMagic)); err != nil {
+		return nil, err
+	}
+
+	iv := make([]byte, aes.BlockSize)
+	if _, err := io.ReadFull(buffered, iv); err != nil {
+		return nil, fmt.Errorf("failed to read backup IV: %w", err)
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &cipher.StreamReader{S: cipher.NewCTR(block, iv), R: buffered}, nil
+}
@@ -0,0 +1,184 @@
+package storage
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// MemoryStore implements Store in memory, mainly for tests
+type MemoryStore struct {
+	mu   sync.RWMutex
+	data map[string][]byte
+}
+
+// NewMemoryStore creates an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		data: make(map[string][]byte),
+	}
+}
+
+// Close is a no-op for the in-memory store
+func (s *MemoryStore) Close() error {
+	return nil
+}
+
+// Get returns the value stored under key
+func (s *MemoryStore) Get(key string) ([]byte, error) {
+	var value []byte
+	err := s.View(func(tx Reader) error {
+		var err error
+		value, err = tx.Get(key)
+		return err
+	})
+	return value, err
+}
+
+// Set stores a value under key
+func (s *MemoryStore) Set(key string, value []byte) error {
+	return s.Update(func(tx Tx) error {
+		return tx.Set(key, value)
+	})
+}
+
+// Delete removes a key
+func (s *MemoryStore) Delete(key string) error {
+	return s.Update(func(tx Tx) error {
+		return tx.Delete(key)
+	})
+}
+
+// Scan calls fn for every key with the given prefix
+func (s *MemoryStore) Scan(prefix string, fn func(key string, value []byte) error) error {
+	return s.View(func(tx Reader) error {
+		return tx.Scan(prefix, fn)
+	})
+}
+
+// ScanKeys calls fn for every key with the given prefix
+func (s *MemoryStore) ScanKeys(prefix string, fn func(key string) error) error {
+	return s.View(func(tx Reader) error {
+		return tx.ScanKeys(prefix, fn)
+	})
+}
+
+// Update runs fn against a staged copy of changes and applies them if fn succeeds
+func (s *MemoryStore) Update(fn func(tx Tx) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tx := &memoryTx{store: s, pending: make(map[string][]byte)}
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	for key, value := range tx.pending {
+		if value == nil {
+			delete(s.data, key)
+		} else {
+			s.data[key] = value
+		}
+	}
+
+	return nil
+}
+
+// View runs fn in a read-only transaction
+func (s *MemoryStore) View(fn func(tx Reader) error) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return fn(&memoryTx{store: s})
+}
+
+// Batch applies writes the same way as Update
+func (s *MemoryStore) Batch(fn func(w Writer) error) error {
+	return s.Update(func(tx Tx) error {
+		return fn(tx)
+	})
+}
+
+// memoryTx stages writes until the transaction commits; nil values mark deletions
+type memoryTx struct {
+	store   *MemoryStore
+	pending map[string][]byte
+}
+
+func (t *memoryTx) Get(key string) ([]byte, error) {
+	if value, ok := t.pending[key]; ok {
+		if value == nil {
+			return nil, ErrNotFound
+		}
+		return append([]byte(nil), value...), nil
+	}
+
+	value, ok := t.store.data[key]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return append([]byte(nil), value...), nil
+}
+
+func (t *memoryTx) Set(key string, value []byte) error {
+	if t.pending == nil {
+		return errors.New("transaction is read-only")
+	}
+	t.pending[key] = append([]byte{}, value...)
+	return nil
+}
+
+func (t *memoryTx) Delete(key string) error {
+	if t.pending == nil {
+		return errors.New("transaction is read-only")
+	}
+	t.pending[key] = nil
+	return nil
+}
+
+func (t *memoryTx) Scan(prefix string, fn func(key string, value []byte) error) error {
+	return t.ScanKeys(prefix, func(key string) error {
+		value, err := t.Get(key)
+		if err != nil {
+			return err
+		}
+		return fn(key, value)
+	})
+}
+
+func (t *memoryTx) ScanKeys(prefix string, fn func(key string) error) error {
+	for _, key := range t.keys(prefix) {
+		if err := fn(key); err != nil {
+			if errors.Is(err, ErrStopScan) {
+				return nil
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// keys returns the sorted set of visible keys with the given prefix
+func (t *memoryTx) keys(prefix string) []string {
+	var keys []string
+	for key := range t.store.data {
+		if strings.HasPrefix(key, prefix) {
+			if value, ok := t.pending[key]; ok && value == nil {
+				continue
+			}
+			keys = append(keys, key)
+		}
+	}
+	for key, value := range t.pending {
+		if value == nil || !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		if _, exists := t.store.data[key]; !exists {
+			keys = append(keys, key)
+		}
+	}
+
+	sort.Strings(keys)
+	return keys
+}
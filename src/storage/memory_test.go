@@ -0,0 +1,144 @@
+package storage
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestMemoryStoreGetSetDelete(t *testing.T) {
+	store := NewMemoryStore()
+
+	if _, err := store.Get("missing"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get(missing) error = %v, want ErrNotFound", err)
+	}
+
+	if err := store.Set("key", []byte("value")); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	value, err := store.Get("key")
+	if err != nil || string(value) != "value" {
+		t.Fatalf("Get(key) = %q, %v, want \"value\"", value, err)
+	}
+
+	if err := store.Delete("key"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := store.Get("key"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get after Delete error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestMemoryStoreScanPrefix(t *testing.T) {
+	store := NewMemoryStore()
+	for _, key := range []string{"b_2", "a_1", "b_1", "c_1"} {
+		store.Set(key, []byte(key))
+	}
+
+	tests := []struct {
+		prefix string
+		want   []string
+	}{
+		{"b_", []string{"b_1", "b_2"}},
+		{"a_", []string{"a_1"}},
+		{"d_", nil},
+		{"", []string{"a_1", "b_1", "b_2", "c_1"}},
+	}
+
+	for _, tt := range tests {
+		var got []string
+		err := store.Scan(tt.prefix, func(key string, value []byte) error {
+			if string(value) != key {
+				t.Errorf("Scan(%q) value of %s = %q", tt.prefix, key, value)
+			}
+			got = append(got, key)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("Scan(%q): %v", tt.prefix, err)
+		}
+		if !equalStrings(got, tt.want) {
+			t.Errorf("Scan(%q) = %v, want %v", tt.prefix, got, tt.want)
+		}
+	}
+}
+
+func TestMemoryStoreScanStop(t *testing.T) {
+	store := NewMemoryStore()
+	for _, key := range []string{"k_1", "k_2", "k_3"} {
+		store.Set(key, nil)
+	}
+
+	count := 0
+	err := store.ScanKeys("k_", func(key string) error {
+		count++
+		return ErrStopScan
+	})
+	if err != nil || count != 1 {
+		t.Fatalf("ScanKeys with ErrStopScan = %d keys, %v, want 1 key and no error", count, err)
+	}
+}
+
+func TestMemoryStoreUpdateRollback(t *testing.T) {
+	store := NewMemoryStore()
+	store.Set("kept", []byte("old"))
+
+	failure := errors.New("failure")
+	err := store.Update(func(tx Tx) error {
+		tx.Set("kept", []byte("new"))
+		tx.Set("added", []byte("new"))
+		return failure
+	})
+	if !errors.Is(err, failure) {
+		t.Fatalf("Update error = %v, want %v", err, failure)
+	}
+
+	if value, _ := store.Get("kept"); string(value) != "old" {
+		t.Errorf("kept = %q after a failed update, want \"old\"", value)
+	}
+	if _, err := store.Get("added"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("added exists after a failed update")
+	}
+}
+
+func TestMemoryStoreUpdateReadsOwnWrites(t *testing.T) {
+	store := NewMemoryStore()
+	store.Set("deleted", []byte("x"))
+
+	err := store.Update(func(tx Tx) error {
+		tx.Set("added", []byte("y"))
+		tx.Delete("deleted")
+
+		if value, err := tx.Get("added"); err != nil || string(value) != "y" {
+			t.Errorf("tx.Get(added) = %q, %v, want \"y\"", value, err)
+		}
+		if _, err := tx.Get("deleted"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("tx.Get(deleted) error = %v, want ErrNotFound", err)
+		}
+
+		var keys []string
+		tx.ScanKeys("", func(key string) error {
+			keys = append(keys, key)
+			return nil
+		})
+		if !equalStrings(keys, []string{"added"}) {
+			t.Errorf("tx.ScanKeys = %v, want [added]", keys)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+}
+
+// equalStrings compares string slices, nil and empty are equal
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,201 @@
+package storage
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// SchemaVersionKey stores the version of the data layout
+const SchemaVersionKey = "schema_version"
+
+// Migration transforms stored data from Version-1 to Version
+type Migration struct {
+	Version     int
+	Description string
+	Up          func(store Store) error
+}
+
+// Backuper is implemented by stores that can write a full backup
+type Backuper interface {
+	Backup(w io.Writer) error
+}
+
+// MigrateOptions controls how migrations are applied
+type MigrateOptions struct {
+	DryRun    bool   // Apply migrations to an in-memory overlay and discard the result
+	BackupDir string // Write a backup here before migrating (empty disables)
+}
+
+// MigrateResult describes what a migration run did
+type MigrateResult struct {
+	FromVersion int
+	ToVersion   int
+	Applied     []int
+	Writes      int // Number of key writes and deletes, counted in dry-run mode
+	BackupPath  string
+}
+
+// Migrator applies registered migrations in order
+type Migrator struct {
+	store      Store
+	migrations []Migration
+}
+
+// NewMigrator creates a new migrator for the store
+func NewMigrator(store Store) *Migrator {
+	return &Migrator{
+		store: store,
+	}
+}
+
+// Register adds migrations; they are sorted by version before running
+func (m *Migrator) Register(migrations ...Migration) {
+	m.migrations = append(m.migrations, migrations...)
+	sort.Slice(m.migrations, func(i, j int) bool {
+		return m.migrations[i].Version < m.migrations[j].Version
+	})
+}
+
+// LatestVersion returns the highest registered version
+func (m *Migrator) LatestVersion() int {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// CurrentVersion returns the stored schema version (0 if never migrated)
+func (m *Migrator) CurrentVersion() (int, error) {
+	return readVersion(m.store)
+}
+
+// Run applies all pending migrations
+func (m *Migrator) Run(opts MigrateOptions) (*MigrateResult, error) {
+	for i, migration := range m.migrations {
+		if migration.Version != i+1 {
+			return nil, fmt.Errorf("migration versions must be consecutive from 1, got %d at position %d", migration.Version, i+1)
+		}
+	}
+
+	current, err := m.CurrentVersion()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema version: %w", err)
+	}
+
+	latest := m.LatestVersion()
+	result := &MigrateResult{FromVersion: current, ToVersion: current}
+
+	if current > latest {
+		return nil, fmt.Errorf("database schema version %d is newer than supported version %d", current, latest)
+	}
+
+	// A fresh database already has the latest layout
+	if current == 0 {
+		empty, err := isEmpty(m.store)
+		if err != nil {
+			return nil, err
+		}
+		if empty {
+			if !opts.DryRun {
+				if err := writeVersion(m.store, latest); err != nil {
+					return nil, err
+				}
+			}
+			result.ToVersion = latest
+			return result, nil
+		}
+	}
+
+	if current == latest {
+		return result, nil
+	}
+
+	target := m.store
+	var overlay *dryRunStore
+	if opts.DryRun {
+		overlay = newDryRunStore(m.store)
+		target = overlay
+	} else if opts.BackupDir != "" {
+		path, err := m.backup(opts.BackupDir, current, latest)
+		if err != nil {
+			return nil, fmt.Errorf("backup before migration failed: %w", err)
+		}
+		result.BackupPath = path
+	}
+
+	for _, migration := range m.migrations[current:] {
+		fmt.Printf("[#] Applying migration %d: %s\n", migration.Version, migration.Description)
+		if err := migration.Up(target); err != nil {
+			return result, fmt.Errorf("migration %d failed: %w", migration.Version, err)
+		}
+		if err := writeVersion(target, migration.Version); err != nil {
+			return result, err
+		}
+		result.Applied = append(result.Applied, migration.Version)
+		result.ToVersion = migration.Version
+	}
+
+	if overlay != nil {
+		result.Writes = overlay.writes
+	}
+
+	return result, nil
+}
+
+// backup writes a full backup of the store before migrating
+func (m *Migrator) backup(dir string, from, to int) (string, error) {
+	backuper, ok := m.store.(Backuper)
+	if !ok {
+		return "", fmt.Errorf("store doesn't support backups")
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(dir, fmt.Sprintf("pre-migrate-v%d-v%d-%s.bak", from, to, time.Now().Format("20060102-150405")))
+	file, err := os.Create(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	if err := backuper.Backup(file); err != nil {
+		os.Remove(path)
+		return "", err
+	}
+
+	return path, file.Sync()
+}
+
+// readVersion reads the schema version key
+func readVersion(r Reader) (int, error) {
+	val, err := r.Get(SchemaVersionKey)
+	if err == ErrNotFound {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(string(val))
+}
+
+// writeVersion stores the schema version key
+func writeVersion(w Writer, version int) error {
+	return w.Set(SchemaVersionKey, []byte(strconv.Itoa(version)))
+}
+
+// isEmpty checks if the store has no keys at all
+func isEmpty(r Reader) (bool, error) {
+	empty := true
+	err := r.ScanKeys("", func(key string) error {
+		empty = false
+		return ErrStopScan
+	})
+	return empty, err
+}
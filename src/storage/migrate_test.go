@@ -0,0 +1,221 @@
+package storage
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// testMigrations renames old_* keys to new_* in version 2 and adds a marker in version 3
+func testMigrations() []Migration {
+	return []Migration{
+		{Version: 1, Description: "baseline", Up: func(store Store) error { return nil }},
+		{Version: 2, Description: "rename keys", Up: func(store Store) error {
+			var keys []string
+			err := store.ScanKeys("old_", func(key string) error {
+				keys = append(keys, key)
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			return store.Update(func(tx Tx) error {
+				for _, key := range keys {
+					value, err := tx.Get(key)
+					if err != nil {
+						return err
+					}
+					if err := tx.Set("new_"+strings.TrimPrefix(key, "old_"), value); err != nil {
+						return err
+					}
+					if err := tx.Delete(key); err != nil {
+						return err
+					}
+				}
+				return nil
+			})
+		}},
+		{Version: 3, Description: "marker", Up: func(store Store) error {
+			return store.Set("marker", []byte("3"))
+		}},
+	}
+}
+
+func TestMigratorFreshDatabase(t *testing.T) {
+	store := NewMemoryStore()
+	migrator := NewMigrator(store)
+	migrator.Register(testMigrations()...)
+
+	result, err := migrator.Run(MigrateOptions{})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if result.FromVersion != 0 || result.ToVersion != 3 || len(result.Applied) != 0 {
+		t.Errorf("fresh Run = %+v, want 0 -> 3 without applied migrations", result)
+	}
+
+	if version, _ := migrator.CurrentVersion(); version != 3 {
+		t.Errorf("CurrentVersion = %d, want 3", version)
+	}
+	if _, err := store.Get("marker"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("migrations ran on a fresh database")
+	}
+}
+
+func TestMigratorAppliesPending(t *testing.T) {
+	store := NewMemoryStore()
+	store.Set(SchemaVersionKey, []byte("1"))
+	store.Set("old_a", []byte("A"))
+	store.Set("old_b", []byte("B"))
+
+	migrator := NewMigrator(store)
+	migrator.Register(testMigrations()...)
+
+	result, err := migrator.Run(MigrateOptions{})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if result.FromVersion != 1 || result.ToVersion != 3 || len(result.Applied) != 2 {
+		t.Errorf("Run = %+v, want 1 -> 3 with 2 applied", result)
+	}
+
+	for key, want := range map[string]string{"new_a": "A", "new_b": "B", "marker": "3", SchemaVersionKey: "3"} {
+		if value, err := store.Get(key); err != nil || string(value) != want {
+			t.Errorf("%s = %q, %v, want %q", key, value, err, want)
+		}
+	}
+	if _, err := store.Get("old_a"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("old_a still exists")
+	}
+
+	// A second run has nothing to do
+	result, err = migrator.Run(MigrateOptions{})
+	if err != nil || len(result.Applied) != 0 {
+		t.Errorf("second Run = %+v, %v, want nothing applied", result, err)
+	}
+}
+
+func TestMigratorDryRunWritesNothing(t *testing.T) {
+	store := NewMemoryStore()
+	store.Set(SchemaVersionKey, []byte("1"))
+	store.Set("old_a", []byte("A"))
+
+	before := snapshot(t, store)
+
+	migrator := NewMigrator(store)
+	migrator.Register(testMigrations()...)
+
+	result, err := migrator.Run(MigrateOptions{DryRun: true})
+	if err != nil {
+		t.Fatalf("dry Run: %v", err)
+	}
+	if result.ToVersion != 3 || len(result.Applied) != 2 || result.Writes == 0 {
+		t.Errorf("dry Run = %+v, want 2 applied migrations with counted writes", result)
+	}
+
+	after := snapshot(t, store)
+	if len(before) != len(after) {
+		t.Fatalf("dry run changed the store: %v -> %v", before, after)
+	}
+	for key, value := range before {
+		if after[key] != value {
+			t.Errorf("dry run changed %s: %q -> %q", key, value, after[key])
+		}
+	}
+}
+
+func TestMigratorFreshDryRunWritesNothing(t *testing.T) {
+	store := NewMemoryStore()
+	migrator := NewMigrator(store)
+	migrator.Register(testMigrations()...)
+
+	if _, err := migrator.Run(MigrateOptions{DryRun: true}); err != nil {
+		t.Fatalf("dry Run: %v", err)
+	}
+	if len(snapshot(t, store)) != 0 {
+		t.Errorf("dry run wrote to a fresh database")
+	}
+}
+
+func TestMigratorVersionErrors(t *testing.T) {
+	noop := func(store Store) error { return nil }
+
+	tests := []struct {
+		name       string
+		migrations []Migration
+		stored     string
+		wantErr    string
+	}{
+		{
+			name:       "gap",
+			migrations: []Migration{{Version: 1, Up: noop}, {Version: 3, Up: noop}},
+			wantErr:    "consecutive",
+		},
+		{
+			name:       "not from one",
+			migrations: []Migration{{Version: 2, Up: noop}},
+			wantErr:    "consecutive",
+		},
+		{
+			name:       "duplicate",
+			migrations: []Migration{{Version: 1, Up: noop}, {Version: 1, Up: noop}},
+			wantErr:    "consecutive",
+		},
+		{
+			name:       "newer database",
+			migrations: []Migration{{Version: 1, Up: noop}},
+			stored:     "5",
+			wantErr:    "newer",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := NewMemoryStore()
+			if tt.stored != "" {
+				store.Set(SchemaVersionKey, []byte(tt.stored))
+			}
+
+			migrator := NewMigrator(store)
+			migrator.Register(tt.migrations...)
+
+			_, err := migrator.Run(MigrateOptions{})
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Run error = %v, want it to mention %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMigratorRegisterSorts(t *testing.T) {
+	store := NewMemoryStore()
+	store.Set(SchemaVersionKey, []byte("1"))
+	store.Set("old_a", []byte("A"))
+
+	migrations := testMigrations()
+	migrator := NewMigrator(store)
+	migrator.Register(migrations[2], migrations[0], migrations[1])
+
+	result, err := migrator.Run(MigrateOptions{})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if len(result.Applied) != 2 || result.Applied[0] != 2 || result.Applied[1] != 3 {
+		t.Errorf("Applied = %v, want [2 3]", result.Applied)
+	}
+}
+
+// snapshot copies all keys and values of a store
+func snapshot(t *testing.T, store Store) map[string]string {
+	t.Helper()
+
+	data := make(map[string]string)
+	err := store.Scan("", func(key string, value []byte) error {
+		data[key] = string(value)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Scan: %v", err)
+	}
+	return data
+}
@@ -0,0 +1,88 @@
+package storage
+
+import (
+	"errors"
+)
+
+// ErrNotFound is returned when a key doesn't exist
+var ErrNotFound = errors.New("key not found")
+
+// ErrStopScan can be returned from a scan callback to stop iteration without an error
+var ErrStopScan = errors.New("stop scan")
+
+// Reader provides read access to keys
+type Reader interface {
+	// Get returns the value stored under key or ErrNotFound
+	Get(key string) ([]byte, error)
+	// Scan calls fn for every key with the given prefix in key order
+	Scan(prefix string, fn func(key string, value []byte) error) error
+	// ScanKeys calls fn for every key with the given prefix without loading values
+	ScanKeys(prefix string, fn func(key string) error) error
+}
+
+// Writer provides write access to keys
+type Writer interface {
+	Set(key string, value []byte) error
+	Delete(key string) error
+}
+
+// Tx is a read-write transaction
+type Tx interface {
+	Reader
+	Writer
+}
+
+// Store is a key-value store with prefix scans and transactions
+type Store interface {
+	Tx
+
+	// Update runs fn in a read-write transaction, committing if fn returns nil
+	Update(fn func(tx Tx) error) error
+	// View runs fn in a read-only transaction
+	View(fn func(tx Reader) error) error
+	// Batch applies writes in bulk; unlike Update it isn't atomic but has no size limit
+	Batch(fn func(w Writer) error) error
+	// Close releases the store
+	Close() error
+}
+
+// GarbageCollector is implemented by stores that need periodic space reclamation
+type GarbageCollector interface {
+	// CollectGarbage reclaims space and returns the number of rewritten files
+	CollectGarbage(discardRatio float64) (int, error)
+}
+
+// DeleteKeys removes keys in bulk
+func DeleteKeys(store Store, keys []string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	return store.Batch(func(w Writer) error {
+		for _, key := range keys {
+			if err := w.Delete(key); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// DeletePrefix removes all keys with the given prefix and returns the number of deleted keys
+func DeletePrefix(store Store, prefix string) (int, error) {
+	var keys []string
+
+	err := store.ScanKeys(prefix, func(key string) error {
+		keys = append(keys, key)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if err := DeleteKeys(store, keys); err != nil {
+		return 0, err
+	}
+
+	return len(keys), nil
+}
@@ -3,6 +3,7 @@ package utils
 import (
 	"bufio"
 	"bytes"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -23,6 +24,7 @@ const (
 	frontendVersion     = "prod-fe-1.0.57"
 	defaultUserLocation = "Russia"
 	defaultUserLanguage = "ru-RU"
+	defaultTimezone     = "Europe/Moscow"
 )
 
 var weekdaysRu = [...]string{
@@ -45,12 +47,17 @@ type AIClient struct {
 	maxRetryDelay time.Duration
 	userAgent     string
 	defaultModel  string
+	visionModel   string // Model used for messages with images, empty when the backend is text-only
 }
 
+// ErrVisionUnsupported is returned when images are sent to a text-only backend
+var ErrVisionUnsupported = errors.New("AI backend doesn't support images")
+
 // ChatMessage represents a message in the conversation
 type ChatMessage struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
+	Role    string   `json:"role"`
+	Content string   `json:"content"`
+	Images  []string `json:"-"` // Image data URLs, sent as content parts to vision models
 }
 
 // ChatRequest represents the request to Z.ai API
@@ -65,6 +72,7 @@ type ChatRequest struct {
 	ToolChoice   string        `json:"tool_choice,omitempty"`
 	UserName     string        `json:"-"`
 	UserLocation string        `json:"-"`
+	Timezone     string        `json:"-"` // IANA timezone of the date and time variables
 }
 
 // Tool represents a function that AI can call
@@ -142,12 +150,23 @@ func NewAIClient() (*AIClient, error) {
 		maxRetryDelay: 30 * time.Second,
 		userAgent:     "Mozilla/5.0 (X11; Linux x86_64; rv:140.0) Gecko/20100101 Firefox/140.0",
 		defaultModel:  "0727-360B-API",
+		visionModel:   os.Getenv("ZAI_VISION_MODEL"),
 		httpClient: &http.Client{
 			Timeout: 0,
 		},
 	}, nil
 }
 
+// SupportsVision reports whether messages may carry images
+func (ai *AIClient) SupportsVision() bool {
+	return ai.visionModel != ""
+}
+
+// ImageDataURL encodes an image as a data URL for ChatMessage.Images
+func ImageDataURL(data []byte) string {
+	return "data:" + http.DetectContentType(data) + ";base64," + base64.StdEncoding.EncodeToString(data)
+}
+
 // Chat sends a chat request to Z.ai with retry logic
 func (ai *AIClient) Chat(messages []ChatMessage, options ...ChatOption) (*ChatResponse, error) {
 	if len(messages) == 0 {
@@ -174,6 +193,16 @@ func (ai *AIClient) Chat(messages []ChatMessage, options ...ChatOption) (*ChatRe
 		req.Model = ai.defaultModel
 	}
 
+	// Images need a vision model
+	if hasImages(req.Messages) {
+		if !ai.SupportsVision() {
+			return nil, ErrVisionUnsupported
+		}
+		if req.Model == ai.defaultModel {
+			req.Model = ai.visionModel
+		}
+	}
+
 	firstUser := ""
 	for _, msg := range req.Messages {
 		if msg.Role == "user" {
@@ -187,7 +216,7 @@ func (ai *AIClient) Chat(messages []ChatMessage, options ...ChatOption) (*ChatRe
 
 	var lastErr error
 	for attempt := 0; attempt <= ai.maxRetries; attempt++ {
-		chatID, err := ai.createChat(firstUser)
+		chatID, err := ai.createChat(req.Model, firstUser)
 		if err != nil {
 			lastErr = err
 			if !ai.isRetryableError(err) || attempt == ai.maxRetries {
@@ -312,6 +341,13 @@ func WithUserContext(name, location string) ChatOption {
 	}
 }
 
+// WithTimezone sets the timezone of the current date and time given to the model
+func WithTimezone(timezone string) ChatOption {
+	return func(req *ChatRequest) {
+		req.Timezone = timezone
+	}
+}
+
 // WithSystemMessage adds a system message
 func WithSystemMessage(content string) ChatOption {
 	return func(req *ChatRequest) {
@@ -340,7 +376,7 @@ func (ai *AIClient) GetUsageStats(resp *ChatResponse) (promptTokens, completionT
 	return 0, 0, 0
 }
 
-func (ai *AIClient) createChat(firstMessage string) (string, error) {
+func (ai *AIClient) createChat(model, firstMessage string) (string, error) {
 	firstMessage = clipUserInput(firstMessage)
 	timestamp := time.Now().Unix()
 	messageID := uuid.NewString()
@@ -349,7 +385,7 @@ func (ai *AIClient) createChat(firstMessage string) (string, error) {
 		"chat": map[string]interface{}{
 			"id":     "",
 			"title":  "BrevX Chat",
-			"models": []string{ai.defaultModel},
+			"models": []string{model},
 			"params": map[string]interface{}{},
 			"history": map[string]interface{}{
 				"messages": map[string]interface{}{
@@ -360,7 +396,7 @@ func (ai *AIClient) createChat(firstMessage string) (string, error) {
 						"role":        "user",
 						"content":     firstMessage,
 						"timestamp":   timestamp,
-						"models":      []string{ai.defaultModel},
+						"models":      []string{model},
 					},
 				},
 				"currentId": messageID,
@@ -373,7 +409,7 @@ func (ai *AIClient) createChat(firstMessage string) (string, error) {
 					"role":        "user",
 					"content":     firstMessage,
 					"timestamp":   timestamp,
-					"models":      []string{ai.defaultModel},
+					"models":      []string{model},
 				},
 			},
 			"tags":  []string{},
@@ -427,7 +463,13 @@ func (ai *AIClient) createChat(firstMessage string) (string, error) {
 }
 
 func (ai *AIClient) streamCompletion(chatID string, req *ChatRequest) (string, *UsageStats, error) {
-	now := time.Now().In(time.FixedZone("Europe/Moscow", 3*3600))
+	timezone := req.Timezone
+	location, err := time.LoadLocation(timezone)
+	if timezone == "" || err != nil {
+		timezone = defaultTimezone
+		location = time.FixedZone(defaultTimezone, 3*3600)
+	}
+	now := time.Now().In(location)
 	variables := map[string]string{
 		"{{USER_NAME}}":        req.UserName,
 		"{{USER_LOCATION}}":    req.UserLocation,
@@ -435,7 +477,7 @@ func (ai *AIClient) streamCompletion(chatID string, req *ChatRequest) (string, *
 		"{{CURRENT_DATE}}":     now.Format("02.01.2006"),
 		"{{CURRENT_TIME}}":     now.Format("15:04:05"),
 		"{{CURRENT_WEEKDAY}}":  formatWeekdayRu(now),
-		"{{CURRENT_TIMEZONE}}": "Europe/Moscow",
+		"{{CURRENT_TIMEZONE}}": timezone,
 		"{{USER_LANGUAGE}}":    defaultUserLanguage,
 	}
 
@@ -446,7 +488,7 @@ func (ai *AIClient) streamCompletion(chatID string, req *ChatRequest) (string, *
 	payload := map[string]interface{}{
 		"stream":   true,
 		"model":    req.Model,
-		"messages": req.Messages,
+		"messages": payloadMessages(req.Messages),
 		"params": map[string]interface{}{
 			"temperature": req.Temperature,
 			"top_p":       req.TopP,
@@ -678,6 +720,24 @@ func cleanResponse(text string) string {
 	return cleaned
 }
 
+// ExtractJSON returns the JSON object or array in a model answer, dropping code fences and chatter around it
+func ExtractJSON(text string) string {
+	start := strings.IndexAny(text, "{[")
+	if start < 0 {
+		return ""
+	}
+
+	closer := "}"
+	if text[start] == '[' {
+		closer = "]"
+	}
+	end := strings.LastIndex(text, closer)
+	if end < start {
+		return ""
+	}
+	return text[start : end+1]
+}
+
 func clipUserInput(text string) string {
 	trimmed := strings.TrimSpace(text)
 	runes := []rune(trimmed)
@@ -717,6 +777,41 @@ func trimMessages(messages []ChatMessage) []ChatMessage {
 	return append(system, rest...)
 }
 
+func hasImages(messages []ChatMessage) bool {
+	for _, msg := range messages {
+		if len(msg.Images) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// payloadMessages converts messages with images to OpenAI-style content parts
+func payloadMessages(messages []ChatMessage) []interface{} {
+	result := make([]interface{}, 0, len(messages))
+	for _, msg := range messages {
+		if len(msg.Images) == 0 {
+			result = append(result, msg)
+			continue
+		}
+
+		parts := []map[string]interface{}{
+			{"type": "text", "text": msg.Content},
+		}
+		for _, image := range msg.Images {
+			parts = append(parts, map[string]interface{}{
+				"type":      "image_url",
+				"image_url": map[string]string{"url": image},
+			})
+		}
+		result = append(result, map[string]interface{}{
+			"role":    msg.Role,
+			"content": parts,
+		})
+	}
+	return result
+}
+
 func formatWeekdayRu(t time.Time) string {
 	weekday := int(t.Weekday())
 	if weekday < 0 || weekday >= len(weekdaysRu) {
@@ -0,0 +1,84 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"image/color"
+	"math/rand"
+
+	"github.com/fogleman/gg"
+)
+
+// GenerateCaptchaImage renders challenge text with rotated letters and noise so scripts can't just read it
+func GenerateCaptchaImage(text string) ([]byte, error) {
+	const width = 480
+	const height = 160
+
+	face := fontFace(56, true)
+	if face == nil {
+		return nil, fmt.Errorf("captcha font isn't available")
+	}
+
+	dc := gg.NewContext(width, height)
+
+	// Light background with random tint
+	dc.SetColor(color.RGBA{uint8(220 + rand.Intn(30)), uint8(220 + rand.Intn(30)), uint8(220 + rand.Intn(30)), 255})
+	dc.Clear()
+
+	// Background noise: dots and arcs
+	for i := 0; i < 400; i++ {
+		dc.SetColor(randomColor(100, 200))
+		dc.DrawPoint(rand.Float64()*width, rand.Float64()*height, 1+rand.Float64()*1.5)
+		dc.Fill()
+	}
+	for i := 0; i < 6; i++ {
+		dc.SetColor(randomColor(80, 180))
+		dc.SetLineWidth(1 + rand.Float64()*2)
+		dc.DrawArc(rand.Float64()*width, rand.Float64()*height, 30+rand.Float64()*80, 0, 3+rand.Float64()*3)
+		dc.Stroke()
+	}
+
+	// Letters one by one with own rotation, size jitter and baseline
+	dc.SetFontFace(face)
+	runes := []rune(text)
+	totalWidth := 0.0
+	for _, r := range runes {
+		w, _ := dc.MeasureString(string(r))
+		totalWidth += w + 4
+	}
+	x := (width - totalWidth) / 2
+
+	for _, r := range runes {
+		w, _ := dc.MeasureString(string(r))
+		y := height/2 + (rand.Float64()-0.5)*24
+
+		dc.Push()
+		dc.RotateAbout(gg.Radians((rand.Float64()-0.5)*40), x+w/2, y)
+		dc.SetColor(randomColor(10, 110))
+		dc.DrawStringAnchored(string(r), x+w/2, y, 0.5, 0.35)
+		dc.Pop()
+
+		x += w + 4
+	}
+
+	// Strike-through lines over the text
+	for i := 0; i < 3; i++ {
+		dc.SetColor(randomColor(30, 130))
+		dc.SetLineWidth(2 + rand.Float64()*2)
+		dc.MoveTo(0, rand.Float64()*height)
+		dc.CubicTo(width/3, rand.Float64()*height, 2*width/3, rand.Float64()*height, width, rand.Float64()*height)
+		dc.Stroke()
+	}
+
+	var buf bytes.Buffer
+	if err := dc.EncodePNG(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// randomColor returns an opaque color with channels in [lo, hi)
+func randomColor(lo, hi int) color.Color {
+	channel := func() uint8 { return uint8(lo + rand.Intn(hi-lo)) }
+	return color.RGBA{channel(), channel(), channel(), 255}
+}
@@ -0,0 +1,66 @@
+package utils
+
+import (
+	"sync"
+	"time"
+)
+
+// CooldownTracker remembers when keys were last used and enforces a minimum interval between uses
+type CooldownTracker struct {
+	lastUsed  map[string]time.Time
+	lastPrune time.Time
+	mu        sync.Mutex
+}
+
+// cooldownPruneInterval is how often expired entries are dropped
+const cooldownPruneInterval = 10 * time.Minute
+
+// cooldownMaxAge is how long entries are kept; longer cooldowns aren't supported
+const cooldownMaxAge = 24 * time.Hour
+
+// NewCooldownTracker creates a new cooldown tracker
+func NewCooldownTracker() *CooldownTracker {
+	return &CooldownTracker{
+		lastUsed:  make(map[string]time.Time),
+		lastPrune: time.Now(),
+	}
+}
+
+// Remaining returns how long key is still on cooldown, or 0 if it can be used
+func (ct *CooldownTracker) Remaining(key string, cooldown time.Duration) time.Duration {
+	if cooldown <= 0 {
+		return 0
+	}
+
+	ct.mu.Lock()
+	defer ct.mu.Unlock()
+
+	last, ok := ct.lastUsed[key]
+	if !ok {
+		return 0
+	}
+
+	remaining := cooldown - time.Since(last)
+	if remaining < 0 {
+		return 0
+	}
+	return remaining
+}
+
+// Touch marks key as used now
+func (ct *CooldownTracker) Touch(key string) {
+	ct.mu.Lock()
+	defer ct.mu.Unlock()
+
+	now := time.Now()
+	ct.lastUsed[key] = now
+
+	if now.Sub(ct.lastPrune) > cooldownPruneInterval {
+		for k, last := range ct.lastUsed {
+			if now.Sub(last) > cooldownMaxAge {
+				delete(ct.lastUsed, k)
+			}
+		}
+		ct.lastPrune = now
+	}
+}
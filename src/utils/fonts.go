@@ -0,0 +1,43 @@
+package utils
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/golang/freetype/truetype"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/goregular"
+)
+
+var (
+	fontsOnce   sync.Once
+	regularFont *truetype.Font
+	boldFont    *truetype.Font
+)
+
+// loadFonts parses the embedded Go fonts, they cover Latin and Cyrillic
+func loadFonts() {
+	var err error
+	if regularFont, err = truetype.Parse(goregular.TTF); err != nil {
+		fmt.Printf("[-] Failed to parse regular font: %v\n", err)
+	}
+	if boldFont, err = truetype.Parse(gobold.TTF); err != nil {
+		fmt.Printf("[-] Failed to parse bold font: %v\n", err)
+	}
+}
+
+// fontFace returns a font face of the given size, nil if fonts failed to load
+func fontFace(size float64, bold bool) font.Face {
+	fontsOnce.Do(loadFonts)
+
+	parsed := regularFont
+	if bold {
+		parsed = boldFont
+	}
+	if parsed == nil {
+		return nil
+	}
+
+	return truetype.NewFace(parsed, &truetype.Options{Size: size})
+}
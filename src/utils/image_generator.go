@@ -129,8 +129,21 @@ func (ac *AvatarCache) loadImageFromFile(path string) (image.Image, error) {
 	return img, err
 }
 
-// GenerateTopUsersImage generates a beautiful image with top users on a podium using gg library
-func GenerateTopUsersImage(users []models.UserStats, bot *telebot.Bot) ([]byte, error) {
+// PodiumLabels holds the title and the value caption drawn on a podium image
+type PodiumLabels struct {
+	Title string
+	Value func(count int) string
+}
+
+// ActivityPodium labels the podium of the most active users
+var ActivityPodium = PodiumLabels{
+	Title: "📊 Статистика активности",
+	Value: func(count int) string { return fmt.Sprintf("%d сообщений", count) },
+}
+
+// GenerateTopUsersImage generates a beautiful image with top users on a podium using gg library.
+// MessageCount of each user is drawn through labels.Value.
+func GenerateTopUsersImage(users []models.UserStats, bot *telebot.Bot, labels PodiumLabels) ([]byte, error) {
 	if len(users) == 0 {
 		return nil, fmt.Errorf("no users provided")
 	}
@@ -183,12 +196,12 @@ func GenerateTopUsersImage(users []models.UserStats, bot *telebot.Bot) ([]byte,
 		// Draw medal
 		drawMedal(dc, pos.x, pos.y + avatarRadius + 80, pos.medal)
 		
-		// Draw message count
-		drawMessageCount(dc, pos.x, pos.y + avatarRadius + 110, user.MessageCount)
+		// Draw message count or another value
+		drawMessageCount(dc, pos.x, pos.y + avatarRadius + 110, labels.Value(user.MessageCount))
 	}
 
 	// 4. Draw title
-	drawTitle(dc, width, height)
+	drawTitle(dc, width, height, labels.Title)
 
 	// Convert to PNG
 	var buf bytes.Buffer
@@ -329,9 +342,7 @@ func drawMedal(dc *gg.Context, x, y int, medal string) {
 }
 
 // drawMessageCount draws message count
-func drawMessageCount(dc *gg.Context, x, y int, count int) {
-	text := fmt.Sprintf("%d сообщений", count)
-	
+func drawMessageCount(dc *gg.Context, x, y int, text string) {
 	// Draw text
 	dc.SetColor(color.RGBA{200, 200, 200, 255})
 	dc.LoadFontFace("", 18)
@@ -339,9 +350,7 @@ func drawMessageCount(dc *gg.Context, x, y int, count int) {
 }
 
 // drawTitle draws title
-func drawTitle(dc *gg.Context, width, height int) {
-	title := "📊 Статистика активности"
-	
+func drawTitle(dc *gg.Context, width, height int, title string) {
 	// Draw title
 	dc.SetColor(color.RGBA{255, 255, 255, 255})
 	dc.LoadFontFace("", 32)
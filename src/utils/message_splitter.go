@@ -105,22 +105,25 @@ func (ms *MessageSplitter) SendLongMessage(c telebot.Context, text string, optio
 	return nil
 }
 
-// EditLongMessage edits a message, handling length limits
-func (ms *MessageSplitter) EditLongMessage(bot *telebot.Bot, message *telebot.Message, text string, options *telebot.SendOptions) error {
+// EditLongMessage edits a message, handling length limits.
+// The reply markup of options goes to the last part. Returns all parts in order, the edited message first.
+func (ms *MessageSplitter) EditLongMessage(bot *telebot.Bot, message *telebot.Message, text string, options *telebot.SendOptions) ([]*telebot.Message, error) {
 	// Sanitize text for Telegram
 	sanitizedText := ms.utf8Validator.SanitizeForTelegram(text)
 	
 	// If the message is short enough, just edit it
 	if utf8.RuneCountInString(sanitizedText) <= SafeMessageLength {
-		_, err := bot.Edit(message, sanitizedText, options)
-		return err
+		if _, err := bot.Edit(message, sanitizedText, options); err != nil {
+			return nil, err
+		}
+		return []*telebot.Message{message}, nil
 	}
 
 	// If too long, edit with truncated version and send continuation
 	parts := ms.SplitMessage(sanitizedText, SafeMessageLength)
 	
 	if len(parts) == 0 {
-		return fmt.Errorf("no parts to send")
+		return nil, fmt.Errorf("no parts to send")
 	}
 
 	// Edit original message with first part
@@ -129,25 +132,32 @@ func (ms *MessageSplitter) EditLongMessage(bot *telebot.Bot, message *telebot.Me
 		firstPart += fmt.Sprintf("\n\n<i>(1/%d) Продолжение следует...</i>", len(parts))
 	}
 	
-	_, err := bot.Edit(message, firstPart, options)
+	_, err := bot.Edit(message, firstPart, &telebot.SendOptions{ParseMode: options.ParseMode})
 	if err != nil {
-		return fmt.Errorf("failed to edit original message: %w", err)
+		return nil, fmt.Errorf("failed to edit original message: %w", err)
 	}
 
 	// Send remaining parts as new messages
+	sent := []*telebot.Message{message}
 	for i := 1; i < len(parts); i++ {
 		part := fmt.Sprintf("<i>(%d/%d)</i>\n\n%s", i+1, len(parts), parts[i])
 		
-		_, err := bot.Send(message.Chat, part, &telebot.SendOptions{
+		partOptions := &telebot.SendOptions{
 			ParseMode: options.ParseMode,
 			ReplyTo:   message,
-		})
+		}
+		if i == len(parts)-1 {
+			partOptions.ReplyMarkup = options.ReplyMarkup
+		}
+		
+		partMsg, err := bot.Send(message.Chat, part, partOptions)
 		if err != nil {
-			return fmt.Errorf("failed to send continuation part %d: %w", i+1, err)
+			return nil, fmt.Errorf("failed to send continuation part %d: %w", i+1, err)
 		}
+		sent = append(sent, partMsg)
 	}
 
-	return nil
+	return sent, nil
 }
 
 // TruncateMessage truncates a message to fit within limits
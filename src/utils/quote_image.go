@@ -0,0 +1,257 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/fogleman/gg"
+	"golang.org/x/image/font"
+	"gopkg.in/telebot.v3"
+	"gobrev/src/models"
+)
+
+// Quote image layout
+const (
+	quoteWidth        = 720
+	quotePadding      = 24
+	quoteAvatarRadius = 26
+	quoteBubbleX      = quotePadding + 2*quoteAvatarRadius + 14
+	quoteBubblePad    = 14
+	quoteTextWidth    = quoteWidth - quoteBubbleX - quotePadding - 2*quoteBubblePad
+	quoteGroupGap     = 16
+	quoteLineGap      = 6
+	quoteMaxTextLines = 30
+	quoteMaxTextRunes = 1500
+)
+
+// quoteNameColors are name colors picked by user ID, like Telegram does
+var quoteNameColors = []string{"#ff8e86", "#ffc870", "#b8a4ff", "#78e08f", "#70d0ff", "#ff9ed0", "#5fd9cc", "#ffb27a"}
+
+// quoteBubble is one laid out message
+type quoteBubble struct {
+	line       models.QuoteLine
+	showHeader bool // First message of a group from the same author shows the avatar and name
+	text       []string
+	width      float64
+	height     float64
+}
+
+// GenerateQuoteImage renders quoted messages as chat bubbles with avatars, names and times in loc
+func GenerateQuoteImage(lines []models.QuoteLine, bot *telebot.Bot, loc *time.Location) ([]byte, error) {
+	if len(lines) == 0 {
+		return nil, fmt.Errorf("no messages provided")
+	}
+
+	nameFace := fontFace(22, true)
+	textFace := fontFace(24, false)
+	timeFace := fontFace(16, false)
+	if nameFace == nil || textFace == nil || timeFace == nil {
+		return nil, fmt.Errorf("quote font isn't available")
+	}
+
+	// Lay out bubbles first to know the image height
+	measure := gg.NewContext(1, 1)
+	bubbles := make([]quoteBubble, len(lines))
+	height := float64(quotePadding * 2)
+	for i, line := range lines {
+		bubble := quoteBubble{
+			line:       line,
+			showHeader: i == 0 || lines[i-1].UserID != line.UserID,
+		}
+
+		measure.SetFontFace(textFace)
+		bubble.text = wrapQuoteText(measure, line.Text, quoteTextWidth)
+		_, lineHeight := measure.MeasureString("Ag")
+		for _, text := range bubble.text {
+			w, _ := measure.MeasureString(text)
+			bubble.width = math.Max(bubble.width, w)
+		}
+		bubble.height = float64(len(bubble.text)) * (lineHeight + quoteLineGap)
+
+		measure.SetFontFace(timeFace)
+		timeWidth, timeHeight := measure.MeasureString(formatQuoteTime(line.Timestamp, loc))
+		bubble.width = math.Max(bubble.width, timeWidth)
+		bubble.height += timeHeight + quoteLineGap
+
+		if bubble.showHeader {
+			measure.SetFontFace(nameFace)
+			nameWidth, nameHeight := measure.MeasureString(line.Username)
+			bubble.width = math.Max(bubble.width, math.Min(nameWidth, quoteTextWidth))
+			bubble.height += nameHeight + quoteLineGap*2
+		}
+
+		bubble.width += 2 * quoteBubblePad
+		bubble.height += 2 * quoteBubblePad
+		bubble.height = math.Max(bubble.height, 2*quoteAvatarRadius)
+		bubbles[i] = bubble
+
+		height += bubble.height
+		if i > 0 {
+			height += quoteGap(bubble)
+		}
+	}
+
+	dc := gg.NewContext(quoteWidth, int(height))
+	dc.SetColor(parseColor("#0e1621"))
+	dc.Clear()
+
+	avatarCache := NewAvatarCache(bot)
+	y := float64(quotePadding)
+	for i, bubble := range bubbles {
+		if i > 0 {
+			y += quoteGap(bubble)
+		}
+		drawQuoteBubble(dc, y, bubble, avatarCache, nameFace, textFace, timeFace, loc)
+		y += bubble.height
+	}
+
+	var buf bytes.Buffer
+	if err := dc.EncodePNG(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// quoteGap returns the space above a bubble, messages of one author stay closer together
+func quoteGap(bubble quoteBubble) float64 {
+	if bubble.showHeader {
+		return quoteGroupGap
+	}
+	return quoteLineGap
+}
+
+// drawQuoteBubble draws one message with its avatar, name, text and time
+func drawQuoteBubble(dc *gg.Context, y float64, bubble quoteBubble, cache *AvatarCache, nameFace, textFace, timeFace font.Face, loc *time.Location) {
+	nameColor := parseColor(quoteNameColors[int(uint64(bubble.line.UserID)%uint64(len(quoteNameColors)))])
+
+	if bubble.showHeader {
+		drawQuoteAvatar(dc, quotePadding+quoteAvatarRadius, y+quoteAvatarRadius, bubble.line, nameColor, cache, nameFace)
+	}
+
+	dc.DrawRoundedRectangle(quoteBubbleX, y, bubble.width, bubble.height, 16)
+	dc.SetColor(parseColor("#182533"))
+	dc.Fill()
+
+	x := float64(quoteBubbleX + quoteBubblePad)
+	cursor := y + quoteBubblePad
+
+	if bubble.showHeader {
+		dc.SetFontFace(nameFace)
+		_, nameHeight := dc.MeasureString(bubble.line.Username)
+		dc.SetColor(nameColor)
+		dc.DrawStringAnchored(clipToWidth(dc, bubble.line.Username, quoteTextWidth), x, cursor, 0, 1)
+		cursor += nameHeight + quoteLineGap*2
+	}
+
+	dc.SetFontFace(textFace)
+	_, lineHeight := dc.MeasureString("Ag")
+	dc.SetColor(color.RGBA{245, 245, 245, 255})
+	for _, text := range bubble.text {
+		dc.DrawStringAnchored(text, x, cursor, 0, 1)
+		cursor += lineHeight + quoteLineGap
+	}
+
+	dc.SetFontFace(timeFace)
+	dc.SetColor(parseColor("#6d7f8f"))
+	dc.DrawStringAnchored(formatQuoteTime(bubble.line.Timestamp, loc), quoteBubbleX+bubble.width-quoteBubblePad, y+bubble.height-quoteBubblePad, 1, 0)
+}
+
+// drawQuoteAvatar draws a round avatar scaled to the circle, or an initial on a colored circle
+func drawQuoteAvatar(dc *gg.Context, x, y float64, line models.QuoteLine, fill color.Color, cache *AvatarCache, face font.Face) {
+	var avatar image.Image
+	if line.UserID > 0 {
+		avatar, _ = cache.GetUserAvatar(line.UserID)
+	}
+
+	if avatar == nil {
+		dc.DrawCircle(x, y, quoteAvatarRadius)
+		dc.SetColor(fill)
+		dc.Fill()
+
+		initial := "?"
+		for _, r := range line.Username {
+			if unicode.IsLetter(r) || unicode.IsDigit(r) {
+				initial = strings.ToUpper(string(r))
+				break
+			}
+		}
+		dc.SetFontFace(face)
+		dc.SetColor(color.RGBA{255, 255, 255, 255})
+		dc.DrawStringAnchored(initial, x, y, 0.5, 0.35)
+		return
+	}
+
+	bounds := avatar.Bounds()
+	scale := 2 * quoteAvatarRadius / float64(min(bounds.Dx(), bounds.Dy()))
+
+	dc.Push()
+	dc.DrawCircle(x, y, quoteAvatarRadius)
+	dc.Clip()
+	dc.Translate(x, y)
+	dc.Scale(scale, scale)
+	dc.DrawImageAnchored(avatar, 0, 0, 0.5, 0.5)
+	dc.Pop()
+	dc.ResetClip()
+}
+
+// wrapQuoteText wraps text to width, splitting words that don't fit on a line by themselves
+func wrapQuoteText(dc *gg.Context, text string, width float64) []string {
+	if runes := []rune(text); len(runes) > quoteMaxTextRunes {
+		text = string(runes[:quoteMaxTextRunes]) + "…"
+	}
+
+	var lines []string
+	for _, line := range dc.WordWrap(text, width) {
+		for {
+			if w, _ := dc.MeasureString(line); w <= width {
+				break
+			}
+			runes := []rune(line)
+			cut := len(runes) - 1
+			for cut > 1 {
+				if w, _ := dc.MeasureString(string(runes[:cut])); w <= width {
+					break
+				}
+				cut--
+			}
+			lines = append(lines, string(runes[:cut]))
+			line = string(runes[cut:])
+		}
+		lines = append(lines, line)
+	}
+
+	if len(lines) > quoteMaxTextLines {
+		lines = append(lines[:quoteMaxTextLines-1], "…")
+	}
+	return lines
+}
+
+// clipToWidth shortens text with an ellipsis so it fits width
+func clipToWidth(dc *gg.Context, text string, width float64) string {
+	if w, _ := dc.MeasureString(text); w <= width {
+		return text
+	}
+	runes := []rune(text)
+	for len(runes) > 1 {
+		runes = runes[:len(runes)-1]
+		if w, _ := dc.MeasureString(string(runes) + "…"); w <= width {
+			break
+		}
+	}
+	return string(runes) + "…"
+}
+
+// formatQuoteTime formats a message time, adding the date for messages of other days
+func formatQuoteTime(timestamp int64, loc *time.Location) string {
+	at := time.Unix(timestamp, 0).In(loc)
+	if at.Format("2006-01-02") == time.Now().In(loc).Format("2006-01-02") {
+		return at.Format("15:04")
+	}
+	return at.Format("02.01.2006 15:04")
+}
@@ -0,0 +1,120 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"time"
+
+	"gopkg.in/telebot.v3"
+)
+
+// maxTranscribeFileSize is the largest file the Bot API lets bots download
+const maxTranscribeFileSize = 20 << 20
+
+// Transcriber converts speech to text
+type Transcriber interface {
+	Transcribe(audio io.Reader, filename string) (string, error)
+}
+
+// WhisperTranscriber calls an OpenAI-compatible /audio/transcriptions endpoint
+// (OpenAI, Groq, a local whisper.cpp or faster-whisper server)
+type WhisperTranscriber struct {
+	baseURL    string
+	apiKey     string
+	model      string
+	language   string
+	httpClient *http.Client
+}
+
+// NewWhisperTranscriber creates a transcriber for the API at baseURL (e.g. http://localhost:8080/v1)
+func NewWhisperTranscriber(baseURL, apiKey, model, language string) *WhisperTranscriber {
+	return &WhisperTranscriber{
+		baseURL:  strings.TrimRight(baseURL, "/"),
+		apiKey:   apiKey,
+		model:    model,
+		language: language,
+		httpClient: &http.Client{
+			Timeout: 2 * time.Minute,
+		},
+	}
+}
+
+// Transcribe uploads audio and returns the recognized text
+func (t *WhisperTranscriber) Transcribe(audio io.Reader, filename string) (string, error) {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+
+	part, err := form.CreateFormFile("file", filename)
+	if err != nil {
+		return "", fmt.Errorf("failed to create form file: %w", err)
+	}
+	if _, err := io.Copy(part, audio); err != nil {
+		return "", fmt.Errorf("failed to read audio: %w", err)
+	}
+
+	fields := map[string]string{
+		"model":           t.model,
+		"language":        t.language,
+		"response_format": "json",
+	}
+	for name, value := range fields {
+		if value == "" {
+			continue
+		}
+		if err := form.WriteField(name, value); err != nil {
+			return "", fmt.Errorf("failed to write form field %s: %w", name, err)
+		}
+	}
+	if err := form.Close(); err != nil {
+		return "", fmt.Errorf("failed to finish form: %w", err)
+	}
+
+	req, err := http.NewRequest("POST", t.baseURL+"/audio/transcriptions", &body)
+	if err != nil {
+		return "", fmt.Errorf("failed to create transcription request: %w", err)
+	}
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	if t.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+t.apiKey)
+	}
+
+	resp, err := t.httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("transcription failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var result struct {
+		Text string `json:"text"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("failed to decode transcription response: %w", err)
+	}
+
+	return strings.TrimSpace(result.Text), nil
+}
+
+// TranscribeFile downloads a Telegram file and transcribes it
+func TranscribeFile(bot *telebot.Bot, transcriber Transcriber, file *telebot.File, filename string) (string, error) {
+	if file.FileSize > maxTranscribeFileSize {
+		return "", fmt.Errorf("file is too large: %d bytes", file.FileSize)
+	}
+
+	reader, err := bot.File(file)
+	if err != nil {
+		return "", fmt.Errorf("failed to download file: %w", err)
+	}
+	defer reader.Close()
+
+	return transcriber.Transcribe(reader, filename)
+}